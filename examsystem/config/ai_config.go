@@ -2,21 +2,168 @@
 package config
 
 import (
+	"encoding/json"
+	"log"
 	"os"
+	"time"
 )
 
+// AI模型接口类型
+const (
+	AIProviderTypeOpenAI = "openai" // OpenAI 兼容的 /chat/completions 接口
+	AIProviderTypeOllama = "ollama" // Ollama 的 /api/generate 接口
+	AIProviderTypeMock   = "mock"   // 内置模拟模型，不访问网络，用于开发和自动化测试
+)
+
+// AIProviderConfig 单个AI模型的配置
+type AIProviderConfig struct {
+	Name           string   `json:"name"`            // 生成题目时传入的 ai_model 取值
+	Type           string   `json:"type"`            // 接口类型，默认为 openai
+	URL            string   `json:"url"`             // 接口地址
+	APIKey         string   `json:"api_key"`         // 接口密钥
+	APIKeyEnv      string   `json:"api_key_env"`     // 从该环境变量读取接口密钥，避免将密钥写入配置
+	Model          string   `json:"model"`           // 请求中使用的模型名称
+	MaxTokens      int      `json:"max_tokens"`      // 单次请求最大生成token数
+	Temperature    *float64 `json:"temperature"`     // 生成的随机性，范围0-1，未配置时为0.7
+	TimeoutSeconds int      `json:"timeout_seconds"` // 非流式请求的超时时间，流式请求只限制等待响应头的时间
+
+	// 以下仅用于模拟模型
+	Seed          int64   `json:"seed"`           // 随机种子，相同种子和请求总是生成相同的题目
+	MalformedRate float64 `json:"malformed_rate"` // 故意生成不合格题目的比例，范围0-1
+	ScriptFile    string  `json:"script_file"`    // 脚本文件（JSON字符串数组），设置后按顺序循环返回其中的内容
+	DelayMs       int     `json:"delay_ms"`       // 模拟的响应延迟（毫秒）
+}
+
+// AIGenerationConfig 批量生成题目的配置
+type AIGenerationConfig struct {
+	// 单次请求生成的最大题目数量，超过时拆分为多个批次，避免输出超过 max_tokens 被截断
+	ChunkSize int
+	// 同时进行的批次请求数量
+	Concurrency int
+	// 单次生成的最大题目数量
+	MaxQuestions int
+	// 判定为相似题目的相似度阈值（0-1），不大于0时不检查相似题目
+	DuplicateThreshold float64
+	// 审核题目时默认使用的AI模型，为空时使用生成题目的模型
+	ReviewModel string
+	// 待确认题目的保留时间，超过后由定时清理任务删除
+	DraftTTL time.Duration
+	// 清理过期待确认题目的执行间隔
+	DraftCleanupInterval time.Duration
+}
+
+func LoadAIGenerationConfig() AIGenerationConfig {
+	return AIGenerationConfig{
+		ChunkSize:            getEnvInt("AI_GENERATE_CHUNK_SIZE", 5),
+		Concurrency:          getEnvInt("AI_GENERATE_CONCURRENCY", 3),
+		MaxQuestions:         getEnvInt("AI_GENERATE_MAX_QUESTIONS", 100),
+		DuplicateThreshold:   getEnvFloat("AI_DUPLICATE_THRESHOLD", 0.7),
+		ReviewModel:          os.Getenv("AI_REVIEW_MODEL"),
+		DraftTTL:             time.Duration(getEnvInt("QUESTION_DRAFT_TTL", 72)) * time.Hour,                // 默认72小时
+		DraftCleanupInterval: time.Duration(getEnvInt("QUESTION_DRAFT_CLEANUP_INTERVAL", 60)) * time.Minute, // 默认60分钟
+	}
+}
+
 type AIConfig struct {
 	TongyiAPIKey   string
 	DeepSeekAPIKey string
 	TongyiAPIURL   string
 	DeepSeekAPIURL string
+	// 可用的AI模型列表：内置的 deepseek、通义千问 以及 AI_PROVIDERS 中配置的模型
+	Providers []AIProviderConfig
 }
 
 func LoadAIConfig() AIConfig {
-	return AIConfig{
+	aiConfig := AIConfig{
 		TongyiAPIKey:   os.Getenv("TONGYI_API_KEY"),
 		DeepSeekAPIKey: os.Getenv("DEEPSEEK_API_KEY"),
 		TongyiAPIURL:   os.Getenv("TONGYI_API_URL"),
 		DeepSeekAPIURL: os.Getenv("DEEPSEEK_API_URL"),
 	}
+
+	// 内置模型，仅在配置了接口地址时启用
+	if aiConfig.DeepSeekAPIURL != "" {
+		aiConfig.Providers = append(aiConfig.Providers, AIProviderConfig{
+			Name:   "deepseek",
+			URL:    aiConfig.DeepSeekAPIURL,
+			APIKey: aiConfig.DeepSeekAPIKey,
+			Model:  getEnv("DEEPSEEK_MODEL", "deepseek-chat"),
+		})
+	}
+	if aiConfig.TongyiAPIURL != "" {
+		aiConfig.Providers = append(aiConfig.Providers, AIProviderConfig{
+			Name:   "通义千问",
+			URL:    aiConfig.TongyiAPIURL,
+			APIKey: aiConfig.TongyiAPIKey,
+			Model:  getEnv("TONGYI_MODEL", "qwen-plus"),
+		})
+	}
+
+	// 本地部署的模型，用于无法访问外网的环境
+	if url := os.Getenv("LOCAL_LLM_API_URL"); url != "" {
+		aiConfig.Providers = append(aiConfig.Providers, AIProviderConfig{
+			Name:   "local",
+			URL:    url,
+			APIKey: os.Getenv("LOCAL_LLM_API_KEY"),
+			Model:  os.Getenv("LOCAL_LLM_MODEL"),
+		})
+	}
+	if url := os.Getenv("OLLAMA_API_URL"); url != "" {
+		aiConfig.Providers = append(aiConfig.Providers, AIProviderConfig{
+			Name:  "ollama",
+			Type:  AIProviderTypeOllama,
+			URL:   url,
+			Model: getEnv("OLLAMA_MODEL", "qwen2.5"),
+		})
+	}
+
+	// 模拟模型会生成假题目，需要显式设置 AI_MOCK_ENABLED=true 才启用
+	if getEnvBool("AI_MOCK_ENABLED", false) {
+		aiConfig.Providers = append(aiConfig.Providers, AIProviderConfig{
+			Name:          "mock",
+			Type:          AIProviderTypeMock,
+			Model:         "mock",
+			Seed:          int64(getEnvInt("AI_MOCK_SEED", 1)),
+			MalformedRate: getEnvFloat("AI_MOCK_MALFORMED_RATE", 0.2),
+			ScriptFile:    os.Getenv("AI_MOCK_SCRIPT_FILE"),
+			DelayMs:       getEnvInt("AI_MOCK_DELAY_MS", 0),
+		})
+	}
+
+	// AI_PROVIDERS 为JSON数组，用于添加其他模型或同一接口的不同模型，同名配置覆盖内置模型
+	if raw := os.Getenv("AI_PROVIDERS"); raw != "" {
+		var providers []AIProviderConfig
+		if err := json.Unmarshal([]byte(raw), &providers); err != nil {
+			log.Println("警告: AI_PROVIDERS 格式错误，已忽略:", err)
+		} else {
+			aiConfig.Providers = append(aiConfig.Providers, providers...)
+		}
+	}
+
+	for i := range aiConfig.Providers {
+		provider := &aiConfig.Providers[i]
+		if provider.Type == "" {
+			provider.Type = AIProviderTypeOpenAI
+		}
+		if provider.APIKey == "" && provider.APIKeyEnv != "" {
+			provider.APIKey = os.Getenv(provider.APIKeyEnv)
+		}
+		if provider.MaxTokens <= 0 {
+			provider.MaxTokens = 2000
+		}
+		// 温度为0表示尽量确定的输出，只有未配置时才使用默认值
+		if provider.Temperature == nil {
+			temperature := 0.7
+			provider.Temperature = &temperature
+		}
+		if provider.TimeoutSeconds <= 0 {
+			// 本地模型生成较慢，默认超时时间更长
+			provider.TimeoutSeconds = 30
+			if provider.Type == AIProviderTypeOllama {
+				provider.TimeoutSeconds = 120
+			}
+		}
+	}
+
+	return aiConfig
 }
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// CodeRunnerConfig 编程题代码运行沙箱配置
+type CodeRunnerConfig struct {
+	// 存放考生代码和编译产物的临时目录，沙箱用户需要能够进入该目录
+	WorkDir string
+	// 单个测试用例的运行时间上限
+	TimeLimit time.Duration
+	// 编译时间上限
+	CompileTimeout time.Duration
+	// 运行内存上限（MB）
+	MemoryLimitMB int
+	// 标准输出和标准错误各自保留的最大字节数
+	OutputLimitBytes int
+	// 运行时的进程（含线程）数上限
+	ProcessLimit int
+	// 编译和运行时写入的单个文件大小上限（MB）
+	FileSizeLimitMB int
+	// 是否在沙箱中运行代码：使用独立的用户、文件系统、网络和进程命名空间，
+	// 只能看到只读的系统目录和本次运行的临时目录。仅支持 Linux，关闭后代码以服务进程的身份直接运行，只能用于本地开发
+	Sandbox bool
+	// 服务以 root 运行时，沙箱中的进程在宿主机上使用的用户和组，默认为 nobody
+	SandboxUID int
+	SandboxGID int
+	// 以只读方式挂载到沙箱中的宿主机路径，编程语言安装在系统目录以外时需要在 CODE_RUNNER_SANDBOX_MOUNTS 中补充
+	SandboxMounts []string
+}
+
+// defaultSandboxMounts 默认挂载到沙箱中的系统目录，不存在的路径会被跳过
+var defaultSandboxMounts = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+}
+
+func LoadCodeRunnerConfig() CodeRunnerConfig {
+	mounts := append([]string{}, defaultSandboxMounts...)
+	for _, path := range strings.Split(os.Getenv("CODE_RUNNER_SANDBOX_MOUNTS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			mounts = append(mounts, path)
+		}
+	}
+
+	return CodeRunnerConfig{
+		WorkDir:          getEnv("CODE_RUNNER_WORK_DIR", os.TempDir()),
+		TimeLimit:        time.Duration(getEnvInt("CODE_RUNNER_TIME_LIMIT_MS", 2000)) * time.Millisecond,
+		CompileTimeout:   time.Duration(getEnvInt("CODE_RUNNER_COMPILE_TIMEOUT", 30)) * time.Second,
+		MemoryLimitMB:    getEnvInt("CODE_RUNNER_MEMORY_LIMIT_MB", 256),
+		OutputLimitBytes: getEnvInt("CODE_RUNNER_OUTPUT_LIMIT_KB", 64) * 1024,
+		ProcessLimit:     getEnvInt("CODE_RUNNER_PROCESS_LIMIT", 64),
+		FileSizeLimitMB:  getEnvInt("CODE_RUNNER_FILE_SIZE_LIMIT_MB", 16),
+		Sandbox:          getEnvBool("CODE_RUNNER_SANDBOX", true),
+		SandboxUID:       getEnvInt("CODE_RUNNER_SANDBOX_UID", 65534),
+		SandboxGID:       getEnvInt("CODE_RUNNER_SANDBOX_GID", 65534),
+		SandboxMounts:    mounts,
+	}
+}
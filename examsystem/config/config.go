@@ -4,6 +4,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +16,8 @@ type AppConfig struct {
 	Mode       string
 	ResetDB    bool
 	DB         DBConfig
+	// 超时考试自动提交任务的执行间隔
+	ExamAutoSubmitInterval time.Duration
 }
 
 // DBConfig 数据库配置
@@ -79,6 +83,7 @@ func GetConfig() *AppConfig {
 			Name:     getEnv("DB_NAME", "student_management"),
 			Charset:  getEnv("DB_CHARSET", "utf8mb4"),
 		},
+		ExamAutoSubmitInterval: time.Duration(getEnvInt("EXAM_AUTO_SUBMIT_INTERVAL", 30)) * time.Second, // 默认30秒
 	}
 }
 
@@ -102,3 +107,18 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return false
 }
+
+// getEnvFloat 读取浮点数类型环境变量
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return floatValue
+}
@@ -0,0 +1,17 @@
+// config/grading_config.go
+package config
+
+// GradingConfig 阅卷配置
+type GradingConfig struct {
+	// 多选题计分规则：all_or_nothing（全对才得分）、proportional（少选按比例得分，错选不得分）、penalty（错选倒扣）
+	MultipleChoiceRule string
+	// 同时在后台评分的考试数量上限，包含编程题的考试提交后在后台运行测试用例
+	Workers int
+}
+
+func LoadGradingConfig() GradingConfig {
+	return GradingConfig{
+		MultipleChoiceRule: getEnv("GRADING_MULTIPLE_RULE", "all_or_nothing"),
+		Workers:            getEnvInt("GRADING_WORKERS", 2),
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"time"
+)
+
+// QuestionJobConfig AI生成题目后台任务配置
+type QuestionJobConfig struct {
+	// 同时执行生成任务的数量
+	Workers int
+	// 没有新任务通知时检查等待中任务的间隔
+	PollInterval time.Duration
+	// 服务重启时任务已执行的次数达到该值则不再重新执行，直接标记为失败
+	MaxAttempts int
+}
+
+func LoadQuestionJobConfig() QuestionJobConfig {
+	return QuestionJobConfig{
+		Workers:      getEnvInt("QUESTION_JOB_WORKERS", 2),
+		PollInterval: time.Duration(getEnvInt("QUESTION_JOB_POLL_INTERVAL", 5)) * time.Second,
+		MaxAttempts:  getEnvInt("QUESTION_JOB_MAX_ATTEMPTS", 3),
+	}
+}
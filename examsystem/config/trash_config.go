@@ -0,0 +1,20 @@
+package config
+
+import (
+	"time"
+)
+
+// TrashConfig 回收站配置
+type TrashConfig struct {
+	// 删除的题目和试卷在回收站中保留的时间，超过后自动彻底删除，0表示不自动删除
+	Retention time.Duration
+	// 自动清理回收站的执行间隔
+	PurgeInterval time.Duration
+}
+
+func LoadTrashConfig() TrashConfig {
+	return TrashConfig{
+		Retention:     time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour, // 默认30天
+		PurgeInterval: time.Duration(getEnvInt("TRASH_PURGE_INTERVAL", 60)) * time.Minute,    // 默认60分钟
+	}
+}
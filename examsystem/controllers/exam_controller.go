@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"examsystem/dao/model"
+	"examsystem/models/dto"
+	"examsystem/service"
+	"examsystem/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExamController 考试控制器
+type ExamController struct {
+	examService *service.ExamService
+}
+
+// NewExamController 创建考试控制器
+func NewExamController(examService *service.ExamService) *ExamController {
+	return &ExamController{
+		examService: examService,
+	}
+}
+
+// StartExamHandler 开始考试
+func (c *ExamController) StartExamHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	var req dto.StartExamRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	session, err := c.examService.StartExam(int64(userID.(uint)), req.PaperID)
+	if err != nil {
+		handleExamError(ctx, "开始考试失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "开始考试", toExamSessionResponse(session))
+}
+
+// GetExamsHandler 获取考试记录列表
+func (c *ExamController) GetExamsHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	sessions, total, err := c.examService.GetSessions(int64(userID.(uint)), page, pageSize)
+	if err != nil {
+		utils.InternalError(ctx, "获取考试记录失败: "+err.Error())
+		return
+	}
+
+	responseList := make([]*dto.ExamSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responseList = append(responseList, toExamSessionResponse(session))
+	}
+
+	utils.Success(ctx, &dto.ExamListResponse{
+		List:  responseList,
+		Total: total,
+		Page:  page,
+		Size:  pageSize,
+	})
+}
+
+// GetExamHandler 获取考试详情（题目不包含答案和解析）
+func (c *ExamController) GetExamHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的考试ID")
+		return
+	}
+
+	session, err := c.examService.GetSession(int64(userID.(uint)), sessionID)
+	if err != nil {
+		handleExamError(ctx, "获取考试失败", err)
+		return
+	}
+
+	paper, err := c.examService.GetExamPaper(session)
+	if err != nil {
+		utils.InternalError(ctx, "获取试卷失败: "+err.Error())
+		return
+	}
+
+	details, err := c.examService.GetExamQuestions(session)
+	if err != nil {
+		utils.InternalError(ctx, "获取考试题目失败: "+err.Error())
+		return
+	}
+
+	answers, err := c.examService.GetAnswers(session.ID)
+	if err != nil {
+		utils.InternalError(ctx, "获取作答记录失败: "+err.Error())
+		return
+	}
+
+	questions := make([]*dto.ExamQuestionResponse, 0, len(details))
+	for _, eq := range details {
+		item := &dto.ExamQuestionResponse{
+			QuestionID:    eq.Question.ID,
+			QuestionOrder: eq.DisplayOrder,
+			Score:         eq.PaperQuestion.Score,
+			Title:         eq.Question.Title,
+			QuestionType:  string(eq.Question.QuestionType),
+			Options:       eq.Options,
+		}
+		if eq.Question.QuestionType == model.QuestionTypeCoding {
+			item.CodeLanguage = service.NormalizeCodeLanguage(eq.Question.Language)
+			item.CodeTemplate = eq.Question.CodeTemplate
+		}
+		if answer, ok := answers[eq.Question.ID]; ok {
+			item.MyAnswer = answer.Answer
+		}
+		questions = append(questions, item)
+	}
+
+	response := &dto.ExamDetailResponse{
+		ExamSessionResponse: *toExamSessionResponse(session),
+		PaperTitle:          paper.Title,
+		TotalScore:          paper.TotalScore,
+		DurationMinutes:     paper.DurationMinutes,
+		Questions:           questions,
+	}
+	if session.Deadline != nil && session.Status == model.ExamStatusInProgress {
+		remaining := int64(time.Until(*session.Deadline).Seconds())
+		if remaining < 0 {
+			remaining = 0
+		}
+		response.RemainingSeconds = &remaining
+	}
+
+	utils.Success(ctx, response)
+}
+
+// SaveAnswersHandler 保存作答（可多次调用）
+func (c *ExamController) SaveAnswersHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的考试ID")
+		return
+	}
+
+	var req dto.SaveAnswersRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	answers := make(map[int64]string, len(req.Answers))
+	for _, item := range req.Answers {
+		answers[item.QuestionID] = item.Answer
+	}
+
+	if err := c.examService.SaveAnswers(int64(userID.(uint)), sessionID, answers); err != nil {
+		handleExamError(ctx, "保存作答失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "保存作答成功", nil)
+}
+
+// SubmitExamHandler 提交考试
+func (c *ExamController) SubmitExamHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的考试ID")
+		return
+	}
+
+	session, err := c.examService.SubmitExam(int64(userID.(uint)), sessionID)
+	if err != nil {
+		handleExamError(ctx, "提交考试失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "提交考试成功", toExamSessionResponse(session))
+}
+
+// GetExamResultHandler 获取考试成绩及每题得分明细
+func (c *ExamController) GetExamResultHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的考试ID")
+		return
+	}
+
+	session, err := c.examService.GetExamResult(int64(userID.(uint)), sessionID)
+	if err != nil {
+		handleExamError(ctx, "获取考试成绩失败", err)
+		return
+	}
+
+	paper, err := c.examService.GetExamPaper(session)
+	if err != nil {
+		utils.InternalError(ctx, "获取试卷失败: "+err.Error())
+		return
+	}
+
+	details, err := c.examService.GetExamQuestions(session)
+	if err != nil {
+		utils.InternalError(ctx, "获取考试题目失败: "+err.Error())
+		return
+	}
+
+	answers, err := c.examService.GetAnswers(session.ID)
+	if err != nil {
+		utils.InternalError(ctx, "获取作答记录失败: "+err.Error())
+		return
+	}
+
+	questions := make([]*dto.ExamResultQuestionResponse, 0, len(details))
+	for _, eq := range details {
+		// 选项和答案按考生作答时看到的顺序展示
+		item := &dto.ExamResultQuestionResponse{
+			QuestionID:    eq.Question.ID,
+			QuestionOrder: eq.DisplayOrder,
+			FullScore:     eq.PaperQuestion.Score,
+			Title:         eq.Question.Title,
+			QuestionType:  string(eq.Question.QuestionType),
+			Options:       eq.Options,
+			CorrectAnswer: eq.ToDisplayAnswer(eq.Question.Answer),
+			Explanation:   eq.Question.Explanation,
+		}
+		if answer, ok := answers[eq.Question.ID]; ok {
+			item.MyAnswer = answer.Answer
+			item.Score = answer.Score
+			item.IsCorrect = answer.IsCorrect
+			item.Graded = answer.GradedAt != nil
+			item.Feedback = answer.Feedback
+			item.Comment = answer.Comment
+			if answer.TestResults != "" {
+				json.Unmarshal([]byte(answer.TestResults), &item.TestResults)
+			}
+		}
+		questions = append(questions, item)
+	}
+
+	utils.Success(ctx, &dto.ExamResultResponse{
+		ExamSessionResponse: *toExamSessionResponse(session),
+		PaperTitle:          paper.Title,
+		TotalScore:          paper.TotalScore,
+		Questions:           questions,
+	})
+}
+
+// toExamSessionResponse 转换为考试会话响应DTO
+func toExamSessionResponse(session *model.ExamSession) *dto.ExamSessionResponse {
+	return &dto.ExamSessionResponse{
+		ID:            session.ID,
+		PaperID:       session.PaperID,
+		Status:        string(session.Status),
+		Score:         session.Score,
+		StartedAt:     session.StartedAt,
+		Deadline:      session.Deadline,
+		SubmittedAt:   session.SubmittedAt,
+		AutoSubmitted: session.AutoSubmitted,
+		GradedAt:      session.GradedAt,
+	}
+}
+
+// handleExamError 根据考试服务返回的错误类型输出响应
+func handleExamError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrExamNotFound), errors.Is(err, service.ErrPaperNotFound):
+		utils.NotFound(ctx, err.Error())
+	case errors.Is(err, service.ErrExamPermissionDenied):
+		utils.Forbidden(ctx, err.Error())
+	case errors.Is(err, service.ErrExamNotInProgress),
+		errors.Is(err, service.ErrExamNotSubmitted),
+		errors.Is(err, service.ErrExamNotOpen),
+		errors.Is(err, service.ErrExamClosed),
+		errors.Is(err, service.ErrExamTimeUp),
+		errors.Is(err, service.ErrQuestionNotInExam),
+		errors.Is(err, service.ErrPaperHasNoQuestions):
+		utils.BusinessError(ctx, err.Error())
+	default:
+		utils.InternalError(ctx, msg+": "+err.Error())
+	}
+}
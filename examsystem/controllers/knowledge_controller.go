@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"errors"
+	"examsystem/dao/model"
+	"examsystem/models/dto"
+	"examsystem/service"
+	"examsystem/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KnowledgeController 知识体系控制器
+type KnowledgeController struct {
+	knowledgeService *service.KnowledgeService
+}
+
+// NewKnowledgeController 创建知识体系控制器
+func NewKnowledgeController(knowledgeService *service.KnowledgeService) *KnowledgeController {
+	return &KnowledgeController{
+		knowledgeService: knowledgeService,
+	}
+}
+
+// GetTreeHandler 获取学科、章节、知识点组成的知识体系树，以及当前用户题库中每个节点的题目数量
+func (c *KnowledgeController) GetTreeHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	tree, err := c.knowledgeService.GetTree(int64(userID.(uint)))
+	if err != nil {
+		utils.InternalError(ctx, "获取知识体系失败: "+err.Error())
+		return
+	}
+	utils.Success(ctx, toKnowledgeTreeResponses(tree))
+}
+
+// GetNodeStatisticsHandler 统计当前用户题库中关联到某个节点的题目
+func (c *KnowledgeController) GetNodeStatisticsHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+	nodeID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的节点ID")
+		return
+	}
+
+	stats, err := c.knowledgeService.GetNodeStatistics(int64(userID.(uint)), nodeID)
+	if err != nil {
+		handleKnowledgeError(ctx, "获取知识点统计失败", err)
+		return
+	}
+
+	resp := &dto.KnowledgeNodeStatisticsResponse{
+		Node:          toKnowledgeNodeResponse(stats.Node, stats.QuestionCount),
+		Path:          make([]*dto.KnowledgeNodeResponse, 0, len(stats.Path)),
+		QuestionCount: stats.QuestionCount,
+		ByType:        make(map[string]int64, len(stats.ByType)),
+		ByDifficulty:  stats.ByDifficulty,
+		Children:      toKnowledgeTreeResponses(stats.Children),
+	}
+	for _, node := range stats.Path {
+		resp.Path = append(resp.Path, toKnowledgeNodeResponse(node, 0))
+	}
+	for questionType, count := range stats.ByType {
+		resp.ByType[string(questionType)] = count
+	}
+	utils.Success(ctx, resp)
+}
+
+// CreateNodeHandler 创建学科、章节或知识点，层级由上级节点决定
+func (c *KnowledgeController) CreateNodeHandler(ctx *gin.Context) {
+	var req dto.KnowledgeNodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	node := &model.KnowledgeNode{
+		ParentID:    req.ParentID,
+		Name:        req.Name,
+		Description: req.Description,
+		SortOrder:   req.SortOrder,
+	}
+	if err := c.knowledgeService.CreateNode(node); err != nil {
+		handleKnowledgeError(ctx, "创建节点失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "创建成功", toKnowledgeNodeResponse(node, 0))
+}
+
+// UpdateNodeHandler 更新节点的名称、说明和排列顺序
+func (c *KnowledgeController) UpdateNodeHandler(ctx *gin.Context) {
+	nodeID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的节点ID")
+		return
+	}
+
+	var req dto.KnowledgeNodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	node, err := c.knowledgeService.UpdateNode(nodeID, &model.KnowledgeNode{
+		Name:        req.Name,
+		Description: req.Description,
+		SortOrder:   req.SortOrder,
+	})
+	if err != nil {
+		handleKnowledgeError(ctx, "更新节点失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "更新成功", toKnowledgeNodeResponse(node, 0))
+}
+
+// DeleteNodeHandler 删除没有下级节点的节点
+func (c *KnowledgeController) DeleteNodeHandler(ctx *gin.Context) {
+	nodeID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的节点ID")
+		return
+	}
+
+	if err := c.knowledgeService.DeleteNode(nodeID); err != nil {
+		handleKnowledgeError(ctx, "删除节点失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "删除成功", nil)
+}
+
+// toKnowledgeNodeResponse 转换为节点响应
+func toKnowledgeNodeResponse(node *model.KnowledgeNode, questionCount int64) *dto.KnowledgeNodeResponse {
+	return &dto.KnowledgeNodeResponse{
+		ID:            node.ID,
+		ParentID:      node.ParentID,
+		Level:         string(node.Level),
+		Name:          node.Name,
+		Description:   node.Description,
+		SortOrder:     node.SortOrder,
+		QuestionCount: questionCount,
+		CreatedAt:     node.CreatedAt,
+		UpdatedAt:     node.UpdatedAt,
+	}
+}
+
+// toKnowledgeTreeResponses 递归转换知识体系树
+func toKnowledgeTreeResponses(tree []*service.KnowledgeTreeNode) []*dto.KnowledgeNodeResponse {
+	list := make([]*dto.KnowledgeNodeResponse, 0, len(tree))
+	for _, treeNode := range tree {
+		resp := toKnowledgeNodeResponse(treeNode.Node, treeNode.QuestionCount)
+		if len(treeNode.Children) > 0 {
+			resp.Children = toKnowledgeTreeResponses(treeNode.Children)
+		}
+		list = append(list, resp)
+	}
+	return list
+}
+
+// handleKnowledgeError 将知识体系服务错误转换为统一响应
+func handleKnowledgeError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrKnowledgeNodeNotFound):
+		utils.NotFound(ctx, err.Error())
+	case errors.Is(err, service.ErrInvalidKnowledgeNode):
+		utils.ParamError(ctx, err.Error())
+	case errors.Is(err, service.ErrKnowledgeNodeInUse):
+		utils.BusinessError(ctx, err.Error())
+	default:
+		utils.InternalError(ctx, msg+": "+err.Error())
+	}
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"examsystem/models/dto"
+	"examsystem/service"
+	"examsystem/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarkingController 人工阅卷控制器
+type MarkingController struct {
+	markingService *service.MarkingService
+}
+
+// NewMarkingController 创建人工阅卷控制器
+func NewMarkingController(markingService *service.MarkingService) *MarkingController {
+	return &MarkingController{
+		markingService: markingService,
+	}
+}
+
+// GetMarkingPapersHandler 获取有待阅卷作答的试卷列表
+func (c *MarkingController) GetMarkingPapersHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	rows, err := c.markingService.GetMarkingPapers(int64(userID.(uint)), ctx.GetString("role"))
+	if err != nil {
+		utils.InternalError(ctx, "获取待阅卷试卷失败: "+err.Error())
+		return
+	}
+
+	papers := make([]*dto.MarkingPaperResponse, 0, len(rows))
+	for _, row := range rows {
+		papers = append(papers, &dto.MarkingPaperResponse{
+			PaperID:         row.PaperID,
+			Title:           row.Title,
+			PendingAnswers:  row.PendingAnswers,
+			PendingSessions: row.PendingSessions,
+		})
+	}
+
+	utils.Success(ctx, papers)
+}
+
+// GetPaperAnswersHandler 获取试卷的作答列表，默认只返回待阅卷的作答，all=true 时返回全部作答
+func (c *MarkingController) GetPaperAnswersHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	pendingOnly := ctx.Query("all") != "true"
+
+	answers, total, err := c.markingService.GetPaperAnswers(int64(userID.(uint)), ctx.GetString("role"), paperID, pendingOnly, page, pageSize)
+	if err != nil {
+		handleMarkingError(ctx, "获取作答列表失败", err)
+		return
+	}
+
+	list := make([]*dto.MarkingAnswerResponse, 0, len(answers))
+	for _, answer := range answers {
+		item := &dto.MarkingAnswerResponse{
+			AnswerID:      answer.ID,
+			SessionID:     answer.SessionID,
+			SessionStatus: string(answer.SessionStatus),
+			UserID:        answer.UserID,
+			Username:      answer.Username,
+			SubmittedAt:   answer.SubmittedAt,
+			QuestionID:    answer.QuestionID,
+			FullScore:     answer.FullScore,
+			Answer:        answer.Answer,
+			Score:         answer.Score,
+			Graded:        answer.GradedAt != nil,
+			Feedback:      answer.Feedback,
+			Comment:       answer.Comment,
+			MarkedBy:      answer.MarkedBy,
+		}
+		if answer.Question != nil {
+			item.QuestionType = string(answer.Question.QuestionType)
+			item.Title = answer.Question.Title
+			item.ReferenceAnswer = answer.Question.Answer
+			item.Explanation = answer.Question.Explanation
+			json.Unmarshal([]byte(answer.Question.Options), &item.Options)
+		}
+		if answer.TestResults != "" {
+			json.Unmarshal([]byte(answer.TestResults), &item.TestResults)
+		}
+		list = append(list, item)
+	}
+
+	utils.Success(ctx, &dto.MarkingAnswerListResponse{
+		List:  list,
+		Total: total,
+		Page:  page,
+		Size:  pageSize,
+	})
+}
+
+// MarkAnswerHandler 为作答打分并填写评语
+func (c *MarkingController) MarkAnswerHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	answerID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的作答ID")
+		return
+	}
+
+	var req dto.MarkAnswerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	answer, err := c.markingService.MarkAnswer(int64(userID.(uint)), ctx.GetString("role"), answerID, *req.Score, req.Comment)
+	if err != nil {
+		handleMarkingError(ctx, "阅卷失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "阅卷成功", gin.H{
+		"answer_id": answer.ID,
+		"score":     answer.Score,
+		"comment":   answer.Comment,
+	})
+}
+
+// FinalizeSessionHandler 完成考试阅卷
+func (c *MarkingController) FinalizeSessionHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的考试ID")
+		return
+	}
+
+	session, err := c.markingService.FinalizeSession(int64(userID.(uint)), ctx.GetString("role"), sessionID)
+	if err != nil {
+		handleMarkingError(ctx, "完成阅卷失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "阅卷完成", toExamSessionResponse(session))
+}
+
+// handleMarkingError 将阅卷服务错误转换为统一响应
+func handleMarkingError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrPaperNotFound),
+		errors.Is(err, service.ErrExamNotFound),
+		errors.Is(err, service.ErrAnswerNotFound):
+		utils.NotFound(ctx, err.Error())
+	case errors.Is(err, service.ErrMarkingPermissionDenied):
+		utils.Forbidden(ctx, err.Error())
+	case errors.Is(err, service.ErrInvalidMarkScore):
+		utils.ParamError(ctx, err.Error())
+	case errors.Is(err, service.ErrExamNotMarkable),
+		errors.Is(err, service.ErrExamGrading),
+		errors.Is(err, service.ErrExamAlreadyFinalized),
+		errors.Is(err, service.ErrMarkingIncomplete),
+		errors.Is(err, service.ErrQuestionNotInExam):
+		utils.BusinessError(ctx, err.Error())
+	default:
+		utils.InternalError(ctx, msg+": "+err.Error())
+	}
+}
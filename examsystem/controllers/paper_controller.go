@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"examsystem/dao/model"
+	"examsystem/models/dto"
+	"examsystem/service"
+	"examsystem/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaperController 试卷控制器
+type PaperController struct {
+	paperService *service.PaperService
+}
+
+// NewPaperController 创建试卷控制器
+func NewPaperController(paperService *service.PaperService) *PaperController {
+	return &PaperController{
+		paperService: paperService,
+	}
+}
+
+// GetPapersHandler 获取试卷列表
+func (c *PaperController) GetPapersHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	keyword := ctx.Query("keyword")
+
+	papers, questionCounts, total, err := c.paperService.GetPapers(int64(userID.(uint)), keyword, page, pageSize)
+	if err != nil {
+		utils.InternalError(ctx, "获取试卷列表失败: "+err.Error())
+		return
+	}
+
+	responseList := make([]*dto.PaperResponse, 0, len(papers))
+	for _, paper := range papers {
+		responseList = append(responseList, toPaperResponse(paper, questionCounts[paper.ID]))
+	}
+
+	utils.Success(ctx, &dto.PaperListResponse{
+		List:  responseList,
+		Total: total,
+		Page:  page,
+		Size:  pageSize,
+	})
+}
+
+// CreatePaperHandler 创建试卷
+func (c *PaperController) CreatePaperHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	var req dto.CreatePaperRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	paper := &model.Paper{
+		Title:            req.Title,
+		Description:      req.Description,
+		DurationMinutes:  req.DurationMinutes,
+		OpenAt:           req.OpenAt,
+		CloseAt:          req.CloseAt,
+		ShuffleQuestions: req.ShuffleQuestions,
+		ShuffleOptions:   req.ShuffleOptions,
+		Published:        req.Published,
+		CreatorID:        int64(userID.(uint)),
+	}
+
+	if err := c.paperService.CreatePaper(paper); err != nil {
+		handlePaperError(ctx, "创建试卷失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "创建试卷成功", toPaperResponse(paper, 0))
+}
+
+// GetPaperHandler 获取试卷详情（包含题目）
+func (c *PaperController) GetPaperHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	paper, err := c.paperService.GetPaper(int64(userID.(uint)), paperID)
+	if err != nil {
+		handlePaperError(ctx, "获取试卷失败", err)
+		return
+	}
+
+	details, err := c.paperService.GetPaperQuestions(paperID)
+	if err != nil {
+		utils.InternalError(ctx, "获取试卷题目失败: "+err.Error())
+		return
+	}
+
+	questions := make([]*dto.PaperQuestionResponse, 0, len(details))
+	for _, detail := range details {
+		var opts []string
+		json.Unmarshal([]byte(detail.Question.Options), &opts)
+
+		questions = append(questions, &dto.PaperQuestionResponse{
+			QuestionID:    detail.Question.ID,
+			QuestionOrder: detail.PaperQuestion.QuestionOrder,
+			Score:         detail.PaperQuestion.Score,
+			Title:         detail.Question.Title,
+			QuestionType:  string(detail.Question.QuestionType),
+			Options:       opts,
+			Answer:        detail.Question.Answer,
+			Explanation:   detail.Question.Explanation,
+		})
+	}
+
+	utils.Success(ctx, &dto.PaperDetailResponse{
+		PaperResponse: *toPaperResponse(paper, len(questions)),
+		Questions:     questions,
+	})
+}
+
+// UpdatePaperHandler 更新试卷信息
+func (c *PaperController) UpdatePaperHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	var req dto.UpdatePaperRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	paper := &model.Paper{
+		ID:               paperID,
+		Title:            req.Title,
+		Description:      req.Description,
+		DurationMinutes:  req.DurationMinutes,
+		OpenAt:           req.OpenAt,
+		CloseAt:          req.CloseAt,
+		ShuffleQuestions: req.ShuffleQuestions,
+		ShuffleOptions:   req.ShuffleOptions,
+		Published:        req.Published,
+	}
+
+	if err := c.paperService.UpdatePaper(int64(userID.(uint)), paper); err != nil {
+		handlePaperError(ctx, "更新试卷失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "更新试卷成功", nil)
+}
+
+// DeletePaperHandler 删除试卷
+func (c *PaperController) DeletePaperHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	if err := c.paperService.DeletePaper(int64(userID.(uint)), paperID); err != nil {
+		handlePaperError(ctx, "删除试卷失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "删除试卷成功", nil)
+}
+
+// AddQuestionToPaperHandler 添加题目到试卷
+func (c *PaperController) AddQuestionToPaperHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	var req dto.AddPaperQuestionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.paperService.AddQuestionToPaper(int64(userID.(uint)), paperID, req.QuestionID, req.Score); err != nil {
+		handlePaperError(ctx, "添加题目失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "添加题目成功", nil)
+}
+
+// RemoveQuestionFromPaperHandler 从试卷中移除题目
+func (c *PaperController) RemoveQuestionFromPaperHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	questionID, err := strconv.ParseInt(ctx.Param("questionId"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的题目ID")
+		return
+	}
+
+	if err := c.paperService.RemoveQuestionFromPaper(int64(userID.(uint)), paperID, questionID); err != nil {
+		handlePaperError(ctx, "移除题目失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "移除题目成功", nil)
+}
+
+// UpdateQuestionOrderHandler 更新试卷题目顺序
+func (c *PaperController) UpdateQuestionOrderHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	var req dto.UpdateQuestionOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := c.paperService.UpdateQuestionOrder(int64(userID.(uint)), paperID, req.QuestionIDs); err != nil {
+		handlePaperError(ctx, "更新题目顺序失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "更新题目顺序成功", nil)
+}
+
+// GetUserStatisticsHandler 获取用户统计信息
+func (c *PaperController) GetUserStatisticsHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	stats, err := c.paperService.GetUserStatistics(int64(userID.(uint)))
+	if err != nil {
+		utils.InternalError(ctx, "获取统计信息失败: "+err.Error())
+		return
+	}
+
+	utils.Success(ctx, stats)
+}
+
+// toPaperResponse 转换为试卷响应DTO
+func toPaperResponse(paper *model.Paper, questionCount int) *dto.PaperResponse {
+	return &dto.PaperResponse{
+		ID:               paper.ID,
+		Title:            paper.Title,
+		Description:      paper.Description,
+		TotalScore:       paper.TotalScore,
+		QuestionCount:    questionCount,
+		DurationMinutes:  paper.DurationMinutes,
+		OpenAt:           paper.OpenAt,
+		CloseAt:          paper.CloseAt,
+		ShuffleQuestions: paper.ShuffleQuestions,
+		ShuffleOptions:   paper.ShuffleOptions,
+		Published:        paper.Published,
+		CreatorID:        paper.CreatorID,
+		CreatedAt:        paper.CreatedAt,
+		UpdatedAt:        paper.UpdatedAt,
+	}
+}
+
+// handlePaperError 根据试卷服务返回的错误类型输出响应
+func handlePaperError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrPaperNotFound):
+		utils.NotFound(ctx, err.Error())
+	case errors.Is(err, service.ErrPaperPermissionDenied):
+		utils.Forbidden(ctx, err.Error())
+	case errors.Is(err, service.ErrInvalidPaperParam):
+		utils.ParamError(ctx, err.Error())
+	case errors.Is(err, service.ErrQuestionAlreadyInPaper),
+		errors.Is(err, service.ErrQuestionNotInPaper),
+		errors.Is(err, service.ErrQuestionUnavailable):
+		utils.BusinessError(ctx, err.Error())
+	default:
+		utils.InternalError(ctx, msg+": "+err.Error())
+	}
+}
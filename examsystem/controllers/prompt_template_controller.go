@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"errors"
+	"examsystem/dao/model"
+	"examsystem/models/dto"
+	"examsystem/service"
+	"examsystem/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromptTemplateController 提示语模板控制器
+type PromptTemplateController struct {
+	templateService *service.PromptTemplateService
+}
+
+// NewPromptTemplateController 创建提示语模板控制器
+func NewPromptTemplateController(templateService *service.PromptTemplateService) *PromptTemplateController {
+	return &PromptTemplateController{
+		templateService: templateService,
+	}
+}
+
+// GetTemplatesHandler 获取提示语模板列表，可按学科和题型筛选
+func (c *PromptTemplateController) GetTemplatesHandler(ctx *gin.Context) {
+	templates, err := c.templateService.GetTemplates(ctx.Query("subject"), model.QuestionType(ctx.Query("question_type")))
+	if err != nil {
+		utils.InternalError(ctx, "获取提示语模板失败: "+err.Error())
+		return
+	}
+
+	list := make([]*dto.PromptTemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		list = append(list, toPromptTemplateResponse(template, ""))
+	}
+	utils.Success(ctx, list)
+}
+
+// GetTemplateHandler 获取提示语模板详情及全部历史版本
+func (c *PromptTemplateController) GetTemplateHandler(ctx *gin.Context) {
+	templateID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的模板ID")
+		return
+	}
+
+	template, versions, err := c.templateService.GetTemplate(templateID)
+	if err != nil {
+		handlePromptTemplateError(ctx, "获取提示语模板失败", err)
+		return
+	}
+
+	detail := &dto.PromptTemplateDetailResponse{
+		Versions: make([]*dto.PromptTemplateVersionResponse, 0, len(versions)),
+	}
+	for _, version := range versions {
+		if version.Version == template.Version {
+			detail.PromptTemplateResponse = toPromptTemplateResponse(template, version.Content)
+		}
+		detail.Versions = append(detail.Versions, &dto.PromptTemplateVersionResponse{
+			Version:   version.Version,
+			Content:   version.Content,
+			CreatorID: version.CreatorID,
+			CreatedAt: version.CreatedAt,
+		})
+	}
+	if detail.PromptTemplateResponse == nil {
+		detail.PromptTemplateResponse = toPromptTemplateResponse(template, "")
+	}
+	utils.Success(ctx, detail)
+}
+
+// CreateTemplateHandler 创建提示语模板
+func (c *PromptTemplateController) CreateTemplateHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	var req dto.PromptTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	template := &model.PromptTemplate{
+		Name:         req.Name,
+		Subject:      req.Subject,
+		QuestionType: model.QuestionType(req.QuestionType),
+		Description:  req.Description,
+		IsDefault:    req.IsDefault,
+		CreatorID:    int64(userID.(uint)),
+	}
+	if err := c.templateService.CreateTemplate(template, req.Content); err != nil {
+		handlePromptTemplateError(ctx, "创建提示语模板失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "创建成功", toPromptTemplateResponse(template, req.Content))
+}
+
+// UpdateTemplateHandler 更新提示语模板，修改模板内容时新增一个版本
+func (c *PromptTemplateController) UpdateTemplateHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	templateID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的模板ID")
+		return
+	}
+
+	var req dto.PromptTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ParamError(ctx, "参数错误: "+err.Error())
+		return
+	}
+
+	template, err := c.templateService.UpdateTemplate(int64(userID.(uint)), templateID, &model.PromptTemplate{
+		Name:         req.Name,
+		Subject:      req.Subject,
+		QuestionType: model.QuestionType(req.QuestionType),
+		Description:  req.Description,
+		IsDefault:    req.IsDefault,
+	}, req.Content)
+	if err != nil {
+		handlePromptTemplateError(ctx, "更新提示语模板失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "更新成功", toPromptTemplateResponse(template, ""))
+}
+
+// DeleteTemplateHandler 删除提示语模板
+func (c *PromptTemplateController) DeleteTemplateHandler(ctx *gin.Context) {
+	templateID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的模板ID")
+		return
+	}
+
+	if err := c.templateService.DeleteTemplate(templateID); err != nil {
+		handlePromptTemplateError(ctx, "删除提示语模板失败", err)
+		return
+	}
+
+	utils.SuccessWithMsg(ctx, "删除成功", nil)
+}
+
+// toPromptTemplateResponse 转换为模板响应，content 为空时不返回模板内容
+func toPromptTemplateResponse(template *model.PromptTemplate, content string) *dto.PromptTemplateResponse {
+	return &dto.PromptTemplateResponse{
+		ID:           template.ID,
+		Name:         template.Name,
+		Subject:      template.Subject,
+		QuestionType: string(template.QuestionType),
+		Description:  template.Description,
+		Version:      template.Version,
+		IsDefault:    template.IsDefault,
+		Content:      content,
+		CreatorID:    template.CreatorID,
+		CreatedAt:    template.CreatedAt,
+		UpdatedAt:    template.UpdatedAt,
+	}
+}
+
+// handlePromptTemplateError 将提示语模板服务错误转换为统一响应
+func handlePromptTemplateError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrPromptTemplateNotFound):
+		utils.NotFound(ctx, err.Error())
+	case errors.Is(err, service.ErrInvalidPromptTemplate):
+		utils.ParamError(ctx, err.Error())
+	default:
+		utils.InternalError(ctx, msg+": "+err.Error())
+	}
+}
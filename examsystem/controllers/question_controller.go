@@ -2,26 +2,39 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"examsystem/dao/model"
 	"examsystem/service"
 	"examsystem/utils"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 题目列表每页数量的默认值和上限
+const (
+	defaultQuestionPageSize = 20
+	maxQuestionPageSize     = 100
+)
+
 type QuestionController struct {
-	questionService *service.QuestionService
+	questionService  *service.QuestionService
+	jobService       *service.QuestionJobService
+	knowledgeService *service.KnowledgeService
 }
 
-func NewQuestionController(questionService *service.QuestionService) *QuestionController {
+func NewQuestionController(questionService *service.QuestionService, jobService *service.QuestionJobService, knowledgeService *service.KnowledgeService) *QuestionController {
 	return &QuestionController{
-		questionService: questionService,
+		questionService:  questionService,
+		jobService:       jobService,
+		knowledgeService: knowledgeService,
 	}
 }
 
-// GenerateQuestionsHandler 生成题目
+// GenerateQuestionsHandler 创建生成题目任务，题目由后台生成，通过 GetQuestionJobHandler 查询进度和结果
 func (c *QuestionController) GenerateQuestionsHandler(ctx *gin.Context) {
 	userID, exists := ctx.Get("user_id")
 	if !exists {
@@ -29,43 +42,168 @@ func (c *QuestionController) GenerateQuestionsHandler(ctx *gin.Context) {
 		return
 	}
 
-	aiModel := ctx.Query("ai_model")
-	language := ctx.Query("language")
-	questionType := ctx.Query("question_type")
-	keywords := ctx.Query("keywords")
-	numQuestions, _ := strconv.Atoi(ctx.Query("num_questions"))
+	req, ok := generateRequest(ctx)
+	if !ok {
+		return
+	}
 
-	if questionType != string(model.QuestionTypeSingle) && questionType != string(model.QuestionTypeMultiple) {
+	if !service.IsValidQuestionType(req.QuestionType) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的题目类型", "data": nil})
 		return
 	}
 
-	questions, err := c.questionService.GenerateQuestions(int64(userID.(uint)), aiModel, language, model.QuestionType(questionType), keywords, numQuestions)
+	job, err := c.jobService.SubmitJob(int64(userID.(uint)), req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成题目失败", "data": nil})
+		if service.IsGenerateRequestError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建生成任务失败", "data": nil})
 		return
 	}
 
-	var response []map[string]interface{}
+	ctx.JSON(http.StatusAccepted, gin.H{"code": 200, "message": "生成任务已创建", "data": gin.H{
+		"jobId":  job.ID,
+		"status": job.Status,
+	}})
+}
+
+// GenerateQuestionsStreamHandler 以 Server-Sent Events 推送生成的题目
+// 每解析出一道题目推送一个 question 事件，题目未通过校验时推送 rejected 事件，
+// 全部保存后推送携带待确认题目ID的 done 事件，失败时推送 error 事件
+func (c *QuestionController) GenerateQuestionsStreamHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	req, ok := generateRequest(ctx)
+	if !ok {
+		return
+	}
+
+	// 参数错误在开始推送之前以普通响应返回
+	if err := c.questionService.ValidateGenerateRequest(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no") // 禁止反向代理缓冲
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	indexes := make(map[*model.Question]int) // 题目在 question 事件中的序号
+	questions, report, err := c.questionService.GenerateQuestionsStream(ctx.Request.Context(), int64(userID.(uint)), req, service.GenerateCallbacks{
+		OnQuestion: func(q *model.Question, similar *service.SimilarQuestion) {
+			indexes[q] = len(indexes)
+			ctx.SSEvent("question", gin.H{"index": indexes[q], "question": questionResponse(q), "similar": similar})
+			ctx.Writer.Flush()
+		},
+		OnReviewed: func(q *model.Question) {
+			ctx.SSEvent("review", gin.H{"index": indexes[q], "review": questionResponse(q)["review"]})
+			ctx.Writer.Flush()
+		},
+		OnRejected: func(rejection service.QuestionRejection) {
+			ctx.SSEvent("rejected", rejection)
+			ctx.Writer.Flush()
+		},
+	})
+	if err != nil {
+		ctx.SSEvent("error", gin.H{"message": "生成题目失败: " + err.Error()})
+		ctx.Writer.Flush()
+		return
+	}
+
+	ids := make([]int64, 0, len(questions))
 	for _, q := range questions {
-		var opts []string
-		json.Unmarshal([]byte(q.Options), &opts)
-
-		response = append(response, map[string]interface{}{
-			"id":           q.ID,
-			"title":        q.Title,
-			"questionType": q.QuestionType,
-			"options":      opts,
-			"answer":       q.Answer,
-			"explanation":  q.Explanation,
-			"keywords":     q.Keywords,
-			"language":     q.Language,
-			"aiModel":      q.AIModel,
-			"userID":       q.UserID,
+		ids = append(ids, q.ID)
+	}
+	ctx.SSEvent("done", gin.H{"ids": ids, "batchId": report.BatchID, "count": len(ids), "requested": req.NumQuestions, "report": report})
+	ctx.Writer.Flush()
+}
+
+// GetQuestionJobHandler 查询生成题目任务的进度、错误信息和生成的题目
+func (c *QuestionController) GetQuestionJobHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	jobID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的任务ID", "data": nil})
+		return
+	}
+
+	detail, err := c.jobService.GetJob(int64(userID.(uint)), jobID)
+	if err != nil {
+		if errors.Is(err, service.ErrQuestionJobNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取任务失败", "data": nil})
+		return
+	}
+
+	points, err := c.knowledgeService.GetQuestionKnowledgePoints(questionIDs(detail.Questions))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取任务失败", "data": nil})
+		return
+	}
+
+	job := detail.Job
+	questions := make([]map[string]interface{}, 0, len(detail.Questions))
+	for _, q := range detail.Questions {
+		item := questionResponse(q)
+		item["similar"] = detail.Similar[q.ID]
+		item["knowledgePoints"] = knowledgePointRefs(points[q.ID])
+		questions = append(questions, item)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": gin.H{
+		"jobId":            job.ID,
+		"status":           job.Status,
+		"queuePosition":    detail.QueuePosition,
+		"attempts":         job.Attempts,
+		"error":            job.Error,
+		"report":           detail.Report,
+		"aiModel":          job.AIModel,
+		"language":         job.Language,
+		"questionType":     job.QuestionType,
+		"keywords":         job.Keywords,
+		"numQuestions":     job.NumQuestions,
+		"difficulty":       job.Difficulty,
+		"cognitiveLevel":   job.CognitiveLevel,
+		"templateId":       job.TemplateID,
+		"templateVersion":  job.TemplateVersion,
+		"knowledgePointId": job.KnowledgePointID,
+		"createdAt":        job.CreatedAt,
+		"startedAt":        job.StartedAt,
+		"finishedAt":       job.FinishedAt,
+		"questions":        questions,
+	}})
+}
+
+// GetAIModelsHandler 获取可用的AI模型
+func (c *QuestionController) GetAIModelsHandler(ctx *gin.Context) {
+	providers := c.questionService.GetAIProviders()
+	result := make([]map[string]interface{}, 0, len(providers))
+	for _, provider := range providers {
+		capabilities := provider.Capabilities()
+		result = append(result, map[string]interface{}{
+			"name":       provider.Name(),
+			"model":      provider.Model(),
+			"streaming":  capabilities.Streaming,
+			"max_tokens": capabilities.MaxTokens,
 		})
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "生成成功", "data": response})
+	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": result})
 }
 
 // SaveSelectedQuestionsHandler 保存选中的题目
@@ -77,7 +215,9 @@ func (c *QuestionController) SaveSelectedQuestionsHandler(ctx *gin.Context) {
 	}
 
 	var req struct {
-		SelectedIDs []int64 `json:"selected_ids"`
+		BatchID        string  `json:"batch_id"`
+		SelectedIDs    []int64 `json:"selected_ids"`
+		SkipDuplicates bool    `json:"skip_duplicates"`
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -85,16 +225,23 @@ func (c *QuestionController) SaveSelectedQuestionsHandler(ctx *gin.Context) {
 		return
 	}
 
-	err := c.questionService.SaveSelectedQuestions(int64(userID.(uint)), req.SelectedIDs)
+	result, err := c.questionService.SaveSelectedQuestions(int64(userID.(uint)), req.BatchID, req.SelectedIDs, req.SkipDuplicates)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "确认失败", "data": nil})
+		switch {
+		case errors.Is(err, service.ErrDraftBatchNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		case errors.Is(err, service.ErrInvalidDraftSelection):
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "确认失败", "data": nil})
+		}
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "确认成功", "data": nil})
+	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "确认成功", "data": result})
 }
 
-// GetQuestionsByUserIDHandler 获取用户的题目,DeletedAt IS NULL 筛选逻辑
+// GetQuestionsByUserIDHandler 分页获取用户的题目，默认为题库中的题目，status 可以查询待确认或已归档的题目
 func (c *QuestionController) GetQuestionsByUserIDHandler(ctx *gin.Context) {
 	userID, exists := ctx.Get("user_id")
 	if !exists {
@@ -102,36 +249,182 @@ func (c *QuestionController) GetQuestionsByUserIDHandler(ctx *gin.Context) {
 		return
 	}
 
-	// language := ctx.Query("language")
-	// questionType := ctx.Query("question_type")
-	// keyword := ctx.Query("keyword")
+	page, pageSize, ok := questionPage(ctx)
+	if !ok {
+		return
+	}
+	filter, ok := questionFilter(ctx)
+	if !ok {
+		return
+	}
+
+	var desc bool
+	switch strings.ToLower(ctx.DefaultQuery("order", "desc")) {
+	case "desc":
+		desc = true
+	case "asc":
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的排序方向", "data": nil})
+		return
+	}
+	sort := service.QuestionSort{Field: ctx.Query("sort"), Desc: desc}
 
-	questions, err := c.questionService.GetQuestionsByUserID(int64(userID.(uint)), "", "", "")
+	questions, total, err := c.questionService.GetQuestionsByUserID(int64(userID.(uint)), filter, sort, page, pageSize)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidQuestionSort),
+			errors.Is(err, service.ErrInvalidQuestionType),
+			errors.Is(err, service.ErrInvalidDifficulty),
+			errors.Is(err, service.ErrInvalidCognitive),
+			errors.Is(err, service.ErrKnowledgeNodeNotFound):
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取失败", "data": nil})
+		}
+		return
+	}
+	points, err := c.knowledgeService.GetQuestionKnowledgePoints(questionIDs(questions))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取失败", "data": nil})
 		return
 	}
 
-	var result []map[string]interface{}
+	result := make([]map[string]interface{}, 0, len(questions))
 	for _, q := range questions {
-		var opts []string
-		json.Unmarshal([]byte(q.Options), &opts)
+		item := questionResponse(q)
+		item["knowledgePoints"] = knowledgePointRefs(points[q.ID])
+		result = append(result, item)
+	}
 
-		result = append(result, map[string]interface{}{
-			"id":           q.ID,
-			"title":        q.Title,
-			"questionType": q.QuestionType,
-			"options":      opts,
-			"answer":       q.Answer,
-			"explanation":  q.Explanation,
-			"keywords":     q.Keywords,
-			"language":     q.Language,
-			"aiModel":      q.AIModel,
-			"userID":       q.UserID,
-		})
+	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": gin.H{
+		"list":  result,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	}})
+}
+
+// SearchQuestionsHandler 全文检索用户的题目，按相关度排序，返回标出命中文字的摘要
+func (c *QuestionController) SearchQuestionsHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "获取成功", "data": result})
+	page, pageSize, ok := questionPage(ctx)
+	if !ok {
+		return
+	}
+	filter, ok := questionFilter(ctx)
+	if !ok {
+		return
+	}
+
+	results, total, err := c.questionService.SearchQuestions(int64(userID.(uint)), ctx.Query("q"), filter, page, pageSize)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptySearchQuery),
+			errors.Is(err, service.ErrInvalidQuestionType),
+			errors.Is(err, service.ErrInvalidDifficulty),
+			errors.Is(err, service.ErrInvalidCognitive),
+			errors.Is(err, service.ErrKnowledgeNodeNotFound):
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "检索失败", "data": nil})
+		}
+		return
+	}
+	hits := make([]*model.Question, 0, len(results))
+	for _, r := range results {
+		hits = append(hits, r.Question)
+	}
+	points, err := c.knowledgeService.GetQuestionKnowledgePoints(questionIDs(hits))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "检索失败", "data": nil})
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(results))
+	for _, r := range results {
+		item := questionResponse(r.Question)
+		item["score"] = r.Score
+		item["highlights"] = r.Highlights
+		item["knowledgePoints"] = knowledgePointRefs(points[r.Question.ID])
+		list = append(list, item)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "检索成功", "data": gin.H{
+		"list":  list,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	}})
+}
+
+// questionPage 读取题目列表的分页参数，参数无效时输出错误响应并返回 false
+func questionPage(ctx *gin.Context) (int, int, bool) {
+	page, err1 := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, err2 := strconv.Atoi(ctx.DefaultQuery("page_size", strconv.Itoa(defaultQuestionPageSize)))
+	if err1 != nil || err2 != nil || page < 1 || pageSize < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的分页参数", "data": nil})
+		return 0, 0, false
+	}
+	if pageSize > maxQuestionPageSize {
+		pageSize = maxQuestionPageSize
+	}
+	return page, pageSize, true
+}
+
+// questionFilter 读取题目列表的筛选参数，参数无效时输出错误响应并返回 false
+func questionFilter(ctx *gin.Context) (service.QuestionFilter, bool) {
+	createdAfter, err1 := optionalTime(ctx, "created_from", false)
+	createdBefore, err2 := optionalTime(ctx, "created_to", true)
+	if err1 != nil || err2 != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的日期，格式为 2006-01-02 或 RFC3339", "data": nil})
+		return service.QuestionFilter{}, false
+	}
+
+	var tags []string
+	for _, tag := range strings.FieldsFunc(ctx.Query("tags"), func(r rune) bool { return r == ',' || r == '，' }) {
+		if tag = strings.ReplaceAll(strings.TrimSpace(tag), " ", ""); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	minDifficulty, err1 := optionalInt(ctx, "min_difficulty")
+	maxDifficulty, err2 := optionalInt(ctx, "max_difficulty")
+	if err1 != nil || err2 != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的难度", "data": nil})
+		return service.QuestionFilter{}, false
+	}
+	knowledgeNodeID, err := optionalInt(ctx, "knowledge_node_id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的知识体系节点", "data": nil})
+		return service.QuestionFilter{}, false
+	}
+	status := model.QuestionStatus(ctx.Query("status"))
+	switch status {
+	case "", model.QuestionStatusDraft, model.QuestionStatusActive, model.QuestionStatusArchived:
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的题目状态", "data": nil})
+		return service.QuestionFilter{}, false
+	}
+
+	return service.QuestionFilter{
+		Status:          status,
+		Language:        ctx.Query("language"),
+		QuestionType:    ctx.Query("question_type"),
+		Keyword:         ctx.Query("keyword"),
+		MinDifficulty:   minDifficulty,
+		MaxDifficulty:   maxDifficulty,
+		CognitiveLevel:  ctx.Query("cognitive_level"),
+		AIModel:         ctx.Query("ai_model"),
+		Tags:            tags,
+		CreatedAfter:    createdAfter,
+		CreatedBefore:   createdBefore,
+		KnowledgeNodeID: int64(knowledgeNodeID),
+	}, true
 }
 
 // UpdateQuestionHandler 更新题目
@@ -147,14 +440,18 @@ func (c *QuestionController) UpdateQuestionHandler(ctx *gin.Context) {
 
 	// 解析请求体
 	var request struct {
-		Title        string             `json:"title"`
-		QuestionType model.QuestionType `json:"questionType"`
-		Options      []string           `json:"options"`
-		Answer       string             `json:"answer"`
-		Explanation  string             `json:"explanation"`
-		Keywords     string             `json:"keywords"`
-		Language     string             `json:"language"`
-		AIModel      string             `json:"aiModel"`
+		Title          string                 `json:"title"`
+		QuestionType   model.QuestionType     `json:"questionType"`
+		Options        []string               `json:"options"`
+		Answer         string                 `json:"answer"`
+		Explanation    string                 `json:"explanation"`
+		CodeTemplate   string                 `json:"codeTemplate"`
+		TestCases      []service.CodeTestCase `json:"testCases"`
+		Keywords       string                 `json:"keywords"`
+		Language       string                 `json:"language"`
+		AIModel        string                 `json:"aiModel"`
+		Difficulty     int                    `json:"difficulty"`
+		CognitiveLevel *model.CognitiveLevel  `json:"cognitiveLevel"` // 未填写时保留原值，为空字符串时清除
 	}
 
 	if err := ctx.BindJSON(&request); err != nil {
@@ -186,19 +483,37 @@ func (c *QuestionController) UpdateQuestionHandler(ctx *gin.Context) {
 		Options:      string(optionsJSON),
 		Answer:       request.Answer,
 		Explanation:  request.Explanation,
+		CodeTemplate: request.CodeTemplate,
 		Keywords:     request.Keywords,
 		Language:     request.Language,
 		AIModel:      request.AIModel,
+		Difficulty:   request.Difficulty,
+	}
+
+	if request.QuestionType == model.QuestionTypeCoding {
+		testsJSON, err := json.Marshal(request.TestCases)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "测试用例格式错误",
+				"data":    nil,
+			})
+			return
+		}
+		question.TestCases = string(testsJSON)
 	}
 
 	// 调用服务层更新题目
-	err = c.questionService.UpdateQuestion(question)
+	err = c.questionService.UpdateQuestion(question, request.CognitiveLevel)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "更新题目失败",
-			"data":    nil,
-		})
+		switch {
+		case errors.Is(err, service.ErrQuestionNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		case errors.Is(err, service.ErrInvalidQuestion):
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新题目失败", "data": nil})
+		}
 		return
 	}
 
@@ -226,3 +541,200 @@ func (c *QuestionController) DeleteQuestionHandler(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功", "data": nil})
 }
+
+// SetQuestionStatusHandler 归档题目或将归档的题目放回题库
+func (c *QuestionController) SetQuestionStatusHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+	questionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的题目ID", "data": nil})
+		return
+	}
+
+	var req struct {
+		Status model.QuestionStatus `json:"status" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误", "data": nil})
+		return
+	}
+
+	err = c.questionService.SetQuestionStatus(int64(userID.(uint)), questionID, req.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrQuestionNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		case errors.Is(err, service.ErrInvalidQuestionStatus):
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "修改题目状态失败", "data": nil})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "修改成功", "data": nil})
+}
+
+// SetQuestionKnowledgePointsHandler 设置题目关联的知识点，替换原有的关联，传入空数组时取消全部关联
+func (c *QuestionController) SetQuestionKnowledgePointsHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+	questionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的题目ID", "data": nil})
+		return
+	}
+
+	var req struct {
+		KnowledgePointIDs []int64 `json:"knowledgePointIds"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请求参数错误", "data": nil})
+		return
+	}
+
+	points, err := c.knowledgeService.SetQuestionKnowledgePoints(int64(userID.(uint)), questionID, req.KnowledgePointIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrQuestionNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"code": 404, "message": err.Error(), "data": nil})
+		case errors.Is(err, service.ErrKnowledgeNodeNotFound),
+			errors.Is(err, service.ErrInvalidKnowledgeNode):
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error(), "data": nil})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "设置知识点失败", "data": nil})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"code": 200, "message": "设置成功", "data": knowledgePointRefs(points)})
+}
+
+// questionResponse 将题目转换为接口返回格式
+func questionResponse(q *model.Question) map[string]interface{} {
+	var opts []string
+	json.Unmarshal([]byte(q.Options), &opts)
+
+	return map[string]interface{}{
+		"id":             q.ID,
+		"title":          q.Title,
+		"questionType":   q.QuestionType,
+		"options":        opts,
+		"answer":         q.Answer,
+		"explanation":    q.Explanation,
+		"codeTemplate":   q.CodeTemplate,
+		"testCases":      codeTestCases(q),
+		"keywords":       q.Keywords,
+		"language":       q.Language,
+		"aiModel":        q.AIModel,
+		"userID":         q.UserID,
+		"difficulty":     q.Difficulty,
+		"cognitiveLevel": q.CognitiveLevel,
+		"status":         q.Status,
+		"batchId":        q.BatchID,
+		"review": gin.H{
+			"verdict":    q.ReviewVerdict,
+			"issues":     service.ParseReviewIssues(q),
+			"suggestion": q.ReviewSuggestion,
+			"model":      q.ReviewModel,
+		},
+	}
+}
+
+// questionIDs 返回题目的ID列表
+func questionIDs(questions []*model.Question) []int64 {
+	ids := make([]int64, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	return ids
+}
+
+// knowledgePointRefs 将题目关联的知识点转换为接口返回格式
+func knowledgePointRefs(points []*model.KnowledgeNode) []gin.H {
+	refs := make([]gin.H, 0, len(points))
+	for _, point := range points {
+		refs = append(refs, gin.H{"id": point.ID, "name": point.Name})
+	}
+	return refs
+}
+
+// generateRequest 读取生成题目的查询参数，难度格式错误时返回400响应
+func generateRequest(ctx *gin.Context) (service.GenerateRequest, bool) {
+	numQuestions, _ := strconv.Atoi(ctx.Query("num_questions"))
+	difficulty, err := optionalInt(ctx, "difficulty")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的难度", "data": nil})
+		return service.GenerateRequest{}, false
+	}
+	templateID, err1 := optionalInt(ctx, "template_id")
+	templateVersion, err2 := optionalInt(ctx, "template_version")
+	if err1 != nil || err2 != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的提示语模板", "data": nil})
+		return service.GenerateRequest{}, false
+	}
+	knowledgePointID, err := optionalInt(ctx, "knowledge_point_id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的知识点", "data": nil})
+		return service.GenerateRequest{}, false
+	}
+
+	return service.GenerateRequest{
+		AIModel:          ctx.Query("ai_model"),
+		Language:         ctx.Query("language"),
+		QuestionType:     model.QuestionType(ctx.Query("question_type")),
+		Keywords:         ctx.Query("keywords"),
+		NumQuestions:     numQuestions,
+		Difficulty:       difficulty,
+		CognitiveLevel:   model.CognitiveLevel(ctx.Query("cognitive_level")),
+		TemplateID:       int64(templateID),
+		TemplateVersion:  templateVersion,
+		Review:           ctx.Query("review") == "true",
+		ReviewModel:      ctx.Query("review_model"),
+		KnowledgePointID: int64(knowledgePointID),
+	}, true
+}
+
+// optionalInt 读取可选的整数查询参数，未填写时返回0
+func optionalInt(ctx *gin.Context, key string) (int, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// optionalTime 读取可选的时间查询参数，未提供时返回零值
+// 只有日期时按本地时间的当天零点解析，endOfDay 为 true 时取次日零点，使结束日期包含当天
+// 数据库中的时间以本地时区的文本保存并按文本比较，因此统一转换为本地时间
+func optionalTime(ctx *gin.Context, key string, endOfDay bool) (time.Time, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t.In(time.Local), err
+}
+
+// codeTestCases 解析编程题的测试用例，其他题型返回 nil
+func codeTestCases(q *model.Question) []service.CodeTestCase {
+	if q.QuestionType != model.QuestionTypeCoding {
+		return nil
+	}
+	var tests []service.CodeTestCase
+	json.Unmarshal([]byte(q.TestCases), &tests)
+	return tests
+}
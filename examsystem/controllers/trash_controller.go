@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"errors"
+	"examsystem/dao/model"
+	"examsystem/models/dto"
+	"examsystem/service"
+	"examsystem/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashController 回收站控制器
+type TrashController struct {
+	trashService *service.TrashService
+}
+
+// NewTrashController 创建回收站控制器
+func NewTrashController(trashService *service.TrashService) *TrashController {
+	return &TrashController{
+		trashService: trashService,
+	}
+}
+
+// GetTrashHandler 获取回收站中的题目和试卷
+func (c *TrashController) GetTrashHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+
+	questions, papers, err := c.trashService.GetTrash(int64(userID.(uint)))
+	if err != nil {
+		utils.InternalError(ctx, "获取回收站失败: "+err.Error())
+		return
+	}
+
+	resp := &dto.TrashResponse{
+		Questions: make([]*dto.TrashQuestionResponse, 0, len(questions)),
+		Papers:    make([]*dto.TrashPaperResponse, 0, len(papers)),
+	}
+	for _, item := range questions {
+		resp.Questions = append(resp.Questions, &dto.TrashQuestionResponse{
+			ID:           item.Question.ID,
+			Title:        item.Question.Title,
+			QuestionType: string(item.Question.QuestionType),
+			Language:     item.Question.Language,
+			DeletedAt:    item.Question.DeletedAt.Time,
+			ExpiresAt:    item.ExpiresAt,
+			Papers:       toTrashPaperRefs(item.Papers),
+		})
+	}
+	for _, item := range papers {
+		resp.Papers = append(resp.Papers, &dto.TrashPaperResponse{
+			ID:            item.Paper.ID,
+			Title:         item.Paper.Title,
+			TotalScore:    item.Paper.TotalScore,
+			QuestionCount: item.QuestionCount,
+			DeletedAt:     *item.Paper.DeletedAt,
+			ExpiresAt:     item.ExpiresAt,
+		})
+	}
+	utils.Success(ctx, resp)
+}
+
+// RestoreQuestionHandler 从回收站恢复题目
+func (c *TrashController) RestoreQuestionHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+	questionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的题目ID")
+		return
+	}
+
+	papers, err := c.trashService.RestoreQuestion(int64(userID.(uint)), questionID)
+	if err != nil {
+		handleTrashError(ctx, "恢复题目失败", err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, "恢复成功", &dto.RestoreQuestionResponse{ID: questionID, Papers: toTrashPaperRefs(papers)})
+}
+
+// RestorePaperHandler 从回收站恢复试卷，试卷中已删除的题目一并恢复
+func (c *TrashController) RestorePaperHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	restoredIDs, err := c.trashService.RestorePaper(int64(userID.(uint)), paperID)
+	if err != nil {
+		handleTrashError(ctx, "恢复试卷失败", err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, "恢复成功", &dto.RestorePaperResponse{ID: paperID, RestoredQuestionIDs: restoredIDs})
+}
+
+// PurgeQuestionHandler 彻底删除回收站中的题目
+func (c *TrashController) PurgeQuestionHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+	questionID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的题目ID")
+		return
+	}
+
+	if err := c.trashService.PurgeQuestion(int64(userID.(uint)), questionID); err != nil {
+		handleTrashError(ctx, "彻底删除题目失败", err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, "已彻底删除", nil)
+}
+
+// PurgePaperHandler 彻底删除回收站中的试卷
+func (c *TrashController) PurgePaperHandler(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		utils.Unauthorized(ctx, "未登录")
+		return
+	}
+	paperID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.ParamError(ctx, "无效的试卷ID")
+		return
+	}
+
+	if err := c.trashService.PurgePaper(int64(userID.(uint)), paperID); err != nil {
+		handleTrashError(ctx, "彻底删除试卷失败", err)
+		return
+	}
+	utils.SuccessWithMsg(ctx, "已彻底删除", nil)
+}
+
+// toTrashPaperRefs 转换为试卷引用列表
+func toTrashPaperRefs(papers []*model.Paper) []*dto.TrashPaperRef {
+	refs := make([]*dto.TrashPaperRef, 0, len(papers))
+	for _, paper := range papers {
+		refs = append(refs, &dto.TrashPaperRef{ID: paper.ID, Title: paper.Title, Deleted: paper.DeletedAt != nil})
+	}
+	return refs
+}
+
+// handleTrashError 根据回收站服务返回的错误类型输出响应
+func handleTrashError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrTrashItemNotFound):
+		utils.NotFound(ctx, err.Error())
+	case errors.Is(err, service.ErrTrashItemInUse):
+		utils.BusinessError(ctx, err.Error())
+	default:
+		utils.InternalError(ctx, msg+": "+err.Error())
+	}
+}
@@ -16,28 +16,34 @@ import (
 // InitDB 初始化数据库连接，可选择是否执行初始化和清空
 func InitDB(reset bool) (*gorm.DB, error) {
 	dbPath := "examsystem.db"
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	// TranslateError 将唯一约束冲突转换为 gorm.ErrDuplicatedKey
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库失败: %v", err)
 	}
 
-	// 仅当 reset 为 true 时执行初始化 SQL 脚本和清空数据
-	if reset {
-		sqlFilePath := filepath.Join("migrations", "init.sql")
-		sqlContent, err := ioutil.ReadFile(sqlFilePath)
-		if err != nil {
-			return nil, fmt.Errorf("读取 init.sql 失败: %v", err)
-		}
-
-		if err := executeSQLScriptInTransaction(db, string(sqlContent)); err != nil {
-			return nil, fmt.Errorf("执行 init.sql 脚本失败: %v", err)
-		}
+	// 每次启动时将已有的数据库结构升级到 init.sql 中的定义，并创建缺少的表
+	sqlFilePath := filepath.Join("migrations", "init.sql")
+	sqlContent, err := ioutil.ReadFile(sqlFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取 init.sql 失败: %v", err)
+	}
+	if err := migrateSchema(db, string(sqlContent)); err != nil {
+		return nil, fmt.Errorf("升级数据库结构失败: %v", err)
+	}
 
+	// 仅当 reset 为 true 时清空数据
+	if reset {
 		if err := clearAllData(db); err != nil {
 			return nil, fmt.Errorf("清空数据失败: %v", err)
 		}
 	}
 
+	// 创建题目全文检索表，并为已有的题目建立索引
+	if err := SyncQuestionSearchIndex(db); err != nil {
+		return nil, fmt.Errorf("同步题目检索索引失败: %v", err)
+	}
+
 	// 创建默认管理员账号（如果不存在）
 	var count int64
 	db.Table("users").Where("username = ?", "admin").Count(&count)
@@ -66,7 +72,8 @@ func InitDB(reset bool) (*gorm.DB, error) {
 // 清空数据库所有数据（保留表结构）
 func clearAllData(db *gorm.DB) error {
 	var tables []struct{ Name string }
-	if err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE'sqlite_%'").Scan(&tables).Error; err != nil {
+	// 全文检索表的内部表随检索表一起清空，不能单独清空
+	if err := db.Raw("SELECT name FROM pragma_table_list WHERE schema = 'main' AND type IN ('table', 'virtual') AND name NOT LIKE'sqlite_%'").Scan(&tables).Error; err != nil {
 		return fmt.Errorf("获取表名失败: %v", err)
 	}
 
@@ -86,43 +93,6 @@ func clearAllData(db *gorm.DB) error {
 	return nil
 }
 
-// 在事务中执行SQL脚本
-func executeSQLScriptInTransaction(db *gorm.DB, sqlScript string) error {
-	sqlDB, err := db.DB()
-	if err != nil {
-		return err
-	}
-
-	tx, err := sqlDB.Begin()
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
-		}
-	}()
-
-	statements := splitSQLStatements(sqlScript)
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" {
-			continue
-		}
-		_, err = tx.Exec(stmt)
-		if err != nil {
-			return fmt.Errorf("执行SQL语句失败: %v\n语句: %s", err, stmt)
-		}
-	}
-	return nil
-}
-
 // 分割SQL语句
 func splitSQLStatements(sqlScript string) []string {
 	var statements []string
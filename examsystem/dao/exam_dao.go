@@ -0,0 +1,287 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"examsystem/dao/model"
+)
+
+// ExamDAO 考试数据访问对象
+type ExamDAO struct {
+	DB *gorm.DB
+}
+
+// NewExamDAO 创建考试DAO实例
+func NewExamDAO(db *gorm.DB) *ExamDAO {
+	return &ExamDAO{DB: db}
+}
+
+// CreateSession 创建考试会话
+func (dao *ExamDAO) CreateSession(session *model.ExamSession) error {
+	return dao.DB.Create(session).Error
+}
+
+// CountSessionsByPaperID 统计试卷的考试记录数量
+func (dao *ExamDAO) CountSessionsByPaperID(paperID int64) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&model.ExamSession{}).Where("paper_id = ?", paperID).Count(&count).Error
+	return count, err
+}
+
+// CountAnswersByQuestionID 统计题目的作答记录数量
+func (dao *ExamDAO) CountAnswersByQuestionID(questionID int64) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&model.ExamAnswer{}).Where("question_id = ?", questionID).Count(&count).Error
+	return count, err
+}
+
+// GetSessionByID 根据ID获取考试会话
+func (dao *ExamDAO) GetSessionByID(id int64) (*model.ExamSession, error) {
+	var session model.ExamSession
+	err := dao.DB.First(&session, id).Error
+	return &session, err
+}
+
+// GetInProgressSession 获取用户在某张试卷上进行中的考试会话
+func (dao *ExamDAO) GetInProgressSession(userID, paperID int64) (*model.ExamSession, error) {
+	var session model.ExamSession
+	err := dao.DB.Where("user_id = ? AND paper_id = ? AND status = ?", userID, paperID, model.ExamStatusInProgress).
+		First(&session).Error
+	return &session, err
+}
+
+// GetSessionsByUserID 获取用户的考试会话列表（支持分页）
+func (dao *ExamDAO) GetSessionsByUserID(userID int64, page, pageSize int) ([]*model.ExamSession, int64, error) {
+	var sessions []*model.ExamSession
+	var total int64
+
+	query := dao.DB.Model(&model.ExamSession{}).Where("user_id = ?", userID)
+
+	// 查询总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 获取数据列表
+	offset := (page - 1) * pageSize
+	err := query.Order("started_at DESC").Offset(offset).Limit(pageSize).Find(&sessions).Error
+	return sessions, total, err
+}
+
+// UpdateSession 更新考试会话
+func (dao *ExamDAO) UpdateSession(session *model.ExamSession) error {
+	return dao.DB.Save(session).Error
+}
+
+// MarkSubmitted 将进行中的考试标记为已提交
+// 仅当考试仍处于进行中时才会更新，返回 false 表示已被其他请求（如超时自动提交）抢先提交
+func (dao *ExamDAO) MarkSubmitted(session *model.ExamSession, submittedAt time.Time, autoSubmitted bool) (bool, error) {
+	result := dao.DB.Model(&model.ExamSession{}).
+		Where("id = ? AND status = ?", session.ID, model.ExamStatusInProgress).
+		Updates(map[string]interface{}{
+			"status":         model.ExamStatusSubmitted,
+			"submitted_at":   submittedAt,
+			"auto_submitted": autoSubmitted,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	session.Status = model.ExamStatusSubmitted
+	session.SubmittedAt = &submittedAt
+	session.AutoSubmitted = autoSubmitted
+	return true, nil
+}
+
+// UpdateSessionStatus 将考试状态从 from 改为 to，返回 false 表示考试已不处于 from 状态
+func (dao *ExamDAO) UpdateSessionStatus(session *model.ExamSession, from, to model.ExamStatus) (bool, error) {
+	result := dao.DB.Model(&model.ExamSession{}).
+		Where("id = ? AND status = ?", session.ID, from).
+		Update("status", to)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	session.Status = to
+	return true, nil
+}
+
+// GetSessionsByStatus 获取处于指定状态的全部考试会话
+func (dao *ExamDAO) GetSessionsByStatus(status model.ExamStatus) ([]*model.ExamSession, error) {
+	var sessions []*model.ExamSession
+	err := dao.DB.Where("status = ?", status).Order("id ASC").Find(&sessions).Error
+	return sessions, err
+}
+
+// GetExpiredSessions 获取已超过截止时间但仍处于进行中的考试会话
+func (dao *ExamDAO) GetExpiredSessions(now time.Time, limit int) ([]*model.ExamSession, error) {
+	var sessions []*model.ExamSession
+	err := dao.DB.Where("status = ? AND deadline IS NOT NULL AND deadline <= ?", model.ExamStatusInProgress, now).
+		Order("deadline ASC").
+		Limit(limit).
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// SaveAnswers 保存作答（同一题目重复提交时覆盖之前的答案）
+// 在同一事务中确认考试仍处于进行中，返回 false 表示考试已被提交，作答不会保存
+func (dao *ExamDAO) SaveAnswers(sessionID int64, answers []*model.ExamAnswer) (bool, error) {
+	saved := false
+	err := dao.DB.Transaction(func(tx *gorm.DB) error {
+		// 先写入考试会话，与提交考试的更新互斥：提交在此之前完成时不再保存，之后完成时能读到本次作答
+		result := tx.Model(&model.ExamSession{}).
+			Where("id = ? AND status = ?", sessionID, model.ExamStatusInProgress).
+			Update("updated_at", time.Now())
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		saved = true
+		if len(answers) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}, {Name: "question_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"answer", "updated_at"}),
+		}).Create(&answers).Error
+	})
+	return saved && err == nil, err
+}
+
+// GetAnswersBySessionID 获取考试会话的全部作答
+func (dao *ExamDAO) GetAnswersBySessionID(sessionID int64) ([]*model.ExamAnswer, error) {
+	var answers []*model.ExamAnswer
+	err := dao.DB.Where("session_id = ?", sessionID).Find(&answers).Error
+	return answers, err
+}
+
+// SaveGradingResult 在同一事务中保存每题得分明细和考试总分
+func (dao *ExamDAO) SaveGradingResult(session *model.ExamSession, answers []*model.ExamAnswer) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		for _, answer := range answers {
+			if err := tx.Save(answer).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(session).Error
+	})
+}
+
+// MarkingPaperRow 试卷的待阅卷统计
+type MarkingPaperRow struct {
+	PaperID         int64
+	Title           string
+	PendingAnswers  int64
+	PendingSessions int64
+}
+
+// MarkingAnswerRow 阅卷列表中的作答，附带考生和考试会话信息
+type MarkingAnswerRow struct {
+	model.ExamAnswer
+	UserID        int64
+	Username      string
+	SessionStatus model.ExamStatus
+	SubmittedAt   *time.Time
+}
+
+// GetMarkingPapers 统计各试卷已提交考试中待人工阅卷的作答数量，creatorID 为 0 时统计全部试卷
+func (dao *ExamDAO) GetMarkingPapers(creatorID int64) ([]*MarkingPaperRow, error) {
+	var rows []*MarkingPaperRow
+	query := dao.DB.Table("exam_answers AS a").
+		Select("s.paper_id AS paper_id, p.title AS title, COUNT(*) AS pending_answers, COUNT(DISTINCT a.session_id) AS pending_sessions").
+		Joins("JOIN exam_sessions AS s ON s.id = a.session_id").
+		Joins("JOIN papers AS p ON p.id = s.paper_id").
+		Where("s.status = ? AND a.graded_at IS NULL", model.ExamStatusSubmitted)
+	if creatorID != 0 {
+		query = query.Where("p.creator_id = ?", creatorID)
+	}
+	err := query.Group("s.paper_id, p.title").Order("s.paper_id").Scan(&rows).Error
+	return rows, err
+}
+
+// GetMarkingAnswers 获取试卷已提交考试的作答列表（支持分页），pendingOnly 为 true 时只返回待人工阅卷的作答
+func (dao *ExamDAO) GetMarkingAnswers(paperID int64, pendingOnly bool, page, pageSize int) ([]*MarkingAnswerRow, int64, error) {
+	var rows []*MarkingAnswerRow
+	var total int64
+
+	query := dao.DB.Table("exam_answers AS a").
+		Joins("JOIN exam_sessions AS s ON s.id = a.session_id").
+		Joins("LEFT JOIN users AS u ON u.id = s.user_id").
+		Where("s.paper_id = ? AND s.status IN ?", paperID, []model.ExamStatus{model.ExamStatusSubmitted, model.ExamStatusGraded})
+	if pendingOnly {
+		query = query.Where("a.graded_at IS NULL")
+	}
+
+	// 查询总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 获取数据列表
+	offset := (page - 1) * pageSize
+	err := query.Select("a.*, s.user_id AS user_id, u.username AS username, s.status AS session_status, s.submitted_at AS submitted_at").
+		Order("s.submitted_at ASC, a.session_id ASC, a.id ASC").
+		Offset(offset).Limit(pageSize).
+		Scan(&rows).Error
+	return rows, total, err
+}
+
+// GetAnswerByID 根据ID获取作答
+func (dao *ExamDAO) GetAnswerByID(id int64) (*model.ExamAnswer, error) {
+	var answer model.ExamAnswer
+	err := dao.DB.First(&answer, id).Error
+	return &answer, err
+}
+
+// SaveMark 保存人工阅卷结果，并在同一事务中重新计算考试总分
+func (dao *ExamDAO) SaveMark(answer *model.ExamAnswer) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(answer).Error; err != nil {
+			return err
+		}
+		return syncSessionScore(tx, answer.SessionID)
+	})
+}
+
+// FinalizeSession 将已提交的考试标记为已评分，并重新计算考试总分
+// 仅当考试仍处于已提交状态时才会更新，返回 false 表示考试已被其他请求完成阅卷
+func (dao *ExamDAO) FinalizeSession(session *model.ExamSession, gradedAt time.Time) (bool, error) {
+	finalized := false
+	err := dao.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.ExamSession{}).
+			Where("id = ? AND status = ?", session.ID, model.ExamStatusSubmitted).
+			Updates(map[string]interface{}{
+				"status":    model.ExamStatusGraded,
+				"graded_at": gradedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		finalized = true
+		return syncSessionScore(tx, session.ID)
+	})
+	return finalized, err
+}
+
+// syncSessionScore 将考试总分重新计算为各题得分之和
+func syncSessionScore(tx *gorm.DB, sessionID int64) error {
+	var score float64
+	if err := tx.Model(&model.ExamAnswer{}).
+		Where("session_id = ?", sessionID).
+		Select("COALESCE(ROUND(SUM(score), 2), 0)").
+		Scan(&score).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&model.ExamSession{}).
+		Where("id = ?", sessionID).
+		Update("score", score).Error
+}
@@ -0,0 +1,172 @@
+package dao
+
+import (
+	"examsystem/dao/model"
+
+	"gorm.io/gorm"
+)
+
+// knowledgeClosureCTE 列出每个知识体系节点及其自身和全部下级节点，用于按子树统计
+const knowledgeClosureCTE = `WITH RECURSIVE closure(ancestor_id, node_id) AS (
+    SELECT id, id FROM knowledge_nodes
+    UNION ALL
+    SELECT closure.ancestor_id, knowledge_nodes.id FROM knowledge_nodes JOIN closure ON knowledge_nodes.parent_id = closure.node_id
+)`
+
+// knowledgeSubtreeQuestions 关联到某个节点或其下级知识点的题目ID
+const knowledgeSubtreeQuestions = `SELECT question_id FROM question_knowledge_points WHERE knowledge_point_id IN (
+    WITH RECURSIVE subtree(id) AS (
+        SELECT ?
+        UNION ALL
+        SELECT knowledge_nodes.id FROM knowledge_nodes JOIN subtree ON knowledge_nodes.parent_id = subtree.id
+    )
+    SELECT id FROM subtree
+)`
+
+// KnowledgeDAO 知识体系数据访问对象
+type KnowledgeDAO struct {
+	DB *gorm.DB
+}
+
+// NewKnowledgeDAO 创建知识体系DAO实例
+func NewKnowledgeDAO(db *gorm.DB) *KnowledgeDAO {
+	return &KnowledgeDAO{DB: db}
+}
+
+// CreateNode 创建节点
+func (dao *KnowledgeDAO) CreateNode(node *model.KnowledgeNode) error {
+	return dao.DB.Create(node).Error
+}
+
+// GetNodeByID 获取节点
+func (dao *KnowledgeDAO) GetNodeByID(id int64) (*model.KnowledgeNode, error) {
+	var node model.KnowledgeNode
+	err := dao.DB.First(&node, id).Error
+	return &node, err
+}
+
+// GetNodesByIDs 批量获取节点
+func (dao *KnowledgeDAO) GetNodesByIDs(ids []int64) ([]*model.KnowledgeNode, error) {
+	var nodes []*model.KnowledgeNode
+	if len(ids) == 0 {
+		return nodes, nil
+	}
+	err := dao.DB.Where("id IN ?", ids).Find(&nodes).Error
+	return nodes, err
+}
+
+// GetAllNodes 获取全部节点，同级节点按排列顺序排列
+func (dao *KnowledgeDAO) GetAllNodes() ([]*model.KnowledgeNode, error) {
+	var nodes []*model.KnowledgeNode
+	err := dao.DB.Order("parent_id, sort_order, id").Find(&nodes).Error
+	return nodes, err
+}
+
+// UpdateNode 更新节点的名称、说明和排列顺序
+func (dao *KnowledgeDAO) UpdateNode(node *model.KnowledgeNode) error {
+	return dao.DB.Model(node).Updates(map[string]interface{}{
+		"name":        node.Name,
+		"description": node.Description,
+		"sort_order":  node.SortOrder,
+	}).Error
+}
+
+// DeleteNode 删除节点及其与题目的关联
+func (dao *KnowledgeDAO) DeleteNode(id int64) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("knowledge_point_id = ?", id).Delete(&model.QuestionKnowledgePoint{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.KnowledgeNode{}, id).Error
+	})
+}
+
+// CountChildren 统计节点的下级节点数量
+func (dao *KnowledgeDAO) CountChildren(id int64) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&model.KnowledgeNode{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// ExistsSiblingName 判断同一上级节点下是否已有同名节点，excludeID 为更新的节点自身
+func (dao *KnowledgeDAO) ExistsSiblingName(parentID int64, name string, excludeID int64) (bool, error) {
+	var count int64
+	err := dao.DB.Model(&model.KnowledgeNode{}).
+		Where("parent_id = ? AND name = ? AND id <> ?", parentID, name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SetQuestionKnowledgePoints 将题目关联的知识点替换为 pointIDs
+func (dao *KnowledgeDAO) SetQuestionKnowledgePoints(questionID int64, pointIDs []int64) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("question_id = ?", questionID).Delete(&model.QuestionKnowledgePoint{}).Error; err != nil {
+			return err
+		}
+		return linkKnowledgePoints(tx, []int64{questionID}, pointIDs)
+	})
+}
+
+// GetKnowledgePointsByQuestionIDs 获取题目关联的知识点，按题目ID分组
+func (dao *KnowledgeDAO) GetKnowledgePointsByQuestionIDs(questionIDs []int64) (map[int64][]*model.KnowledgeNode, error) {
+	result := make(map[int64][]*model.KnowledgeNode)
+	if len(questionIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		QuestionID int64
+		model.KnowledgeNode
+	}
+	err := dao.DB.Table("question_knowledge_points").
+		Select("question_knowledge_points.question_id, knowledge_nodes.*").
+		Joins("JOIN knowledge_nodes ON knowledge_nodes.id = question_knowledge_points.knowledge_point_id").
+		Where("question_knowledge_points.question_id IN ?", questionIDs).
+		Order("knowledge_nodes.sort_order, knowledge_nodes.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		result[rows[i].QuestionID] = append(result[rows[i].QuestionID], &rows[i].KnowledgeNode)
+	}
+	return result, nil
+}
+
+// CountQuestionsByNode 统计用户题库中关联到每个节点或其下级知识点的题目数量（同一道题目只计一次）
+func (dao *KnowledgeDAO) CountQuestionsByNode(userID int64) (map[int64]int64, error) {
+	var rows []struct {
+		NodeID int64
+		Count  int64
+	}
+	err := dao.DB.Raw(knowledgeClosureCTE+`
+SELECT closure.ancestor_id AS node_id, COUNT(DISTINCT questions.id) AS count
+FROM closure
+JOIN question_knowledge_points ON question_knowledge_points.knowledge_point_id = closure.node_id
+JOIN questions ON questions.id = question_knowledge_points.question_id
+WHERE questions.user_id = ? AND questions.status = ? AND questions.deleted_at IS NULL
+GROUP BY closure.ancestor_id`, userID, model.QuestionStatusActive).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]int64)
+	for _, row := range rows {
+		counts[row.NodeID] = row.Count
+	}
+	return counts, nil
+}
+
+// linkKnowledgePoints 将每道题目关联到每个知识点
+func linkKnowledgePoints(tx *gorm.DB, questionIDs, pointIDs []int64) error {
+	links := make([]*model.QuestionKnowledgePoint, 0, len(questionIDs)*len(pointIDs))
+	for _, questionID := range questionIDs {
+		for _, pointID := range pointIDs {
+			links = append(links, &model.QuestionKnowledgePoint{QuestionID: questionID, KnowledgePointID: pointID})
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return tx.Create(&links).Error
+}
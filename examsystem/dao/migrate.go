@@ -0,0 +1,245 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// columnMigration 为已有的表补充后来新增的列。init.sql 中的 CREATE TABLE IF NOT EXISTS 不会修改已有的表
+type columnMigration struct {
+	Table      string
+	Column     string
+	Definition string // ALTER TABLE ADD COLUMN 中列名之后的部分，与 init.sql 中的定义一致
+	Backfill   string // 添加列后为已有数据补充的值，为空时使用列的默认值
+}
+
+// tableRebuild 已有的表的约束无法用 ALTER TABLE 修改时，按 init.sql 中的定义重建表并复制数据
+type tableRebuild struct {
+	Table  string
+	Marker string // 最新的表定义中才有的片段，已有的表定义中不包含时重建
+}
+
+// columnMigrations 按版本顺序排列，只对已存在且缺少该列的表执行
+var columnMigrations = []columnMigration{
+	{Table: "papers", Column: "published", Definition: "BOOLEAN NOT NULL DEFAULT 0", Backfill: "1"}, // 发布状态出现前的试卷所有用户都能参加考试，升级后保持不变
+	{Table: "exam_sessions", Column: "score", Definition: "REAL NOT NULL DEFAULT 0"},
+	{Table: "exam_sessions", Column: "graded_at", Definition: "DATETIME DEFAULT NULL"},
+	{Table: "exam_answers", Column: "score", Definition: "REAL NOT NULL DEFAULT 0"},
+	{Table: "exam_answers", Column: "is_correct", Definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{Table: "exam_answers", Column: "feedback", Definition: "TEXT DEFAULT ''"},
+	{Table: "exam_answers", Column: "graded_at", Definition: "DATETIME DEFAULT NULL"},
+	{Table: "papers", Column: "duration_minutes", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "papers", Column: "open_at", Definition: "DATETIME DEFAULT NULL"},
+	{Table: "papers", Column: "close_at", Definition: "DATETIME DEFAULT NULL"},
+	{Table: "exam_sessions", Column: "deadline", Definition: "DATETIME DEFAULT NULL"},
+	{Table: "exam_sessions", Column: "auto_submitted", Definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{Table: "papers", Column: "shuffle_questions", Definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{Table: "papers", Column: "shuffle_options", Definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{Table: "exam_sessions", Column: "shuffle_seed", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "exam_sessions", Column: "shuffle_questions", Definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{Table: "exam_sessions", Column: "shuffle_options", Definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{Table: "questions", Column: "code_template", Definition: "TEXT DEFAULT ''"},
+	{Table: "questions", Column: "test_cases", Definition: "TEXT DEFAULT ''"},
+	{Table: "exam_answers", Column: "test_results", Definition: "TEXT DEFAULT ''"},
+	{Table: "exam_answers", Column: "comment", Definition: "TEXT DEFAULT ''"},
+	{Table: "exam_answers", Column: "marked_by", Definition: "INTEGER DEFAULT NULL"},
+	{Table: "question_jobs", Column: "report", Definition: "TEXT DEFAULT ''"},
+	{Table: "questions", Column: "difficulty", Definition: "INTEGER NOT NULL DEFAULT 3 CHECK (difficulty BETWEEN 1 AND 5)"},
+	{Table: "questions", Column: "cognitive_level", Definition: "VARCHAR(20) DEFAULT '' CHECK (cognitive_level IN ('', 'remember', 'understand', 'apply', 'analyze', 'evaluate', 'create'))"},
+	{Table: "question_jobs", Column: "difficulty", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "question_jobs", Column: "cognitive_level", Definition: "VARCHAR(20) DEFAULT ''"},
+	{Table: "question_jobs", Column: "template_id", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "question_jobs", Column: "template_version", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "questions", Column: "review_verdict", Definition: "VARCHAR(20) DEFAULT '' CHECK (review_verdict IN ('', 'pass', 'flagged'))"},
+	{Table: "questions", Column: "review_issues", Definition: "TEXT DEFAULT ''"},
+	{Table: "questions", Column: "review_suggestion", Definition: "TEXT DEFAULT ''"},
+	{Table: "questions", Column: "review_model", Definition: "VARCHAR(50) DEFAULT ''"},
+	{Table: "question_jobs", Column: "review", Definition: "BOOLEAN NOT NULL DEFAULT 0"},
+	{Table: "question_jobs", Column: "review_model", Definition: "VARCHAR(50) DEFAULT ''"},
+	{Table: "questions", Column: "status", Definition: "VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('draft', 'active', 'archived'))", Backfill: "'active'"}, // 状态出现前的题目都在题库中
+	{Table: "questions", Column: "batch_id", Definition: "VARCHAR(32) DEFAULT ''"},
+	{Table: "question_jobs", Column: "batch_id", Definition: "VARCHAR(32) DEFAULT ''"},
+	{Table: "question_jobs", Column: "knowledge_point_id", Definition: "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// tableRebuilds 需要修改约束的表
+var tableRebuilds = []tableRebuild{
+	{Table: "exam_sessions", Marker: "'grading'"}, // 考试状态增加 graded 和 grading
+	{Table: "questions", Marker: "'coding'"},      // 题型增加判断题、填空题、简答题和编程题
+}
+
+// migrateSchema 将已有的数据库结构升级到 init.sql 中的定义：
+// 先重建约束有变化的表，再为已有的表补充新增的列，最后执行 init.sql 创建缺少的表和索引。
+// 全部修改在同一个事务中完成，任何一步失败时数据库保持原样
+func migrateSchema(db *gorm.DB, initSQL string) (err error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// 重建表时删除旧表不能级联删除引用它的数据；外键设置在事务中修改无效，需要在事务外关闭
+	var foreignKeys int
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("禁用外键约束失败: %v", err)
+	}
+	defer conn.ExecContext(ctx, fmt.Sprintf("PRAGMA foreign_keys = %d", foreignKeys))
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var statements []string
+	for _, stmt := range splitSQLStatements(initSQL) {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+
+	for _, rebuild := range tableRebuilds {
+		if err = rebuildTable(tx, statements, rebuild); err != nil {
+			return fmt.Errorf("重建 %s 表失败: %v", rebuild.Table, err)
+		}
+	}
+
+	for _, migration := range columnMigrations {
+		if err = addColumn(tx, migration); err != nil {
+			return fmt.Errorf("为 %s 表添加 %s 列失败: %v", migration.Table, migration.Column, err)
+		}
+	}
+
+	for _, stmt := range statements {
+		if _, err = tx.Exec(stmt); err != nil {
+			return fmt.Errorf("执行SQL语句失败: %v\n语句: %s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// rebuildTable 按 init.sql 中的定义创建新表，复制两个表共有的列后替换旧表。
+// 旧表的索引随旧表删除，之后由 init.sql 重新创建
+func rebuildTable(tx *sql.Tx, statements []string, rebuild tableRebuild) error {
+	current, err := tableDefinition(tx, rebuild.Table)
+	if err != nil || current == "" || strings.Contains(current, rebuild.Marker) {
+		return err
+	}
+
+	prefix := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (", rebuild.Table)
+	var create string
+	for _, stmt := range statements {
+		if strings.Contains(stmt, prefix) {
+			create = strings.Replace(stmt, prefix, fmt.Sprintf("CREATE TABLE %s_new (", rebuild.Table), 1)
+			break
+		}
+	}
+	if create == "" {
+		return fmt.Errorf("init.sql 中没有 %s 表的定义", rebuild.Table)
+	}
+	if _, err := tx.Exec(create); err != nil {
+		return err
+	}
+
+	oldColumns, err := tableColumns(tx, rebuild.Table)
+	if err != nil {
+		return err
+	}
+	newColumns, err := tableColumns(tx, rebuild.Table+"_new")
+	if err != nil {
+		return err
+	}
+	var columns []string
+	for _, column := range newColumns {
+		for _, old := range oldColumns {
+			if column == old {
+				columns = append(columns, column)
+				break
+			}
+		}
+	}
+
+	columnList := strings.Join(columns, ", ")
+	for _, stmt := range []string{
+		fmt.Sprintf("INSERT INTO %s_new (%s) SELECT %s FROM %s", rebuild.Table, columnList, columnList, rebuild.Table),
+		fmt.Sprintf("DROP TABLE %s", rebuild.Table),
+		fmt.Sprintf("ALTER TABLE %s_new RENAME TO %s", rebuild.Table, rebuild.Table),
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn 表存在且缺少该列时添加列并补充已有数据
+func addColumn(tx *sql.Tx, migration columnMigration) error {
+	current, err := tableDefinition(tx, migration.Table)
+	if err != nil || current == "" {
+		return err
+	}
+	columns, err := tableColumns(tx, migration.Table)
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column == migration.Column {
+			return nil
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", migration.Table, migration.Column, migration.Definition)); err != nil {
+		return err
+	}
+	if migration.Backfill != "" {
+		_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET %s = %s", migration.Table, migration.Column, migration.Backfill))
+	}
+	return err
+}
+
+// tableDefinition 返回表的建表语句，表不存在时返回空字符串
+func tableDefinition(tx *sql.Tx, table string) (string, error) {
+	var definition string
+	err := tx.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&definition)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return definition, err
+}
+
+// tableColumns 返回表的全部列名
+func tableColumns(tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
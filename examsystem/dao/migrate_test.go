@@ -0,0 +1,269 @@
+package dao
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// baselineSchema 最初版本的数据库结构，以及早期版本新增的考试表
+const baselineSchema = `
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(20) DEFAULT 'user',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME DEFAULT NULL
+);
+CREATE TABLE questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    question_type VARCHAR(20) NOT NULL CHECK (question_type IN ('single', 'multiple')),
+    options TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    explanation TEXT DEFAULT '',
+    keywords VARCHAR(255) DEFAULT '',
+    language VARCHAR(50) NOT NULL,
+    ai_model VARCHAR(50) NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE papers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(255) NOT NULL,
+    description TEXT DEFAULT '',
+    total_score INTEGER NOT NULL DEFAULT 0,
+    creator_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME DEFAULT NULL,
+    FOREIGN KEY (creator_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE paper_questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    paper_id INTEGER NOT NULL,
+    question_id INTEGER NOT NULL,
+    question_order INTEGER NOT NULL,
+    score INTEGER DEFAULT 5,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME DEFAULT NULL,
+    FOREIGN KEY (paper_id) REFERENCES papers(id) ON DELETE CASCADE,
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+    UNIQUE (paper_id, question_id)
+);
+CREATE TABLE exam_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    paper_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'submitted')),
+    started_at DATETIME NOT NULL,
+    submitted_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (paper_id) REFERENCES papers(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE exam_answers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id INTEGER NOT NULL,
+    question_id INTEGER NOT NULL,
+    answer TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES exam_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+    UNIQUE (session_id, question_id)
+);
+CREATE INDEX idx_questions_user_id ON questions(user_id);
+INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x');
+INSERT INTO questions (id, title, question_type, options, answer, language, ai_model, user_id) VALUES (7, 'Go 的零值', 'single', '["A","B"]', 'A', 'Go', 'deepseek', 1);
+INSERT INTO papers (id, title, creator_id) VALUES (1, '期中考试', 1);
+INSERT INTO paper_questions (paper_id, question_id, question_order) VALUES (1, 7, 1);
+INSERT INTO exam_sessions (id, paper_id, user_id, status, started_at) VALUES (1, 1, 1, 'submitted', '2025-01-01 08:00:00');
+INSERT INTO exam_answers (session_id, question_id, answer) VALUES (1, 7, 'A');
+`
+
+// codingSchema 增加编程题和生成任务后的数据库结构，题型约束已是最新的，只缺少之后新增的列
+const codingSchema = `
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(20) DEFAULT 'user',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME DEFAULT NULL
+);
+CREATE TABLE questions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    question_type VARCHAR(20) NOT NULL CHECK (question_type IN ('single', 'multiple', 'judge', 'blank', 'short_answer', 'coding')),
+    options TEXT NOT NULL,
+    answer TEXT NOT NULL,
+    explanation TEXT DEFAULT '',
+    code_template TEXT DEFAULT '',
+    test_cases TEXT DEFAULT '',
+    keywords VARCHAR(255) DEFAULT '',
+    language VARCHAR(50) NOT NULL,
+    ai_model VARCHAR(50) NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE question_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    ai_model VARCHAR(50) NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    question_type VARCHAR(20) NOT NULL,
+    keywords VARCHAR(255) DEFAULT '',
+    num_questions INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT DEFAULT '',
+    question_ids TEXT DEFAULT '',
+    started_at DATETIME DEFAULT NULL,
+    finished_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x');
+INSERT INTO questions (id, title, question_type, options, answer, language, ai_model, user_id) VALUES (7, 'Go 的零值', 'single', '["A","B"]', 'A', 'Go', 'deepseek', 1);
+INSERT INTO question_jobs (user_id, ai_model, language, question_type, num_questions, status) VALUES (1, 'deepseek', 'Go', 'single', 5, 'running');
+`
+
+// openTestDB 打开临时文件中的数据库；内存数据库的每个连接都是独立的数据库
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// readInitSQL 读取当前的数据库结构定义
+func readInitSQL(t *testing.T) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("..", "migrations", "init.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// schemaOf 返回每个表的列定义和全部索引名
+func schemaOf(t *testing.T, db *gorm.DB) (map[string][]map[string]interface{}, []string) {
+	t.Helper()
+	var tables []string
+	if err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE 'questions_fts%' ORDER BY name").Scan(&tables).Error; err != nil {
+		t.Fatal(err)
+	}
+	columns := make(map[string][]map[string]interface{})
+	for _, table := range tables {
+		var info []map[string]interface{}
+		if err := db.Raw("SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?) ORDER BY name", table).Scan(&info).Error; err != nil {
+			t.Fatal(err)
+		}
+		columns[table] = info
+	}
+	var indexes []string
+	if err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'index' ORDER BY name").Scan(&indexes).Error; err != nil {
+		t.Fatal(err)
+	}
+	return columns, indexes
+}
+
+func TestMigrateSchemaUpgradesLegacyDatabase(t *testing.T) {
+	initSQL := readInitSQL(t)
+
+	fresh := openTestDB(t)
+	if err := migrateSchema(fresh, initSQL); err != nil {
+		t.Fatalf("migrate fresh database: %v", err)
+	}
+	wantColumns, wantIndexes := schemaOf(t, fresh)
+	if len(wantColumns["questions"]) == 0 || len(wantIndexes) == 0 {
+		t.Fatal("fresh database has no schema")
+	}
+
+	for name, schema := range map[string]string{"baseline": baselineSchema, "coding": codingSchema} {
+		t.Run(name, func(t *testing.T) {
+			testMigrateLegacySchema(t, initSQL, schema, wantColumns, wantIndexes)
+		})
+	}
+}
+
+func testMigrateLegacySchema(t *testing.T, initSQL, schema string, wantColumns map[string][]map[string]interface{}, wantIndexes []string) {
+	legacy := openTestDB(t)
+	for _, stmt := range splitSQLStatements(schema) {
+		if err := legacy.Exec(stmt).Error; err != nil {
+			t.Fatalf("create legacy schema: %v", err)
+		}
+	}
+	if err := migrateSchema(legacy, initSQL); err != nil {
+		t.Fatalf("migrate legacy database: %v", err)
+	}
+	// 再次启动时不做任何修改
+	if err := migrateSchema(legacy, initSQL); err != nil {
+		t.Fatalf("migrate twice: %v", err)
+	}
+
+	gotColumns, gotIndexes := schemaOf(t, legacy)
+	for table, want := range wantColumns {
+		if !reflect.DeepEqual(gotColumns[table], want) {
+			t.Errorf("table %s columns = %v, want %v", table, gotColumns[table], want)
+		}
+	}
+	if !reflect.DeepEqual(gotIndexes, wantIndexes) {
+		t.Errorf("indexes = %v, want %v", gotIndexes, wantIndexes)
+	}
+
+	var published []bool
+	legacy.Raw("SELECT published FROM papers").Scan(&published)
+	for _, p := range published {
+		if !p {
+			t.Error("existing paper was not backfilled as published")
+		}
+	}
+
+	var question struct {
+		Title string
+	}
+	if err := legacy.Raw("SELECT title FROM questions WHERE id = 7").Scan(&question).Error; err != nil || question.Title != "Go 的零值" {
+		t.Errorf("migrated question = %+v, err = %v", question, err)
+	}
+
+	// 只有最初版本的数据库中有试卷和考试记录
+	for _, join := range []string{
+		"exam_answers a JOIN exam_sessions s ON s.id = a.session_id",
+		"paper_questions pq JOIN questions q ON q.id = pq.question_id",
+	} {
+		var references int64
+		legacy.Raw("SELECT COUNT(*) FROM " + join).Scan(&references)
+		if references != 1 && schema == baselineSchema {
+			t.Errorf("references after rebuild in %s = %d, want 1", join, references)
+		}
+	}
+
+	// 重建后的表使用新的约束
+	for _, stmt := range []string{
+		"INSERT INTO questions (title, question_type, options, answer, language, ai_model, user_id) VALUES ('1+1=2', 'judge', '[]', 'true', 'Go', 'deepseek', 1)",
+		"INSERT INTO exam_sessions (paper_id, user_id, status, started_at) VALUES (1, 1, 'graded', '2025-01-01 08:00:00')",
+	} {
+		if err := legacy.Exec(stmt).Error; err != nil {
+			t.Errorf("%s: %v", stmt, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+type ExamAnswer struct {
+	ID         int64   `gorm:"primaryKey;autoIncrement"`
+	SessionID  int64   `gorm:"not null;index;uniqueIndex:idx_session_question"`
+	QuestionID int64   `gorm:"not null;uniqueIndex:idx_session_question"`
+	Answer     string  `gorm:"type:text;not null;default:''"`
+	Score      float64 `gorm:"not null;default:0"`
+	IsCorrect  bool    `gorm:"not null;default:false"`
+	Feedback   string  `gorm:"type:text;default:''"`
+	// 编程题每个测试用例的运行结果（JSON数组）
+	TestResults string `gorm:"type:text;default:''"`
+	// 阅卷老师的评语和阅卷人，自动评分的作答为空
+	Comment   string `gorm:"type:text;default:''"`
+	MarkedBy  *int64
+	GradedAt  *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
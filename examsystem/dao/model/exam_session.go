@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+type ExamStatus string
+
+const (
+	ExamStatusInProgress ExamStatus = "in_progress"
+	ExamStatusSubmitted  ExamStatus = "submitted"
+	ExamStatusGrading    ExamStatus = "grading" // 已提交，包含编程题，正在后台评分
+	ExamStatusGraded     ExamStatus = "graded"
+)
+
+type ExamSession struct {
+	ID               int64      `gorm:"primaryKey;autoIncrement"`
+	PaperID          int64      `gorm:"not null;index"`
+	UserID           int64      `gorm:"not null;index"`
+	Status           ExamStatus `gorm:"size:20;not null;default:'in_progress'"`
+	Score            float64    `gorm:"not null;default:0"`
+	StartedAt        time.Time  `gorm:"not null"`
+	Deadline         *time.Time // 服务端作答截止时间，为空表示不限时
+	ShuffleSeed      int64      `gorm:"not null;default:0"` // 乱序随机种子，保证同一次考试刷新后顺序不变
+	ShuffleQuestions bool       `gorm:"not null;default:false"`
+	ShuffleOptions   bool       `gorm:"not null;default:false"`
+	SubmittedAt      *time.Time
+	AutoSubmitted    bool `gorm:"not null;default:false"` // 是否超时由系统自动提交
+	GradedAt         *time.Time
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+)
+
+// KnowledgeLevel 知识体系节点的层级
+type KnowledgeLevel string
+
+const (
+	KnowledgeLevelSubject KnowledgeLevel = "subject" // 学科
+	KnowledgeLevelChapter KnowledgeLevel = "chapter" // 章节
+	KnowledgeLevelPoint   KnowledgeLevel = "point"   // 知识点
+)
+
+// KnowledgeNode 知识体系中的一个节点，学科下为章节，章节下为知识点
+type KnowledgeNode struct {
+	ID          int64          `gorm:"primaryKey;autoIncrement"`
+	ParentID    int64          `gorm:"not null;default:0;index"` // 上级节点，学科为0
+	Level       KnowledgeLevel `gorm:"size:20;not null;check:level IN ('subject','chapter','point')"`
+	Name        string         `gorm:"size:100;not null"`
+	Description string         `gorm:"type:text;default:''"`
+	SortOrder   int            `gorm:"not null;default:0"` // 同级节点的排列顺序，越小越靠前
+	CreatedAt   time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
+}
+
+// QuestionKnowledgePoint 题目与知识点的关联，一道题目可以关联多个知识点
+type QuestionKnowledgePoint struct {
+	QuestionID       int64     `gorm:"primaryKey"`
+	KnowledgePointID int64     `gorm:"primaryKey;index"`
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+}
@@ -5,12 +5,18 @@ import (
 )
 
 type Paper struct {
-	ID          int64      `gorm:"primaryKey;autoIncrement"`
-	Title       string     `gorm:"size:255;not null"`
-	Description string     `gorm:"type:text;default:''"`
-	TotalScore  int        `gorm:"default:100"`
-	CreatorID   int64      `gorm:"not null;index"`
-	CreatedAt   time.Time  `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time  `gorm:"autoUpdateTime"`
-	DeletedAt   *time.Time `gorm:"index"`
+	ID               int64      `gorm:"primaryKey;autoIncrement"`
+	Title            string     `gorm:"size:255;not null"`
+	Description      string     `gorm:"type:text;default:''"`
+	TotalScore       int        `gorm:"not null"`
+	DurationMinutes  int        `gorm:"not null;default:0"` // 考试时长（分钟），0表示不限时
+	OpenAt           *time.Time // 开放作答时间，为空表示不限制
+	CloseAt          *time.Time // 截止作答时间，为空表示不限制
+	ShuffleQuestions bool       `gorm:"not null;default:false"` // 每位考生随机打乱题目顺序
+	ShuffleOptions   bool       `gorm:"not null;default:false"` // 每位考生随机打乱选择题选项顺序
+	Published        bool       `gorm:"not null;default:false"` // 已发布，发布后其他用户才能参加考试
+	CreatorID        int64      `gorm:"not null;index"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime"`
+	DeletedAt        *time.Time `gorm:"index"`
 }
@@ -6,8 +6,8 @@ import (
 
 type PaperQuestion struct {
 	ID            int64      `gorm:"primaryKey;autoIncrement"`
-	PaperID       int64      `gorm:"not null;index"`
-	QuestionID    int64      `gorm:"not null;index"`
+	PaperID       int64      `gorm:"not null;index;uniqueIndex:idx_paper_question"`
+	QuestionID    int64      `gorm:"not null;index;uniqueIndex:idx_paper_question"`
 	QuestionOrder int        `gorm:"not null"`
 	Score         int        `gorm:"default:5"`
 	CreatedAt     time.Time  `gorm:"autoCreateTime"`
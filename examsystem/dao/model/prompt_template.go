@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+)
+
+// PromptTemplate 生成题目的提示语模板，每次修改内容都会新增一个版本
+type PromptTemplate struct {
+	ID           int64        `gorm:"primaryKey;autoIncrement"`
+	Name         string       `gorm:"size:100;not null"`
+	Subject      string       `gorm:"size:50;default:''"` // 适用的学科，如 编程、数学、英语
+	QuestionType QuestionType `gorm:"size:20;default:''"` // 适用的题型，为空表示适用于所有题型
+	Description  string       `gorm:"type:text;default:''"`
+	Version      int          `gorm:"not null;default:1"`     // 当前版本号
+	IsDefault    bool         `gorm:"not null;default:false"` // 未指定模板时，该题型默认使用的模板
+	CreatorID    int64        `gorm:"not null"`
+	CreatedAt    time.Time    `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time    `gorm:"autoUpdateTime"`
+	DeletedAt    *time.Time   `gorm:"index"`
+}
+
+// PromptTemplateVersion 提示语模板的一个版本
+type PromptTemplateVersion struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement"`
+	TemplateID int64     `gorm:"not null;uniqueIndex:idx_prompt_template_version"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_prompt_template_version"`
+	Content    string    `gorm:"type:text;not null"` // text/template 格式的模板内容
+	CreatorID  int64     `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
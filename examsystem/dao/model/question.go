@@ -9,22 +9,65 @@ import (
 type QuestionType string
 
 const (
-	QuestionTypeSingle   QuestionType = "single"
-	QuestionTypeMultiple QuestionType = "multiple"
+	QuestionTypeSingle      QuestionType = "single"
+	QuestionTypeMultiple    QuestionType = "multiple"
+	QuestionTypeJudge       QuestionType = "judge"
+	QuestionTypeBlank       QuestionType = "blank"
+	QuestionTypeShortAnswer QuestionType = "short_answer"
+	QuestionTypeCoding      QuestionType = "coding"
+)
+
+// CognitiveLevel 布鲁姆认知层次
+type CognitiveLevel string
+
+const (
+	CognitiveLevelRemember   CognitiveLevel = "remember"
+	CognitiveLevelUnderstand CognitiveLevel = "understand"
+	CognitiveLevelApply      CognitiveLevel = "apply"
+	CognitiveLevelAnalyze    CognitiveLevel = "analyze"
+	CognitiveLevelEvaluate   CognitiveLevel = "evaluate"
+	CognitiveLevelCreate     CognitiveLevel = "create"
+)
+
+// QuestionStatus 题目状态
+type QuestionStatus string
+
+const (
+	QuestionStatusDraft    QuestionStatus = "draft"    // AI生成后等待确认
+	QuestionStatusActive   QuestionStatus = "active"   // 在题库中
+	QuestionStatusArchived QuestionStatus = "archived" // 已归档：不在题库中列出，也不能加入新试卷，已有试卷不受影响
+)
+
+// ReviewVerdict AI审核题目的结论
+type ReviewVerdict string
+
+const (
+	ReviewVerdictPass    ReviewVerdict = "pass"    // 未发现问题
+	ReviewVerdictFlagged ReviewVerdict = "flagged" // 发现问题，需要人工检查
 )
 
 type Question struct {
-	ID           int64          `gorm:"primaryKey;autoIncrement"`
-	Title        string         `gorm:"type:text;not null"`
-	QuestionType QuestionType   `gorm:"size:20;not null;check:question_type IN ('single','multiple')"`
-	Options      string         `gorm:"type:text;not null"`
-	Answer       string         `gorm:"type:text;not null"`
-	Explanation  string         `gorm:"type:text;default:''"`
-	Keywords     string         `gorm:"size:255;default:''"`
-	Language     string         `gorm:"size:50;not null"`
-	AIModel      string         `gorm:"size:50;not null;column:ai_model"`
-	UserID       int64          `gorm:"not null;index"`
-	CreatedAt    time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt    time.Time      `gorm:"autoUpdateTime"`
-	DeletedAt    gorm.DeletedAt `gorm:"index;"`
+	ID               int64          `gorm:"primaryKey;autoIncrement"`
+	Title            string         `gorm:"type:text;not null"`
+	QuestionType     QuestionType   `gorm:"size:20;not null;check:question_type IN ('single','multiple','judge','blank','short_answer','coding')"`
+	Options          string         `gorm:"type:text;not null"`
+	Answer           string         `gorm:"type:text;not null"`
+	Explanation      string         `gorm:"type:text;default:''"`
+	CodeTemplate     string         `gorm:"type:text;default:''"`                                // 编程题提供给考生的代码模板
+	TestCases        string         `gorm:"type:text;default:''"`                                // 编程题的隐藏测试用例（JSON数组）
+	Difficulty       int            `gorm:"not null;default:3;check:difficulty BETWEEN 1 AND 5"` // 难度，1（最容易）-5（最难）
+	CognitiveLevel   CognitiveLevel `gorm:"size:20;default:''"`                                  // 布鲁姆认知层次，为空表示未标注
+	Keywords         string         `gorm:"size:255;default:''"`
+	Language         string         `gorm:"size:50;not null"`
+	AIModel          string         `gorm:"size:50;not null;column:ai_model"`
+	ReviewVerdict    ReviewVerdict  `gorm:"size:20;default:''"`                // AI审核结论，为空表示未审核
+	ReviewIssues     string         `gorm:"type:text;default:''"`              // AI审核发现的问题（JSON数组）
+	ReviewSuggestion string         `gorm:"type:text;default:''"`              // AI审核给出的修改建议
+	ReviewModel      string         `gorm:"size:50;default:''"`                // 审核使用的AI模型
+	Status           QuestionStatus `gorm:"size:20;not null;default:'active'"` // 题目状态，AI生成的题目确认前为 draft
+	BatchID          string         `gorm:"size:32;default:'';index"`          // 生成批次，同一次生成的题目相同，手动创建的题目为空
+	UserID           int64          `gorm:"not null;index"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt `gorm:"index;"`
 }
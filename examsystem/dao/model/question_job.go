@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+)
+
+type QuestionJobStatus string
+
+const (
+	QuestionJobStatusPending   QuestionJobStatus = "pending"
+	QuestionJobStatusRunning   QuestionJobStatus = "running"
+	QuestionJobStatusSucceeded QuestionJobStatus = "succeeded"
+	QuestionJobStatusFailed    QuestionJobStatus = "failed"
+)
+
+// QuestionJob AI生成题目的后台任务
+type QuestionJob struct {
+	ID               int64             `gorm:"primaryKey;autoIncrement"`
+	UserID           int64             `gorm:"not null;index"`
+	AIModel          string            `gorm:"size:50;not null;column:ai_model"`
+	Language         string            `gorm:"size:50;not null"`
+	QuestionType     QuestionType      `gorm:"size:20;not null"`
+	Keywords         string            `gorm:"size:255;default:''"`
+	NumQuestions     int               `gorm:"not null"`
+	Difficulty       int               `gorm:"not null;default:0"`     // 目标难度，0表示不指定
+	CognitiveLevel   CognitiveLevel    `gorm:"size:20;default:''"`     // 目标认知层次，为空表示不指定
+	TemplateID       int64             `gorm:"not null;default:0"`     // 提示语模板，0表示使用默认模板
+	TemplateVersion  int               `gorm:"not null;default:0"`     // 模板版本，0表示执行时的当前版本
+	Review           bool              `gorm:"not null;default:false"` // 是否对生成的题目进行AI审核
+	ReviewModel      string            `gorm:"size:50;default:''"`     // 审核使用的AI模型，为空表示使用默认的审核模型
+	KnowledgePointID int64             `gorm:"not null;default:0"`     // 考查的知识点，生成的题目自动关联，0表示不指定
+	BatchID          string            `gorm:"size:32;default:''"`     // 生成的题目所属的批次，创建任务时确定，重新执行时不变
+	Status           QuestionJobStatus `gorm:"size:20;not null;default:'pending'"`
+	Attempts         int               `gorm:"not null;default:0"` // 已开始执行的次数，服务重启中断后重新执行会增加
+	Error            string            `gorm:"type:text;default:''"`
+	QuestionIDs      string            `gorm:"type:text;default:''"` // 生成的待确认题目ID（JSON数组）
+	Report           string            `gorm:"type:text;default:''"` // 生成结果统计（JSON），记录部分批次失败等情况
+	StartedAt        *time.Time
+	FinishedAt       *time.Time
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
+}
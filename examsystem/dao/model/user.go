@@ -4,6 +4,13 @@ import (
 	"time"
 )
 
+// 用户角色
+const (
+	RoleAdmin   = "admin"   // 管理员
+	RoleTeacher = "teacher" // 教师，可以批阅自己试卷的考试
+	RoleUser    = "user"    // 普通用户
+)
+
 type User struct {
 	ID           int64      `gorm:"primaryKey;autoIncrement"`
 	Username     string     `gorm:"size:50;unique;not null"`
@@ -0,0 +1,254 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"examsystem/dao/model"
+)
+
+// PaperDAO 试卷数据访问对象
+type PaperDAO struct {
+	DB *gorm.DB
+}
+
+// NewPaperDAO 创建试卷DAO实例
+func NewPaperDAO(db *gorm.DB) *PaperDAO {
+	return &PaperDAO{DB: db}
+}
+
+// CreatePaper 创建试卷
+func (dao *PaperDAO) CreatePaper(paper *model.Paper) error {
+	return dao.DB.Create(paper).Error
+}
+
+// GetPaperByID 获取未删除的试卷
+func (dao *PaperDAO) GetPaperByID(id int64) (*model.Paper, error) {
+	var paper model.Paper
+	err := dao.DB.Where("deleted_at IS NULL").First(&paper, id).Error
+	return &paper, err
+}
+
+// GetPaperIncludingDeleted 获取试卷（包含已删除的）
+func (dao *PaperDAO) GetPaperIncludingDeleted(id int64) (*model.Paper, error) {
+	var paper model.Paper
+	err := dao.DB.First(&paper, id).Error
+	return &paper, err
+}
+
+// GetPapersByCreatorID 获取用户创建的试卷列表（支持分页）
+func (dao *PaperDAO) GetPapersByCreatorID(creatorID int64, keyword string, page, pageSize int) ([]*model.Paper, int64, error) {
+	var papers []*model.Paper
+	var total int64
+
+	query := dao.DB.Model(&model.Paper{}).Where("creator_id = ? AND deleted_at IS NULL", creatorID)
+	if keyword != "" {
+		query = query.Where("title LIKE ?", "%"+keyword+"%")
+	}
+
+	// 查询总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 获取数据列表
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&papers).Error
+	return papers, total, err
+}
+
+// UpdatePaper 更新试卷基本信息
+func (dao *PaperDAO) UpdatePaper(paper *model.Paper) error {
+	return dao.DB.Model(paper).Updates(map[string]interface{}{
+		"title":             paper.Title,
+		"description":       paper.Description,
+		"duration_minutes":  paper.DurationMinutes,
+		"open_at":           paper.OpenAt,
+		"close_at":          paper.CloseAt,
+		"shuffle_questions": paper.ShuffleQuestions,
+		"shuffle_options":   paper.ShuffleOptions,
+		"published":         paper.Published,
+	}).Error
+}
+
+// DeletePaper 软删除试卷
+func (dao *PaperDAO) DeletePaper(id int64) error {
+	return dao.DB.Model(&model.Paper{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("deleted_at", time.Now()).Error
+}
+
+// GetDeletedPapersByCreatorID 获取用户回收站中的试卷，最近删除的在前
+func (dao *PaperDAO) GetDeletedPapersByCreatorID(creatorID int64) ([]*model.Paper, error) {
+	var papers []*model.Paper
+	err := dao.DB.Where("creator_id = ? AND deleted_at IS NOT NULL", creatorID).
+		Order("deleted_at DESC").
+		Find(&papers).Error
+	return papers, err
+}
+
+// GetDeletedPaperByID 获取回收站中的试卷
+func (dao *PaperDAO) GetDeletedPaperByID(id int64) (*model.Paper, error) {
+	var paper model.Paper
+	err := dao.DB.Where("deleted_at IS NOT NULL").First(&paper, id).Error
+	return &paper, err
+}
+
+// GetPapersDeletedBefore 获取删除时间早于 before 的回收站中的试卷
+func (dao *PaperDAO) GetPapersDeletedBefore(before time.Time) ([]*model.Paper, error) {
+	var papers []*model.Paper
+	err := dao.DB.Where("deleted_at < ?", before).Find(&papers).Error
+	return papers, err
+}
+
+// RestorePaper 从回收站恢复试卷
+func (dao *PaperDAO) RestorePaper(id int64) error {
+	return dao.DB.Model(&model.Paper{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+// PurgePaper 彻底删除试卷及其题目关联
+func (dao *PaperDAO) PurgePaper(id int64) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("paper_id = ?", id).Delete(&model.PaperQuestion{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Paper{}, id).Error
+	})
+}
+
+// GetPapersByQuestionID 获取包含某道题目的试卷（包括已删除的试卷）
+func (dao *PaperDAO) GetPapersByQuestionID(questionID int64) ([]*model.Paper, error) {
+	var papers []*model.Paper
+	err := dao.DB.Select("papers.*").
+		Joins("JOIN paper_questions ON paper_questions.paper_id = papers.id").
+		Where("paper_questions.question_id = ? AND paper_questions.deleted_at IS NULL", questionID).
+		Order("papers.id").
+		Find(&papers).Error
+	return papers, err
+}
+
+// GetPaperQuestions 获取试卷的题目关联（按题目顺序）
+func (dao *PaperDAO) GetPaperQuestions(paperID int64) ([]*model.PaperQuestion, error) {
+	var paperQuestions []*model.PaperQuestion
+	err := dao.DB.Where("paper_id = ? AND deleted_at IS NULL", paperID).
+		Order("question_order ASC").
+		Find(&paperQuestions).Error
+	return paperQuestions, err
+}
+
+// GetPaperQuestion 获取试卷中的某道题目关联
+func (dao *PaperDAO) GetPaperQuestion(paperID, questionID int64) (*model.PaperQuestion, error) {
+	var paperQuestion model.PaperQuestion
+	err := dao.DB.Where("paper_id = ? AND question_id = ? AND deleted_at IS NULL", paperID, questionID).
+		First(&paperQuestion).Error
+	return &paperQuestion, err
+}
+
+// GetMaxQuestionOrder 获取试卷当前最大的题目序号
+func (dao *PaperDAO) GetMaxQuestionOrder(paperID int64) (int, error) {
+	var maxOrder int
+	err := dao.DB.Model(&model.PaperQuestion{}).
+		Where("paper_id = ? AND deleted_at IS NULL", paperID).
+		Select("COALESCE(MAX(question_order), 0)").
+		Scan(&maxOrder).Error
+	return maxOrder, err
+}
+
+// AddQuestionToPaper 添加题目到试卷，并同步试卷总分
+func (dao *PaperDAO) AddQuestionToPaper(paperQuestion *model.PaperQuestion) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(paperQuestion).Error; err != nil {
+			return err
+		}
+		return syncTotalScore(tx, paperQuestion.PaperID)
+	})
+}
+
+// RemoveQuestionFromPaper 从试卷中移除题目，并同步试卷总分
+// 关联记录受 (paper_id, question_id) 唯一约束，因此直接物理删除，便于之后重新添加
+func (dao *PaperDAO) RemoveQuestionFromPaper(paperID, questionID int64) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("paper_id = ? AND question_id = ?", paperID, questionID).
+			Delete(&model.PaperQuestion{}).Error; err != nil {
+			return err
+		}
+		return syncTotalScore(tx, paperID)
+	})
+}
+
+// UpdateQuestionOrder 按给定的题目ID顺序重排试卷题目
+func (dao *PaperDAO) UpdateQuestionOrder(paperID int64, questionIDs []int64) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		for i, questionID := range questionIDs {
+			if err := tx.Model(&model.PaperQuestion{}).
+				Where("paper_id = ? AND question_id = ? AND deleted_at IS NULL", paperID, questionID).
+				Update("question_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CountQuestionsByPaperIDs 统计每张试卷的题目数量
+func (dao *PaperDAO) CountQuestionsByPaperIDs(paperIDs []int64) (map[int64]int, error) {
+	counts := make(map[int64]int, len(paperIDs))
+	if len(paperIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PaperID int64
+		Count   int
+	}
+	err := dao.DB.Model(&model.PaperQuestion{}).
+		Select("paper_id, COUNT(*) AS count").
+		Where("paper_id IN ? AND deleted_at IS NULL", paperIDs).
+		Group("paper_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.PaperID] = row.Count
+	}
+	return counts, nil
+}
+
+// CountPapersByCreatorID 统计用户创建的试卷数量
+func (dao *PaperDAO) CountPapersByCreatorID(creatorID int64) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&model.Paper{}).
+		Where("creator_id = ? AND deleted_at IS NULL", creatorID).
+		Count(&count).Error
+	return count, err
+}
+
+// CountPaperQuestionsByCreatorID 统计用户所有试卷中的题目数量
+func (dao *PaperDAO) CountPaperQuestionsByCreatorID(creatorID int64) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&model.PaperQuestion{}).
+		Joins("JOIN papers ON papers.id = paper_questions.paper_id").
+		Where("papers.creator_id = ? AND papers.deleted_at IS NULL AND paper_questions.deleted_at IS NULL", creatorID).
+		Count(&count).Error
+	return count, err
+}
+
+// syncTotalScore 将试卷总分重新计算为各题分值之和
+func syncTotalScore(tx *gorm.DB, paperID int64) error {
+	var totalScore int
+	if err := tx.Model(&model.PaperQuestion{}).
+		Where("paper_id = ? AND deleted_at IS NULL", paperID).
+		Select("COALESCE(SUM(score), 0)").
+		Scan(&totalScore).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&model.Paper{}).
+		Where("id = ?", paperID).
+		Update("total_score", totalScore).Error
+}
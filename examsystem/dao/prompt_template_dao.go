@@ -0,0 +1,128 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"examsystem/dao/model"
+)
+
+// PromptTemplateDAO 提示语模板数据访问对象
+type PromptTemplateDAO struct {
+	DB *gorm.DB
+}
+
+// NewPromptTemplateDAO 创建提示语模板DAO实例
+func NewPromptTemplateDAO(db *gorm.DB) *PromptTemplateDAO {
+	return &PromptTemplateDAO{DB: db}
+}
+
+// CreateTemplate 创建模板及其第一个版本
+func (dao *PromptTemplateDAO) CreateTemplate(template *model.PromptTemplate, content string) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		template.Version = 1
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		if err := clearOtherDefaults(tx, template); err != nil {
+			return err
+		}
+		return tx.Create(&model.PromptTemplateVersion{
+			TemplateID: template.ID,
+			Version:    template.Version,
+			Content:    content,
+			CreatorID:  template.CreatorID,
+		}).Error
+	})
+}
+
+// UpdateTemplate 更新模板信息，content 不为空时新增一个版本并设为当前版本
+func (dao *PromptTemplateDAO) UpdateTemplate(template *model.PromptTemplate, content string, userID int64) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if content != "" {
+			template.Version++
+			if err := tx.Create(&model.PromptTemplateVersion{
+				TemplateID: template.ID,
+				Version:    template.Version,
+				Content:    content,
+				CreatorID:  userID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(template).Updates(map[string]interface{}{
+			"name":          template.Name,
+			"subject":       template.Subject,
+			"question_type": template.QuestionType,
+			"description":   template.Description,
+			"version":       template.Version,
+			"is_default":    template.IsDefault,
+		}).Error; err != nil {
+			return err
+		}
+		return clearOtherDefaults(tx, template)
+	})
+}
+
+// clearOtherDefaults 模板设为默认时，取消同一题型其他模板的默认状态
+func clearOtherDefaults(tx *gorm.DB, template *model.PromptTemplate) error {
+	if !template.IsDefault {
+		return nil
+	}
+	return tx.Model(&model.PromptTemplate{}).
+		Where("question_type = ? AND id <> ? AND is_default = ?", template.QuestionType, template.ID, true).
+		Update("is_default", false).Error
+}
+
+// GetTemplateByID 获取未删除的模板
+func (dao *PromptTemplateDAO) GetTemplateByID(id int64) (*model.PromptTemplate, error) {
+	var template model.PromptTemplate
+	err := dao.DB.Where("deleted_at IS NULL").First(&template, id).Error
+	return &template, err
+}
+
+// GetTemplates 获取模板列表，subject 和 questionType 为空时不筛选
+func (dao *PromptTemplateDAO) GetTemplates(subject string, questionType model.QuestionType) ([]*model.PromptTemplate, error) {
+	var templates []*model.PromptTemplate
+	query := dao.DB.Where("deleted_at IS NULL")
+	if subject != "" {
+		query = query.Where("subject = ?", subject)
+	}
+	if questionType != "" {
+		// 适用于所有题型的模板也可以用于指定的题型
+		query = query.Where("question_type IN ?", []model.QuestionType{questionType, ""})
+	}
+	err := query.Order("subject, name, id").Find(&templates).Error
+	return templates, err
+}
+
+// GetDefaultTemplate 获取题型的默认模板，优先使用指定该题型的模板，没有默认模板时返回 gorm.ErrRecordNotFound
+func (dao *PromptTemplateDAO) GetDefaultTemplate(questionType model.QuestionType) (*model.PromptTemplate, error) {
+	var template model.PromptTemplate
+	err := dao.DB.Where("deleted_at IS NULL AND is_default = ? AND question_type IN ?", true, []model.QuestionType{questionType, ""}).
+		Order("question_type DESC").
+		First(&template).Error
+	return &template, err
+}
+
+// GetVersion 获取模板的指定版本
+func (dao *PromptTemplateDAO) GetVersion(templateID int64, version int) (*model.PromptTemplateVersion, error) {
+	var templateVersion model.PromptTemplateVersion
+	err := dao.DB.Where("template_id = ? AND version = ?", templateID, version).First(&templateVersion).Error
+	return &templateVersion, err
+}
+
+// GetVersions 获取模板的全部版本，新版本在前
+func (dao *PromptTemplateDAO) GetVersions(templateID int64) ([]*model.PromptTemplateVersion, error) {
+	var versions []*model.PromptTemplateVersion
+	err := dao.DB.Where("template_id = ?", templateID).Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+// DeleteTemplate 软删除模板，保留历史版本
+func (dao *PromptTemplateDAO) DeleteTemplate(id int64) error {
+	return dao.DB.Model(&model.PromptTemplate{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "is_default": false}).Error
+}
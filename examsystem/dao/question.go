@@ -2,6 +2,7 @@ package dao
 
 import (
 	"examsystem/dao/model"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -16,7 +17,12 @@ func NewQuestionDAO(db *gorm.DB) *QuestionDAO {
 
 // CreateQuestion 创建题目
 func (dao *QuestionDAO) CreateQuestion(question *model.Question) error {
-	return dao.DB.Create(question).Error
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(question).Error; err != nil {
+			return err
+		}
+		return indexQuestions(tx, []*model.Question{question})
+	})
 }
 
 // GetQuestionByID 获取题目（包含已删除的）
@@ -33,9 +39,21 @@ func (dao *QuestionDAO) GetUndeletedQuestionByID(id int64) (*model.Question, err
 	return &question, err
 }
 
-// UpdateQuestion 更新题目
+// questionEditableColumns 编辑题目时更新的列；创建时间、所属用户、状态和生成批次不能通过编辑修改
+var questionEditableColumns = []string{
+	"title", "question_type", "options", "answer", "explanation", "code_template", "test_cases",
+	"difficulty", "cognitive_level", "keywords", "language", "ai_model",
+	"review_verdict", "review_issues", "review_suggestion", "review_model", "updated_at",
+}
+
+// UpdateQuestion 更新题目的可编辑内容，零值（如清空的审核结论）同样写入
 func (dao *QuestionDAO) UpdateQuestion(question *model.Question) error {
-	return dao.DB.Save(question).Error
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(question).Select(questionEditableColumns).Updates(question).Error; err != nil {
+			return err
+		}
+		return indexQuestions(tx, []*model.Question{question})
+	})
 }
 
 // DeleteQuestion 软删除题目
@@ -45,54 +63,298 @@ func (dao *QuestionDAO) DeleteQuestion(id int64) error {
 
 // PermanentDeleteQuestion 永久删除题目
 func (dao *QuestionDAO) PermanentDeleteQuestion(id int64) error {
-	return dao.DB.Unscoped().Delete(&model.Question{}, id).Error
+	return dao.DeleteQuestionsPermanently([]int64{id})
+}
+
+// GetDeletedQuestionsByUserID 获取用户回收站中的题目（已删除且不是待确认的题目），最近删除的在前
+func (dao *QuestionDAO) GetDeletedQuestionsByUserID(userID int64) ([]*model.Question, error) {
+	var questions []*model.Question
+	err := dao.DB.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL AND status <> ?", userID, model.QuestionStatusDraft).
+		Order("deleted_at DESC").
+		Find(&questions).Error
+	return questions, err
+}
+
+// GetDeletedQuestionByID 获取回收站中的题目
+func (dao *QuestionDAO) GetDeletedQuestionByID(id int64) (*model.Question, error) {
+	var question model.Question
+	err := dao.DB.Unscoped().
+		Where("deleted_at IS NOT NULL AND status <> ?", model.QuestionStatusDraft).
+		First(&question, id).Error
+	return &question, err
+}
+
+// GetQuestionsDeletedBefore 获取删除时间早于 before 的回收站中的题目
+func (dao *QuestionDAO) GetQuestionsDeletedBefore(before time.Time) ([]*model.Question, error) {
+	var questions []*model.Question
+	err := dao.DB.Unscoped().
+		Where("deleted_at < ? AND status <> ?", before, model.QuestionStatusDraft).
+		Find(&questions).Error
+	return questions, err
+}
+
+// RestoreQuestions 从回收站恢复题目
+func (dao *QuestionDAO) RestoreQuestions(ids []int64) error {
+	return dao.DB.Unscoped().
+		Model(&model.Question{}).
+		Where("id IN ?", ids).
+		Update("deleted_at", nil).Error
+}
+
+// QuestionFilter 题目列表的筛选条件，零值表示不按该条件筛选
+type QuestionFilter struct {
+	Status          model.QuestionStatus // 题目状态，为空时只查询题库中的题目（active）
+	Language        string
+	QuestionType    string
+	Keyword         string
+	MinDifficulty   int
+	MaxDifficulty   int
+	CognitiveLevel  string
+	AIModel         string
+	KnowledgeNodeID int64     // 知识体系节点，筛选关联到该节点或其下级知识点的题目
+	Tags            []string  // 关键词标签，题目需要包含全部标签
+	CreatedAfter    time.Time // 创建时间不早于该时间
+	CreatedBefore   time.Time // 创建时间早于该时间
+}
+
+// QuestionSortColumns 题目列表可用的排序字段
+var QuestionSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"difficulty": "difficulty",
+	"title":      "title",
+	"id":         "id",
+}
+
+// QuestionSort 题目列表的排序方式，Field 为 QuestionSortColumns 中的字段，为空时按创建时间排序
+type QuestionSort struct {
+	Field string
+	Desc  bool
 }
 
 // GetQuestionsByUserID 获取用户题目列表（未删除的）
-func (dao *QuestionDAO) GetQuestionsByUserID(userID int64, language, questionType, keyword string) ([]*model.Question, error) {
+func (dao *QuestionDAO) GetQuestionsByUserID(userID int64, filter QuestionFilter) ([]*model.Question, error) {
+	var questions []*model.Question
+	err := dao.filterQuestions(userID, filter).Order("created_at DESC").Find(&questions).Error
+	return questions, err
+}
+
+// GetQuestionPageByUserID 分页获取用户题目列表（未删除的），返回当前页的题目和符合条件的总数
+func (dao *QuestionDAO) GetQuestionPageByUserID(userID int64, filter QuestionFilter, sort QuestionSort, page, pageSize int) ([]*model.Question, int64, error) {
 	var questions []*model.Question
-	query := dao.DB.Where("user_id = ?", userID)
+	var total int64
+
+	// 查询总数
+	err := dao.filterQuestions(userID, filter).Model(&model.Question{}).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
 
-	if language != "" {
-		query = query.Where("language = ?", language)
+	column, ok := QuestionSortColumns[sort.Field]
+	if !ok {
+		column = "created_at"
+	}
+	direction := " ASC"
+	if sort.Desc {
+		direction = " DESC"
 	}
 
-	if questionType != "" {
-		query = query.Where("question_type = ?", questionType)
+	// 排序字段相同时按ID排序，保证翻页时顺序稳定
+	offset := (page - 1) * pageSize
+	err = dao.filterQuestions(userID, filter).
+		Order(column + direction).
+		Order("id" + direction).
+		Offset(offset).
+		Limit(pageSize).
+		Find(&questions).Error
+	return questions, total, err
+}
+
+// filterQuestions 构造按筛选条件查询用户题目的语句
+func (dao *QuestionDAO) filterQuestions(userID int64, filter QuestionFilter) *gorm.DB {
+	status := filter.Status
+	if status == "" {
+		status = model.QuestionStatusActive
 	}
+	query := dao.DB.Where("user_id = ? AND status = ?", userID, status)
 
-	if keyword != "" {
-		query = query.Where("title LIKE ?", "%"+keyword+"%")
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
 	}
 
-	err := query.Order("created_at DESC").Find(&questions).Error
-	return questions, err
+	if filter.QuestionType != "" {
+		query = query.Where("question_type = ?", filter.QuestionType)
+	}
+
+	if filter.Keyword != "" {
+		query = query.Where("title LIKE ?", "%"+filter.Keyword+"%")
+	}
+
+	if filter.MinDifficulty > 0 {
+		query = query.Where("difficulty >= ?", filter.MinDifficulty)
+	}
+
+	if filter.MaxDifficulty > 0 {
+		query = query.Where("difficulty <= ?", filter.MaxDifficulty)
+	}
+
+	if filter.CognitiveLevel != "" {
+		query = query.Where("cognitive_level = ?", filter.CognitiveLevel)
+	}
+
+	if filter.AIModel != "" {
+		query = query.Where("ai_model = ?", filter.AIModel)
+	}
+
+	if filter.KnowledgeNodeID != 0 {
+		query = query.Where("questions.id IN ("+knowledgeSubtreeQuestions+")", filter.KnowledgeNodeID)
+	}
+
+	// 关键词以逗号分隔，首尾加上逗号后按整个关键词匹配
+	for _, tag := range filter.Tags {
+		query = query.Where("',' || REPLACE(REPLACE(keywords, '，', ','), ' ', '') || ',' LIKE ?", "%,"+tag+",%")
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedAfter)
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+
+	return query
 }
 
-// GetGeneratedQuestionsByUserID 获取用户逻辑删除状态的题目（未确认）
-func (dao *QuestionDAO) GetGeneratedQuestionsByUserID(userID int64) ([]*model.Question, error) {
+// GetDraftQuestionsByBatch 获取用户某个生成批次中待确认的题目
+func (dao *QuestionDAO) GetDraftQuestionsByBatch(userID int64, batchID string) ([]*model.Question, error) {
 	var questions []*model.Question
-	err := dao.DB.Unscoped().
-		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+	err := dao.DB.
+		Where("user_id = ? AND batch_id = ? AND status = ?", userID, batchID, model.QuestionStatusDraft).
+		Order("id").
 		Find(&questions).Error
 	return questions, err
 }
 
-// RestoreQuestionsByID 取消逻辑删除（恢复题目）
-func (dao *QuestionDAO) RestoreQuestionsByID(ids []int64) error {
-	return dao.DB.Unscoped().
+// UpdateQuestionsStatus 批量修改题目状态
+func (dao *QuestionDAO) UpdateQuestionsStatus(ids []int64, status model.QuestionStatus) error {
+	return dao.DB.
 		Model(&model.Question{}).
 		Where("id IN ?", ids).
-		Update("deleted_at", nil).Error
+		Update("status", status).Error
+}
+
+// DeleteExpiredDrafts 物理删除创建时间早于 before 的待确认题目（包括已删除的），返回删除的数量
+func (dao *QuestionDAO) DeleteExpiredDrafts(before time.Time) (int64, error) {
+	var count int64
+	err := dao.DB.Transaction(func(tx *gorm.DB) error {
+		var ids []int64
+		err := tx.Unscoped().
+			Model(&model.Question{}).
+			Where("status = ? AND created_at < ?", model.QuestionStatusDraft, before).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		count, err = purgeQuestions(tx, ids)
+		return err
+	})
+	return count, err
 }
 
 // DeleteQuestionsPermanently 物理删除
 func (dao *QuestionDAO) DeleteQuestionsPermanently(ids []int64) error {
-	return dao.DB.Unscoped().
-		Where("id IN ?", ids).
-		Delete(&model.Question{}).Error
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := purgeQuestions(tx, ids)
+		return err
+	})
+}
+
+// BatchCreateQuestions 批量创建题目，并将每道题目关联到 knowledgePointIDs 中的知识点
+func (dao *QuestionDAO) BatchCreateQuestions(questions []*model.Question, knowledgePointIDs []int64) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		return createQuestions(tx, questions, knowledgePointIDs)
+	})
+}
+
+// createQuestions 在事务中创建题目，写入检索索引并关联到 knowledgePointIDs 中的知识点
+func createQuestions(tx *gorm.DB, questions []*model.Question, knowledgePointIDs []int64) error {
+	if err := tx.Create(&questions).Error; err != nil {
+		return err
+	}
+	if err := indexQuestions(tx, questions); err != nil {
+		return err
+	}
+	ids := make([]int64, 0, len(questions))
+	for _, q := range questions {
+		ids = append(ids, q.ID)
+	}
+	return linkKnowledgePoints(tx, ids, knowledgePointIDs)
+}
+
+// purgeQuestions 在事务中物理删除题目及其检索索引和知识点关联，返回删除的题目数量
+func purgeQuestions(tx *gorm.DB, ids []int64) (int64, error) {
+	if err := unindexQuestions(tx, ids); err != nil {
+		return 0, err
+	}
+	if err := tx.Where("question_id IN ?", ids).Delete(&model.QuestionKnowledgePoint{}).Error; err != nil {
+		return 0, err
+	}
+	result := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Question{})
+	return result.RowsAffected, result.Error
+}
+
+// CountFilteredQuestionsBy 按 column 分组统计符合筛选条件的题目数量，column 为 question_type 或 difficulty
+func (dao *QuestionDAO) CountFilteredQuestionsBy(userID int64, filter QuestionFilter, column string) (map[string]int64, error) {
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	err := dao.filterQuestions(userID, filter).
+		Model(&model.Question{}).
+		Select(column + " AS value, COUNT(*) AS count").
+		Group(column).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, row := range rows {
+		counts[row.Value] = row.Count
+	}
+	return counts, nil
+}
+
+// CountQuestionsByType 按题目类型统计用户题库中的题目数量（未删除的）
+func (dao *QuestionDAO) CountQuestionsByType(userID int64) (map[model.QuestionType]int64, error) {
+	var rows []struct {
+		QuestionType model.QuestionType
+		Count        int64
+	}
+	err := dao.DB.Model(&model.Question{}).
+		Select("question_type, COUNT(*) AS count").
+		Where("user_id = ? AND status = ?", userID, model.QuestionStatusActive).
+		Group("question_type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[model.QuestionType]int64)
+	for _, row := range rows {
+		counts[row.QuestionType] = row.Count
+	}
+	return counts, nil
 }
 
-func (dao *QuestionDAO) BatchCreateQuestions(questions []*model.Question) error {
-	return dao.DB.Create(&questions).Error
+// GetQuestionsByIDs 批量获取题目（包含已删除的）
+func (dao *QuestionDAO) GetQuestionsByIDs(ids []int64) ([]*model.Question, error) {
+	var questions []*model.Question
+	if len(ids) == 0 {
+		return questions, nil
+	}
+	err := dao.DB.Unscoped().Where("id IN ?", ids).Find(&questions).Error
+	return questions, err
 }
@@ -0,0 +1,144 @@
+package dao
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"examsystem/dao/model"
+)
+
+// QuestionJobDAO 题目生成任务数据访问对象
+type QuestionJobDAO struct {
+	DB *gorm.DB
+}
+
+// NewQuestionJobDAO 创建题目生成任务DAO实例
+func NewQuestionJobDAO(db *gorm.DB) *QuestionJobDAO {
+	return &QuestionJobDAO{DB: db}
+}
+
+// CreateJob 创建任务
+func (dao *QuestionJobDAO) CreateJob(job *model.QuestionJob) error {
+	return dao.DB.Create(job).Error
+}
+
+// GetJobByID 根据ID获取任务
+func (dao *QuestionJobDAO) GetJobByID(id int64) (*model.QuestionJob, error) {
+	var job model.QuestionJob
+	err := dao.DB.First(&job, id).Error
+	return &job, err
+}
+
+// CountPendingBefore 统计排在指定任务之前的等待中任务数量
+func (dao *QuestionJobDAO) CountPendingBefore(id int64) (int64, error) {
+	var count int64
+	err := dao.DB.Model(&model.QuestionJob{}).
+		Where("status = ? AND id < ?", model.QuestionJobStatusPending, id).
+		Count(&count).Error
+	return count, err
+}
+
+// ClaimNextJob 领取最早的等待中任务并标记为执行中，没有可执行的任务时返回 nil
+// 仅当任务仍处于等待中时才会领取成功，多个执行者同时领取时每个任务只会被领取一次
+func (dao *QuestionJobDAO) ClaimNextJob(now time.Time) (*model.QuestionJob, error) {
+	for {
+		// 使用 Find 而不是 First，没有任务时不记录 record not found 日志
+		var jobs []*model.QuestionJob
+		err := dao.DB.Where("status = ?", model.QuestionJobStatusPending).Order("id ASC").Limit(1).Find(&jobs).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(jobs) == 0 {
+			return nil, nil
+		}
+		job := jobs[0]
+
+		result := dao.DB.Model(&model.QuestionJob{}).
+			Where("id = ? AND status = ?", job.ID, model.QuestionJobStatusPending).
+			Updates(map[string]interface{}{
+				"status":     model.QuestionJobStatusRunning,
+				"attempts":   gorm.Expr("attempts + 1"),
+				"started_at": now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 已被其他执行者领取，继续领取下一个
+			continue
+		}
+
+		job.Status = model.QuestionJobStatusRunning
+		job.Attempts++
+		job.StartedAt = &now
+		return job, nil
+	}
+}
+
+// RequeueRunningJobs 将执行中的任务重新置为等待中，用于服务重启后继续执行被中断的任务
+func (dao *QuestionJobDAO) RequeueRunningJobs() (int64, error) {
+	result := dao.DB.Model(&model.QuestionJob{}).
+		Where("status = ?", model.QuestionJobStatusRunning).
+		Update("status", model.QuestionJobStatusPending)
+	return result.RowsAffected, result.Error
+}
+
+// MarkSucceeded 在同一事务中保存生成的题目并关联到 knowledgePointIDs 中的知识点，将任务标记为已完成、记录题目ID和结果统计
+// 先删除该批次已保存的待确认题目，任务重复执行时不会留下重复的题目
+func (dao *QuestionJobDAO) MarkSucceeded(id int64, batchID string, questions []*model.Question, knowledgePointIDs []int64, report string, finishedAt time.Time) error {
+	return dao.DB.Transaction(func(tx *gorm.DB) error {
+		if batchID != "" {
+			var staleIDs []int64
+			err := tx.Unscoped().
+				Model(&model.Question{}).
+				Where("batch_id = ? AND status = ?", batchID, model.QuestionStatusDraft).
+				Pluck("id", &staleIDs).Error
+			if err != nil {
+				return err
+			}
+			if len(staleIDs) > 0 {
+				if _, err := purgeQuestions(tx, staleIDs); err != nil {
+					return err
+				}
+			}
+		}
+
+		ids := make([]int64, 0, len(questions))
+		if len(questions) > 0 {
+			if err := createQuestions(tx, questions, knowledgePointIDs); err != nil {
+				return err
+			}
+			for _, q := range questions {
+				ids = append(ids, q.ID)
+			}
+		}
+		questionIDs, err := json.Marshal(ids)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.QuestionJob{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"status":       model.QuestionJobStatusSucceeded,
+				"question_ids": string(questionIDs),
+				"report":       report,
+				"error":        "",
+				"finished_at":  finishedAt,
+			}).Error
+	})
+}
+
+// MarkFailed 将任务标记为失败并记录失败原因和结果统计
+func (dao *QuestionJobDAO) MarkFailed(id int64, reason, report string, finishedAt time.Time) error {
+	return dao.DB.Model(&model.QuestionJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      model.QuestionJobStatusFailed,
+			"error":       reason,
+			"report":      report,
+			"finished_at": finishedAt,
+		}).Error
+}
@@ -0,0 +1,211 @@
+package dao
+
+import (
+	"encoding/json"
+	"examsystem/dao/model"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// 题目全文检索表的定义，与 migrations/init.sql 中的相同，用于为旧数据库补建检索表
+const questionSearchTableDDL = `CREATE VIRTUAL TABLE IF NOT EXISTS questions_fts USING fts5(
+    title,
+    options,
+    explanation,
+    keywords,
+    tokenize = 'unicode61'
+)`
+
+// 检索结果排序时各字段的权重，依次为题干、选项、解析、关键词
+const questionSearchRank = "bm25(questions_fts, 10.0, 4.0, 2.0, 6.0)"
+
+// QuestionSearchHit 全文检索命中的题目，Score 越大越相关
+type QuestionSearchHit struct {
+	model.Question
+	Score float64
+}
+
+// SearchQuestions 在用户的题目中全文检索，按相关度排序分页返回，以及符合条件的总数
+// 检索文字中的每个单词和每段连续的中文都需要命中；无法切分出任何词时返回 nil
+func (dao *QuestionDAO) SearchQuestions(userID int64, text string, filter QuestionFilter, page, pageSize int) ([]*QuestionSearchHit, int64, error) {
+	match := searchMatchExpr(text)
+	if match == "" {
+		return nil, 0, nil
+	}
+	// 检索结果作为子查询连接，避免检索表的列名与筛选条件中的列名冲突
+	join := "JOIN (SELECT rowid AS fts_id, " + questionSearchRank + " AS fts_rank FROM questions_fts WHERE questions_fts MATCH ?) AS fts ON fts.fts_id = questions.id"
+
+	var total int64
+	err := dao.filterQuestions(userID, filter).Model(&model.Question{}).Joins(join, match).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var hits []*QuestionSearchHit
+	offset := (page - 1) * pageSize
+	err = dao.filterQuestions(userID, filter).
+		Model(&model.Question{}).
+		Select("questions.*, -fts.fts_rank AS score").
+		Joins(join, match).
+		Order("fts.fts_rank").
+		Order("questions.id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Scan(&hits).Error
+	return hits, total, err
+}
+
+// SyncQuestionSearchIndex 创建题目全文检索表，检索表与题目表的数量不一致时重建索引
+func SyncQuestionSearchIndex(db *gorm.DB) error {
+	if err := db.Exec(questionSearchTableDDL).Error; err != nil {
+		return err
+	}
+
+	var questionCount, indexCount int64
+	if err := db.Table("questions").Count(&questionCount).Error; err != nil {
+		return err
+	}
+	if err := db.Table("questions_fts").Count(&indexCount).Error; err != nil {
+		return err
+	}
+	if questionCount == indexCount {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM questions_fts").Error; err != nil {
+			return err
+		}
+		var questions []*model.Question
+		return tx.Unscoped().FindInBatches(&questions, 500, func(batch *gorm.DB, _ int) error {
+			return indexQuestions(tx, questions)
+		}).Error
+	})
+}
+
+// indexQuestions 写入题目的检索索引，已有的索引被替换
+func indexQuestions(tx *gorm.DB, questions []*model.Question) error {
+	for _, q := range questions {
+		if err := tx.Exec("DELETE FROM questions_fts WHERE rowid = ?", q.ID).Error; err != nil {
+			return err
+		}
+		err := tx.Exec("INSERT INTO questions_fts (rowid, title, options, explanation, keywords) VALUES (?, ?, ?, ?, ?)",
+			q.ID,
+			searchTokens(q.Title),
+			searchTokens(QuestionOptionsText(q.Options)),
+			searchTokens(q.Explanation),
+			searchTokens(q.Keywords),
+		).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unindexQuestions 删除题目的检索索引
+func unindexQuestions(tx *gorm.DB, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Exec("DELETE FROM questions_fts WHERE rowid IN ?", ids).Error
+}
+
+// QuestionOptionsText 将以JSON数组保存的选项拼接为文本，格式错误时返回原文
+func QuestionOptionsText(options string) string {
+	var items []string
+	if err := json.Unmarshal([]byte(options), &items); err != nil {
+		return options
+	}
+	return strings.Join(items, " ")
+}
+
+// isIdeograph 判断字符是否按相邻两个字切分：汉字、日文假名和韩文
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// searchSegment 文本中连续的一段表意文字或一个单词
+type searchSegment struct {
+	text      []rune
+	ideograph bool
+}
+
+// splitSearchSegments 将文本切分为连续的表意文字和单词（字母和数字），忽略其他字符，单词转为小写
+func splitSearchSegments(text string) []searchSegment {
+	var segments []searchSegment
+	var current *searchSegment
+	for _, r := range text {
+		var ideograph bool
+		switch {
+		case isIdeograph(r):
+			ideograph = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			r = unicode.ToLower(r)
+		default:
+			current = nil
+			continue
+		}
+		if current == nil || current.ideograph != ideograph {
+			segments = append(segments, searchSegment{ideograph: ideograph})
+			current = &segments[len(segments)-1]
+		}
+		current.text = append(current.text, r)
+	}
+	return segments
+}
+
+// SearchTerms 返回检索文字中的各个单词（小写）和各段连续的表意文字，用于在原文中标出命中的位置
+func SearchTerms(text string) []string {
+	var terms []string
+	for _, segment := range splitSearchSegments(text) {
+		terms = append(terms, string(segment.text))
+	}
+	return terms
+}
+
+// bigrams 将连续的表意文字按相邻两个字切分
+func bigrams(text []rune) []string {
+	if len(text) == 1 {
+		return []string{string(text)}
+	}
+	tokens := make([]string, 0, len(text)-1)
+	for i := 0; i+1 < len(text); i++ {
+		tokens = append(tokens, string(text[i:i+2]))
+	}
+	return tokens
+}
+
+// searchTokens 将文本转换为写入检索表的词序列，以空格分隔
+// 连续的表意文字按相邻两个字切分，并在末尾补上最后一个字，使每个字都是某个词的开头，单个字可以按前缀检索
+func searchTokens(text string) string {
+	var tokens []string
+	for _, segment := range splitSearchSegments(text) {
+		if !segment.ideograph {
+			tokens = append(tokens, string(segment.text))
+			continue
+		}
+		tokens = append(tokens, bigrams(segment.text)...)
+		if len(segment.text) > 1 {
+			tokens = append(tokens, string(segment.text[len(segment.text)-1:]))
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// searchMatchExpr 将检索文字转换为 FTS5 的查询表达式
+// 每段连续的表意文字转换为由相邻两个字组成的短语，每个单词按前缀匹配，单个字按以该字开头的词匹配，各段之间为 AND
+func searchMatchExpr(text string) string {
+	var phrases []string
+	for _, segment := range splitSearchSegments(text) {
+		// 词只包含字母、数字和表意文字，不需要转义
+		if segment.ideograph && len(segment.text) > 1 {
+			phrases = append(phrases, `"`+strings.Join(bigrams(segment.text), " ")+`"`)
+		} else {
+			phrases = append(phrases, `"`+string(segment.text)+`" *`)
+		}
+	}
+	return strings.Join(phrases, " AND ")
+}
@@ -0,0 +1,106 @@
+package dao
+
+import (
+	"examsystem/dao/model"
+	"reflect"
+	"testing"
+)
+
+func TestSearchTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Go语言的切片", "go 语言 言的 的切 切片 片"},
+		{"切片", "切片 片"},
+		{"栈", "栈"},
+		{"HTTP/2 协议", "http 2 协议 议"},
+		{"What's a goroutine?", "what s a goroutine"},
+		{"数组a1和b2", "数组 组 a1 和 b2"},
+		{"ひらがなテスト", "ひら らが がな なテ テス スト ト"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := searchTokens(tt.text); got != tt.want {
+			t.Errorf("searchTokens(%q) = %q，应为 %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSearchMatchExpr(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"切片扩容", `"切片 片扩 扩容"`},
+		{"栈", `"栈" *`},
+		{"Goroutine", `"goroutine" *`},
+		{"go 切片", `"go" * AND "切片"`},
+		{`"map" OR 1`, `"map" * AND "or" * AND "1" *`},
+		{"  ！？  ", ""},
+	}
+	for _, tt := range tests {
+		if got := searchMatchExpr(tt.text); got != tt.want {
+			t.Errorf("searchMatchExpr(%q) = %q，应为 %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	got := SearchTerms("Go 的切片(slice)")
+	want := []string{"go", "的切片", "slice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SearchTerms = %q，应为 %q", got, want)
+	}
+}
+
+func TestSearchQuestions(t *testing.T) {
+	dao := newTestQuestionDAO(t)
+	questions := []*model.Question{
+		{Title: "Go 语言中切片的扩容规则是什么？", Explanation: "append 时容量不足会重新分配数组"},
+		{Title: "数组和链表的区别", Explanation: "切片是对数组的引用"},
+		{Title: "goroutine 与线程的区别", Keywords: "并发"},
+		{Title: "栈和队列", Options: `["先进先出", "后进先出"]`},
+	}
+	for _, question := range questions {
+		question.UserID = 1
+		question.QuestionType = model.QuestionTypeShortAnswer
+		question.Status = model.QuestionStatusActive
+		if question.Options == "" {
+			question.Options = "[]"
+		}
+		if err := dao.CreateQuestion(question); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []int64 // 按相关度排序的题目
+	}{
+		{"题干命中排在解析命中之前", "切片", []int64{questions[0].ID, questions[1].ID}},
+		{"单个字按前缀匹配", "栈", []int64{questions[3].ID}},
+		{"单词按前缀匹配且忽略大小写", "GOROUT", []int64{questions[2].ID}},
+		{"检索选项", "先进先出", []int64{questions[3].ID}},
+		{"检索关键词", "并发", []int64{questions[2].ID}},
+		{"所有词都需要命中", "切片 扩容", []int64{questions[0].ID}},
+		{"连续的中文按短语匹配", "切数组", nil},
+		{"没有可检索的词", "？？", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, total, err := dao.SearchQuestions(1, tt.text, QuestionFilter{}, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, hit := range hits {
+				got = append(got, hit.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) || total != int64(len(tt.want)) {
+				t.Fatalf("检索 %q 得到 %v（共%d条），应为 %v", tt.text, got, total, tt.want)
+			}
+		})
+	}
+}
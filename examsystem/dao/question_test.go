@@ -0,0 +1,120 @@
+package dao
+
+import (
+	"examsystem/dao/model"
+	"testing"
+	"time"
+)
+
+// newTestQuestionDAO 创建使用当前数据库结构的临时数据库
+func newTestQuestionDAO(t *testing.T) *QuestionDAO {
+	t.Helper()
+	db := openTestDB(t)
+	if err := migrateSchema(db, readInitSQL(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO users (id, username, password_hash) VALUES (1, 'admin', 'x')").Error; err != nil {
+		t.Fatal(err)
+	}
+	return NewQuestionDAO(db)
+}
+
+func TestUpdateQuestionKeepsProtectedColumns(t *testing.T) {
+	dao := newTestQuestionDAO(t)
+	createdAt := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	question := &model.Question{
+		Title:         "Go 中切片的零值是？",
+		QuestionType:  model.QuestionTypeSingle,
+		Options:       `["nil","[]"]`,
+		Answer:        "A",
+		Difficulty:    2,
+		Language:      "Go",
+		AIModel:       "deepseek",
+		ReviewVerdict: model.ReviewVerdictFlagged,
+		Status:        model.QuestionStatusDraft,
+		BatchID:       "batch-1",
+		UserID:        1,
+		CreatedAt:     createdAt,
+	}
+	if err := dao.CreateQuestion(question); err != nil {
+		t.Fatal(err)
+	}
+
+	// 与编辑接口一样只填写可编辑的字段
+	edited := &model.Question{
+		ID:           question.ID,
+		UserID:       question.UserID,
+		Title:        "Go 中 map 的零值是？",
+		QuestionType: model.QuestionTypeSingle,
+		Options:      `["nil","{}"]`,
+		Answer:       "A",
+		Difficulty:   4,
+		Language:     "Go",
+		AIModel:      "deepseek",
+	}
+	if err := dao.UpdateQuestion(edited); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := dao.GetQuestionByID(question.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(createdAt) {
+		t.Errorf("created_at = %v, want %v", got.CreatedAt, createdAt)
+	}
+	if got.Status != model.QuestionStatusDraft || got.BatchID != "batch-1" || got.UserID != 1 {
+		t.Errorf("status, batch and owner changed: %q %q %d", got.Status, got.BatchID, got.UserID)
+	}
+	if got.Title != edited.Title || got.Difficulty != 4 || got.ReviewVerdict != "" {
+		t.Errorf("editable columns not updated: %q %d %q", got.Title, got.Difficulty, got.ReviewVerdict)
+	}
+	if !got.UpdatedAt.After(createdAt) {
+		t.Errorf("updated_at = %v, not refreshed", got.UpdatedAt)
+	}
+}
+
+func TestDeleteExpiredDraftsKeepsEditedDrafts(t *testing.T) {
+	dao := newTestQuestionDAO(t)
+	now := time.Now()
+	newDraft := func(title string, createdAt time.Time) *model.Question {
+		question := &model.Question{
+			Title:        title,
+			QuestionType: model.QuestionTypeJudge,
+			Options:      "[]",
+			Answer:       "true",
+			Difficulty:   3,
+			Language:     "Go",
+			AIModel:      "deepseek",
+			Status:       model.QuestionStatusDraft,
+			UserID:       1,
+			CreatedAt:    createdAt,
+		}
+		if err := dao.CreateQuestion(question); err != nil {
+			t.Fatal(err)
+		}
+		return question
+	}
+	expired := newDraft("过期的待确认题目", now.Add(-100*time.Hour))
+	edited := newDraft("刚生成并修改过的待确认题目", now)
+	if err := dao.UpdateQuestion(&model.Question{
+		ID: edited.ID, UserID: 1, Title: "修改后的题目", QuestionType: model.QuestionTypeJudge,
+		Options: "[]", Answer: "false", Difficulty: 3, Language: "Go", AIModel: "deepseek",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := dao.DeleteExpiredDrafts(now.Add(-72 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("deleted %d drafts, want 1", count)
+	}
+	if _, err := dao.GetQuestionByID(expired.ID); err == nil {
+		t.Error("expired draft was not deleted")
+	}
+	if _, err := dao.GetQuestionByID(edited.ID); err != nil {
+		t.Errorf("edited draft was deleted: %v", err)
+	}
+}
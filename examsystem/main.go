@@ -14,14 +14,33 @@ import (
 
 // 应用依赖
 type AppDependencies struct {
-	DB                 *gorm.DB
-	UserDAO            *dao.UserDAO
-	QuestionDAO        *dao.QuestionDAO
-	UserService        *service.UserService
-	QuestionService    *service.QuestionService
-	userController     *controllers.UserController
-	authController     *controllers.AuthController
-	questionController *controllers.QuestionController
+	DB                       *gorm.DB
+	UserDAO                  *dao.UserDAO
+	QuestionDAO              *dao.QuestionDAO
+	QuestionJobDAO           *dao.QuestionJobDAO
+	PaperDAO                 *dao.PaperDAO
+	ExamDAO                  *dao.ExamDAO
+	PromptTemplateDAO        *dao.PromptTemplateDAO
+	KnowledgeDAO             *dao.KnowledgeDAO
+	UserService              *service.UserService
+	QuestionService          *service.QuestionService
+	QuestionJobService       *service.QuestionJobService
+	PaperService             *service.PaperService
+	GradingService           *service.GradingService
+	ExamService              *service.ExamService
+	MarkingService           *service.MarkingService
+	PromptTemplateService    *service.PromptTemplateService
+	TrashService             *service.TrashService
+	KnowledgeService         *service.KnowledgeService
+	userController           *controllers.UserController
+	authController           *controllers.AuthController
+	questionController       *controllers.QuestionController
+	paperController          *controllers.PaperController
+	examController           *controllers.ExamController
+	markingController        *controllers.MarkingController
+	promptTemplateController *controllers.PromptTemplateController
+	trashController          *controllers.TrashController
+	knowledgeController      *controllers.KnowledgeController
 }
 
 // GetUserController 获取用户控制器
@@ -43,12 +62,63 @@ func (d *AppDependencies) GetAuthController() *controllers.AuthController {
 // GetQuestionController 获取题目控制器
 func (d *AppDependencies) GetQuestionController() *controllers.QuestionController {
 	if d.questionController == nil {
-		d.questionController = controllers.NewQuestionController(d.QuestionService)
+		d.questionController = controllers.NewQuestionController(d.QuestionService, d.QuestionJobService, d.KnowledgeService)
 	}
 	return d.questionController
 }
 
+// GetPaperController 获取试卷控制器
+func (d *AppDependencies) GetPaperController() *controllers.PaperController {
+	if d.paperController == nil {
+		d.paperController = controllers.NewPaperController(d.PaperService)
+	}
+	return d.paperController
+}
+
+// GetExamController 获取考试控制器
+func (d *AppDependencies) GetExamController() *controllers.ExamController {
+	if d.examController == nil {
+		d.examController = controllers.NewExamController(d.ExamService)
+	}
+	return d.examController
+}
+
+// GetMarkingController 获取人工阅卷控制器
+func (d *AppDependencies) GetMarkingController() *controllers.MarkingController {
+	if d.markingController == nil {
+		d.markingController = controllers.NewMarkingController(d.MarkingService)
+	}
+	return d.markingController
+}
+
+// GetPromptTemplateController 获取提示语模板控制器
+func (d *AppDependencies) GetPromptTemplateController() *controllers.PromptTemplateController {
+	if d.promptTemplateController == nil {
+		d.promptTemplateController = controllers.NewPromptTemplateController(d.PromptTemplateService)
+	}
+	return d.promptTemplateController
+}
+
+// GetTrashController 获取回收站控制器
+func (d *AppDependencies) GetTrashController() *controllers.TrashController {
+	if d.trashController == nil {
+		d.trashController = controllers.NewTrashController(d.TrashService)
+	}
+	return d.trashController
+}
+
+// GetKnowledgeController 获取知识体系控制器
+func (d *AppDependencies) GetKnowledgeController() *controllers.KnowledgeController {
+	if d.knowledgeController == nil {
+		d.knowledgeController = controllers.NewKnowledgeController(d.KnowledgeService)
+	}
+	return d.knowledgeController
+}
+
 func main() {
+	// 作为代码运行沙箱的初始化进程启动时，在这里进入沙箱执行考生程序，不再继续启动服务
+	service.InitSandbox()
+
 	// 获取配置
 	appConfig := config.GetConfig()
 
@@ -62,6 +132,25 @@ func main() {
 	// 初始化依赖
 	deps := initDependencies(db)
 
+	// 继续评分上次退出时仍在后台评分的考试
+	if err := deps.GradingService.ResumeGrading(); err != nil {
+		log.Fatalf("恢复考试评分失败: %v", err)
+	}
+
+	// 启动超时考试自动提交任务
+	go deps.ExamService.RunAutoSubmitWorker(appConfig.ExamAutoSubmitInterval, make(chan struct{}))
+
+	// 启动题目生成任务执行者，并继续执行上次退出时未完成的任务
+	if err := deps.QuestionJobService.RunWorkers(make(chan struct{})); err != nil {
+		log.Fatalf("启动题目生成任务失败: %v", err)
+	}
+
+	// 启动过期待确认题目的清理任务
+	go deps.QuestionService.RunDraftCleanupWorker(make(chan struct{}))
+
+	// 启动回收站过期项目的清理任务
+	go deps.TrashService.RunPurgeWorker(make(chan struct{}))
+
 	// 设置Gin模式
 	gin.SetMode(appConfig.Mode)
 
@@ -83,16 +172,43 @@ func initDependencies(db *gorm.DB) *AppDependencies {
 	// 初始化DAO
 	userDAO := dao.NewUserDAO(db)
 	questionDAO := dao.NewQuestionDAO(db)
+	questionJobDAO := dao.NewQuestionJobDAO(db)
+	paperDAO := dao.NewPaperDAO(db)
+	examDAO := dao.NewExamDAO(db)
+	promptTemplateDAO := dao.NewPromptTemplateDAO(db)
+	knowledgeDAO := dao.NewKnowledgeDAO(db)
 
 	// 初始化服务
 	userService := service.NewUserService(userDAO)
-	questionService := service.NewQuestionService(questionDAO, config.LoadAIConfig())
+	promptTemplateService := service.NewPromptTemplateService(promptTemplateDAO)
+	knowledgeService := service.NewKnowledgeService(knowledgeDAO, questionDAO)
+	questionService := service.NewQuestionService(questionDAO, service.NewAIProviderRegistry(config.LoadAIConfig()), config.LoadAIGenerationConfig(), promptTemplateService, knowledgeService)
+	questionJobService := service.NewQuestionJobService(questionJobDAO, questionDAO, questionService, config.LoadQuestionJobConfig())
+	paperService := service.NewPaperService(paperDAO, questionDAO)
+	codeRunner := service.NewCodeRunner(config.LoadCodeRunnerConfig())
+	gradingService := service.NewGradingService(examDAO, paperService, config.LoadGradingConfig(), codeRunner)
+	examService := service.NewExamService(examDAO, paperDAO, paperService, gradingService)
+	markingService := service.NewMarkingService(examDAO, paperDAO, paperService)
+	trashService := service.NewTrashService(questionDAO, paperDAO, examDAO, config.LoadTrashConfig())
 
 	return &AppDependencies{
-		DB:              db,
-		UserDAO:         userDAO,
-		QuestionDAO:     questionDAO,
-		UserService:     userService,
-		QuestionService: questionService,
+		DB:                    db,
+		UserDAO:               userDAO,
+		QuestionDAO:           questionDAO,
+		QuestionJobDAO:        questionJobDAO,
+		PaperDAO:              paperDAO,
+		ExamDAO:               examDAO,
+		PromptTemplateDAO:     promptTemplateDAO,
+		KnowledgeDAO:          knowledgeDAO,
+		UserService:           userService,
+		QuestionService:       questionService,
+		QuestionJobService:    questionJobService,
+		PaperService:          paperService,
+		GradingService:        gradingService,
+		ExamService:           examService,
+		MarkingService:        markingService,
+		PromptTemplateService: promptTemplateService,
+		TrashService:          trashService,
+		KnowledgeService:      knowledgeService,
 	}
 }
@@ -80,3 +80,34 @@ func AdminAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RoleAuth 角色权限中间件，用户角色需为 roles 之一
+func RoleAuth(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 需要先经过JWTAuth中间件
+		role, exists := c.Get("role")
+
+		if !exists {
+			utils.Unauthorized(c, "未授权")
+			c.Abort()
+			return
+		}
+
+		roleStr, ok := role.(string)
+		if !ok {
+			utils.Unauthorized(c, "角色类型错误")
+			c.Abort()
+			return
+		}
+
+		for _, allowed := range roles {
+			if roleStr == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		utils.Forbidden(c, "当前角色无权访问")
+		c.Abort()
+	}
+}
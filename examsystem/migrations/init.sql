@@ -14,13 +14,23 @@ CREATE TABLE IF NOT EXISTS users (
 CREATE TABLE IF NOT EXISTS questions (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     title TEXT NOT NULL,
-    question_type VARCHAR(20) NOT NULL CHECK (question_type IN ('single', 'multiple')),
+    question_type VARCHAR(20) NOT NULL CHECK (question_type IN ('single', 'multiple', 'judge', 'blank', 'short_answer', 'coding')),
     options TEXT NOT NULL,
     answer TEXT NOT NULL,
     explanation TEXT DEFAULT '',
+    code_template TEXT DEFAULT '',
+    test_cases TEXT DEFAULT '',
+    difficulty INTEGER NOT NULL DEFAULT 3 CHECK (difficulty BETWEEN 1 AND 5),
+    cognitive_level VARCHAR(20) DEFAULT '' CHECK (cognitive_level IN ('', 'remember', 'understand', 'apply', 'analyze', 'evaluate', 'create')),
     keywords VARCHAR(255) DEFAULT '',
     language VARCHAR(50) NOT NULL,
     ai_model VARCHAR(50) NOT NULL,
+    review_verdict VARCHAR(20) DEFAULT '' CHECK (review_verdict IN ('', 'pass', 'flagged')),
+    review_issues TEXT DEFAULT '',
+    review_suggestion TEXT DEFAULT '',
+    review_model VARCHAR(50) DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('draft', 'active', 'archived')),
+    batch_id VARCHAR(32) DEFAULT '',
     user_id INTEGER NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -33,7 +43,13 @@ CREATE TABLE IF NOT EXISTS papers (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     title VARCHAR(255) NOT NULL,
     description TEXT DEFAULT '',
-    total_score INTEGER DEFAULT 100,
+    total_score INTEGER NOT NULL DEFAULT 0,
+    duration_minutes INTEGER NOT NULL DEFAULT 0,
+    open_at DATETIME DEFAULT NULL,
+    close_at DATETIME DEFAULT NULL,
+    shuffle_questions BOOLEAN NOT NULL DEFAULT 0,
+    shuffle_options BOOLEAN NOT NULL DEFAULT 0,
+    published BOOLEAN NOT NULL DEFAULT 0,
     creator_id INTEGER NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -55,11 +71,153 @@ CREATE TABLE IF NOT EXISTS paper_questions (
     UNIQUE (paper_id, question_id)
 );
 
+-- 创建考试会话表
+CREATE TABLE IF NOT EXISTS exam_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    paper_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress' CHECK (status IN ('in_progress', 'submitted', 'grading', 'graded')),
+    score REAL NOT NULL DEFAULT 0,
+    started_at DATETIME NOT NULL,
+    deadline DATETIME DEFAULT NULL,
+    shuffle_seed INTEGER NOT NULL DEFAULT 0,
+    shuffle_questions BOOLEAN NOT NULL DEFAULT 0,
+    shuffle_options BOOLEAN NOT NULL DEFAULT 0,
+    submitted_at DATETIME DEFAULT NULL,
+    auto_submitted BOOLEAN NOT NULL DEFAULT 0,
+    graded_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (paper_id) REFERENCES papers(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 创建考试作答表
+CREATE TABLE IF NOT EXISTS exam_answers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id INTEGER NOT NULL,
+    question_id INTEGER NOT NULL,
+    answer TEXT NOT NULL DEFAULT '',
+    score REAL NOT NULL DEFAULT 0,
+    is_correct BOOLEAN NOT NULL DEFAULT 0,
+    feedback TEXT DEFAULT '',
+    test_results TEXT DEFAULT '',
+    comment TEXT DEFAULT '',
+    marked_by INTEGER DEFAULT NULL,
+    graded_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES exam_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+    UNIQUE (session_id, question_id)
+);
+
+-- 创建题目生成任务表
+CREATE TABLE IF NOT EXISTS question_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    ai_model VARCHAR(50) NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    question_type VARCHAR(20) NOT NULL,
+    keywords VARCHAR(255) DEFAULT '',
+    num_questions INTEGER NOT NULL,
+    difficulty INTEGER NOT NULL DEFAULT 0,
+    cognitive_level VARCHAR(20) DEFAULT '',
+    template_id INTEGER NOT NULL DEFAULT 0,
+    template_version INTEGER NOT NULL DEFAULT 0,
+    review BOOLEAN NOT NULL DEFAULT 0,
+    review_model VARCHAR(50) DEFAULT '',
+    knowledge_point_id INTEGER NOT NULL DEFAULT 0,
+    batch_id VARCHAR(32) DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT DEFAULT '',
+    question_ids TEXT DEFAULT '',
+    report TEXT DEFAULT '',
+    started_at DATETIME DEFAULT NULL,
+    finished_at DATETIME DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 创建提示语模板表
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    subject VARCHAR(50) DEFAULT '',
+    question_type VARCHAR(20) DEFAULT '',
+    description TEXT DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    is_default BOOLEAN NOT NULL DEFAULT 0,
+    creator_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME DEFAULT NULL,
+    FOREIGN KEY (creator_id) REFERENCES users(id)
+);
+
+-- 创建提示语模板版本表
+CREATE TABLE IF NOT EXISTS prompt_template_versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    template_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    creator_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (template_id) REFERENCES prompt_templates(id) ON DELETE CASCADE,
+    UNIQUE (template_id, version)
+);
+
+-- 创建知识体系表（学科 → 章节 → 知识点）
+CREATE TABLE IF NOT EXISTS knowledge_nodes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parent_id INTEGER NOT NULL DEFAULT 0,
+    level VARCHAR(20) NOT NULL CHECK (level IN ('subject', 'chapter', 'point')),
+    name VARCHAR(100) NOT NULL,
+    description TEXT DEFAULT '',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (parent_id, name)
+);
+
+-- 创建题目知识点关联表
+CREATE TABLE IF NOT EXISTS question_knowledge_points (
+    question_id INTEGER NOT NULL,
+    knowledge_point_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (question_id, knowledge_point_id),
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+    FOREIGN KEY (knowledge_point_id) REFERENCES knowledge_nodes(id) ON DELETE CASCADE
+);
+
+-- 创建题目全文检索表（rowid 为题目ID，内容由程序切分为词后写入，中文按相邻两个字切分）
+CREATE VIRTUAL TABLE IF NOT EXISTS questions_fts USING fts5(
+    title,
+    options,
+    explanation,
+    keywords,
+    tokenize = 'unicode61'
+);
+
 -- 创建索引以提高查询性能
 CREATE INDEX IF NOT EXISTS idx_questions_user_id ON questions(user_id);
+CREATE INDEX IF NOT EXISTS idx_questions_user_difficulty ON questions(user_id, difficulty);
+CREATE INDEX IF NOT EXISTS idx_questions_user_status ON questions(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_questions_status_created_at ON questions(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_questions_batch_id ON questions(batch_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_nodes_parent_id ON knowledge_nodes(parent_id);
+CREATE INDEX IF NOT EXISTS idx_question_knowledge_points_point_id ON question_knowledge_points(knowledge_point_id);
 CREATE INDEX IF NOT EXISTS idx_papers_creator_id ON papers(creator_id);
 CREATE INDEX IF NOT EXISTS idx_paper_questions_paper_id ON paper_questions(paper_id);
 CREATE INDEX IF NOT EXISTS idx_paper_questions_question_id ON paper_questions(question_id);
+CREATE INDEX IF NOT EXISTS idx_exam_sessions_paper_id ON exam_sessions(paper_id);
+CREATE INDEX IF NOT EXISTS idx_exam_sessions_user_id ON exam_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_exam_sessions_status_deadline ON exam_sessions(status, deadline);
+CREATE INDEX IF NOT EXISTS idx_exam_answers_session_id ON exam_answers(session_id);
+CREATE INDEX IF NOT EXISTS idx_question_jobs_user_id ON question_jobs(user_id);
+CREATE INDEX IF NOT EXISTS idx_question_jobs_status ON question_jobs(status);
 
 -- 启用外键约束
 PRAGMA foreign_keys = ON;
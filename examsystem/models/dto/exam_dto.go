@@ -0,0 +1,100 @@
+package dto
+
+import "time"
+
+// 开始考试请求
+type StartExamRequest struct {
+	PaperID int64 `json:"paper_id" binding:"required"`
+}
+
+// 单题作答
+type ExamAnswerItem struct {
+	QuestionID int64  `json:"question_id" binding:"required"`
+	Answer     string `json:"answer"`
+}
+
+// 保存作答请求
+type SaveAnswersRequest struct {
+	Answers []ExamAnswerItem `json:"answers" binding:"required,min=1,dive"`
+}
+
+// 考试会话响应
+type ExamSessionResponse struct {
+	ID            int64      `json:"id"`
+	PaperID       int64      `json:"paper_id"`
+	Status        string     `json:"status"`
+	Score         float64    `json:"score"`
+	StartedAt     time.Time  `json:"started_at"`
+	Deadline      *time.Time `json:"deadline"` // 作答截止时间，为空表示不限时
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	AutoSubmitted bool       `json:"auto_submitted"`
+	GradedAt      *time.Time `json:"graded_at"`
+}
+
+// 考试题目响应（不包含答案和解析）
+type ExamQuestionResponse struct {
+	QuestionID    int64    `json:"question_id"`
+	QuestionOrder int      `json:"question_order"`
+	Score         int      `json:"score"`
+	Title         string   `json:"title"`
+	QuestionType  string   `json:"question_type"`
+	Options       []string `json:"options"`
+	CodeLanguage  string   `json:"code_language,omitempty"` // 编程题使用的编程语言
+	CodeTemplate  string   `json:"code_template,omitempty"` // 编程题的代码模板
+	MyAnswer      string   `json:"my_answer"`
+}
+
+// 考试详情响应
+type ExamDetailResponse struct {
+	ExamSessionResponse
+	PaperTitle       string                  `json:"paper_title"`
+	TotalScore       int                     `json:"total_score"`
+	DurationMinutes  int                     `json:"duration_minutes"`
+	RemainingSeconds *int64                  `json:"remaining_seconds"` // 按服务器时间计算的剩余作答秒数，为空表示不限时
+	Questions        []*ExamQuestionResponse `json:"questions"`
+}
+
+// 考试成绩中的单题得分明细
+type ExamResultQuestionResponse struct {
+	QuestionID    int64    `json:"question_id"`
+	QuestionOrder int      `json:"question_order"`
+	FullScore     int      `json:"full_score"`
+	Title         string   `json:"title"`
+	QuestionType  string   `json:"question_type"`
+	Options       []string `json:"options"`
+	MyAnswer      string   `json:"my_answer"`
+	CorrectAnswer string   `json:"correct_answer"`
+	Explanation   string   `json:"explanation"`
+	Score         float64  `json:"score"`
+	IsCorrect     bool     `json:"is_correct"`
+	Graded        bool     `json:"graded"`
+	Feedback      string   `json:"feedback"`
+	// 编程题每个测试用例的运行结果
+	TestResults []*TestCaseResultResponse `json:"test_results,omitempty"`
+	// 阅卷老师的评语
+	Comment string `json:"comment"`
+}
+
+// 编程题单个测试用例的运行结果
+type TestCaseResultResponse struct {
+	Index   int    `json:"index"`
+	Status  string `json:"status"`
+	TimeMs  int64  `json:"time_ms"`
+	Message string `json:"message,omitempty"`
+}
+
+// 考试成绩响应
+type ExamResultResponse struct {
+	ExamSessionResponse
+	PaperTitle string                        `json:"paper_title"`
+	TotalScore int                           `json:"total_score"`
+	Questions  []*ExamResultQuestionResponse `json:"questions"`
+}
+
+// 考试记录列表响应
+type ExamListResponse struct {
+	List  []*ExamSessionResponse `json:"list"`
+	Total int64                  `json:"total"`
+	Page  int                    `json:"page"`
+	Size  int                    `json:"size"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// 创建或更新知识体系节点请求
+type KnowledgeNodeRequest struct {
+	ParentID    int64  `json:"parent_id"` // 上级节点，为0时创建学科；更新时忽略
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	SortOrder   int    `json:"sort_order"` // 同级节点的排列顺序，越小越靠前
+}
+
+// 知识体系节点响应
+type KnowledgeNodeResponse struct {
+	ID            int64                    `json:"id"`
+	ParentID      int64                    `json:"parent_id"`
+	Level         string                   `json:"level"` // subject（学科）、chapter（章节）或 point（知识点）
+	Name          string                   `json:"name"`
+	Description   string                   `json:"description"`
+	SortOrder     int                      `json:"sort_order"`
+	QuestionCount int64                    `json:"question_count"`     // 当前用户题库中关联到该节点或其下级知识点的题目数量
+	Children      []*KnowledgeNodeResponse `json:"children,omitempty"` // 下级节点，只在知识体系树中返回
+	CreatedAt     time.Time                `json:"created_at"`
+	UpdatedAt     time.Time                `json:"updated_at"`
+}
+
+// 知识体系节点统计响应
+type KnowledgeNodeStatisticsResponse struct {
+	Node          *KnowledgeNodeResponse   `json:"node"`
+	Path          []*KnowledgeNodeResponse `json:"path"` // 从学科到该节点的路径
+	QuestionCount int64                    `json:"question_count"`
+	ByType        map[string]int64         `json:"by_type"`       // 按题型统计
+	ByDifficulty  map[int]int64            `json:"by_difficulty"` // 按难度统计
+	Children      []*KnowledgeNodeResponse `json:"children"`      // 下级节点及其题目数量
+}
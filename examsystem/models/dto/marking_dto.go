@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// 人工阅卷请求
+type MarkAnswerRequest struct {
+	Score   *float64 `json:"score" binding:"required"`
+	Comment string   `json:"comment"`
+}
+
+// 待阅卷试卷响应
+type MarkingPaperResponse struct {
+	PaperID         int64  `json:"paper_id"`
+	Title           string `json:"title"`
+	PendingAnswers  int64  `json:"pending_answers"`  // 待阅卷的作答数
+	PendingSessions int64  `json:"pending_sessions"` // 有待阅卷作答的考试数
+}
+
+// 阅卷列表中的作答响应
+type MarkingAnswerResponse struct {
+	AnswerID        int64                     `json:"answer_id"`
+	SessionID       int64                     `json:"session_id"`
+	SessionStatus   string                    `json:"session_status"`
+	UserID          int64                     `json:"user_id"`
+	Username        string                    `json:"username"`
+	SubmittedAt     *time.Time                `json:"submitted_at"`
+	QuestionID      int64                     `json:"question_id"`
+	QuestionType    string                    `json:"question_type"`
+	Title           string                    `json:"title"`
+	Options         []string                  `json:"options"`
+	ReferenceAnswer string                    `json:"reference_answer"`
+	Explanation     string                    `json:"explanation"`
+	FullScore       int                       `json:"full_score"`
+	Answer          string                    `json:"answer"`
+	Score           float64                   `json:"score"`
+	Graded          bool                      `json:"graded"`
+	Feedback        string                    `json:"feedback"`
+	TestResults     []*TestCaseResultResponse `json:"test_results,omitempty"`
+	Comment         string                    `json:"comment"`
+	MarkedBy        *int64                    `json:"marked_by"`
+}
+
+// 阅卷作答列表响应
+type MarkingAnswerListResponse struct {
+	List  []*MarkingAnswerResponse `json:"list"`
+	Total int64                    `json:"total"`
+	Page  int                      `json:"page"`
+	Size  int                      `json:"size"`
+}
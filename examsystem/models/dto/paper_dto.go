@@ -0,0 +1,90 @@
+package dto
+
+import "time"
+
+// 创建试卷请求
+type CreatePaperRequest struct {
+	Title            string     `json:"title" binding:"required,max=255"`
+	Description      string     `json:"description"`
+	DurationMinutes  int        `json:"duration_minutes" binding:"min=0"` // 考试时长（分钟），0表示不限时
+	OpenAt           *time.Time `json:"open_at"`                          // 开放作答时间
+	CloseAt          *time.Time `json:"close_at"`                         // 截止作答时间
+	ShuffleQuestions bool       `json:"shuffle_questions"`                // 考生题目乱序
+	ShuffleOptions   bool       `json:"shuffle_options"`                  // 考生选项乱序
+	Published        bool       `json:"published"`                        // 发布后其他用户才能参加考试
+}
+
+// 更新试卷请求
+type UpdatePaperRequest struct {
+	Title            string     `json:"title" binding:"required,max=255"`
+	Description      string     `json:"description"`
+	DurationMinutes  int        `json:"duration_minutes" binding:"min=0"`
+	OpenAt           *time.Time `json:"open_at"`
+	CloseAt          *time.Time `json:"close_at"`
+	ShuffleQuestions bool       `json:"shuffle_questions"`
+	ShuffleOptions   bool       `json:"shuffle_options"`
+	Published        bool       `json:"published"`
+}
+
+// 添加题目到试卷请求
+type AddPaperQuestionRequest struct {
+	QuestionID int64 `json:"question_id" binding:"required"`
+	Score      *int  `json:"score"` // 分值，不传时默认5分
+}
+
+// 更新试卷题目顺序请求
+type UpdateQuestionOrderRequest struct {
+	QuestionIDs []int64 `json:"question_ids" binding:"required"` // 按新顺序排列的题目ID
+}
+
+// 试卷响应
+type PaperResponse struct {
+	ID               int64      `json:"id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	TotalScore       int        `json:"total_score"`
+	QuestionCount    int        `json:"question_count"`
+	DurationMinutes  int        `json:"duration_minutes"`
+	OpenAt           *time.Time `json:"open_at"`
+	CloseAt          *time.Time `json:"close_at"`
+	ShuffleQuestions bool       `json:"shuffle_questions"`
+	ShuffleOptions   bool       `json:"shuffle_options"`
+	Published        bool       `json:"published"`
+	CreatorID        int64      `json:"creator_id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// 试卷题目响应
+type PaperQuestionResponse struct {
+	QuestionID    int64    `json:"question_id"`
+	QuestionOrder int      `json:"question_order"`
+	Score         int      `json:"score"`
+	Title         string   `json:"title"`
+	QuestionType  string   `json:"question_type"`
+	Options       []string `json:"options"`
+	Answer        string   `json:"answer"`
+	Explanation   string   `json:"explanation"`
+}
+
+// 试卷详情响应
+type PaperDetailResponse struct {
+	PaperResponse
+	Questions []*PaperQuestionResponse `json:"questions"`
+}
+
+// 试卷列表响应
+type PaperListResponse struct {
+	List  []*PaperResponse `json:"list"`
+	Total int64            `json:"total"`
+	Page  int              `json:"page"`
+	Size  int              `json:"size"`
+}
+
+// 用户统计响应
+type UserStatisticsResponse struct {
+	QuestionCount      int64            `json:"question_count"`
+	QuestionTypeCounts map[string]int64 `json:"question_type_counts"`
+	PaperCount         int64            `json:"paper_count"`
+	PaperQuestionCount int64            `json:"paper_question_count"`
+}
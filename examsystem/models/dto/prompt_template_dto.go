@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+// 创建或更新提示语模板请求
+type PromptTemplateRequest struct {
+	Name         string `json:"name" binding:"required,max=100"`
+	Subject      string `json:"subject" binding:"max=50"`
+	QuestionType string `json:"question_type"` // 适用的题型，为空表示适用于所有题型
+	Description  string `json:"description"`
+	Content      string `json:"content"`    // 模板内容，更新时为空或与当前版本相同则不新增版本
+	IsDefault    bool   `json:"is_default"` // 设为该题型的默认模板
+}
+
+// 提示语模板响应
+type PromptTemplateResponse struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Subject      string    `json:"subject"`
+	QuestionType string    `json:"question_type"`
+	Description  string    `json:"description"`
+	Version      int       `json:"version"`
+	IsDefault    bool      `json:"is_default"`
+	Content      string    `json:"content,omitempty"` // 当前版本的模板内容
+	CreatorID    int64     `json:"creator_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// 提示语模板版本响应
+type PromptTemplateVersionResponse struct {
+	Version   int       `json:"version"`
+	Content   string    `json:"content"`
+	CreatorID int64     `json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 提示语模板详情响应
+type PromptTemplateDetailResponse struct {
+	*PromptTemplateResponse
+	Versions []*PromptTemplateVersionResponse `json:"versions"`
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// 回收站中题目或试卷所在的试卷
+type TrashPaperRef struct {
+	ID      int64  `json:"id"`
+	Title   string `json:"title"`
+	Deleted bool   `json:"deleted"` // 试卷是否也在回收站中
+}
+
+// 回收站中的题目
+type TrashQuestionResponse struct {
+	ID           int64            `json:"id"`
+	Title        string           `json:"title"`
+	QuestionType string           `json:"question_type"`
+	Language     string           `json:"language"`
+	DeletedAt    time.Time        `json:"deleted_at"`
+	ExpiresAt    *time.Time       `json:"expires_at"` // 自动彻底删除的时间，为空表示不自动删除
+	Papers       []*TrashPaperRef `json:"papers"`     // 包含该题目的试卷
+}
+
+// 回收站中的试卷
+type TrashPaperResponse struct {
+	ID            int64      `json:"id"`
+	Title         string     `json:"title"`
+	TotalScore    int        `json:"total_score"`
+	QuestionCount int        `json:"question_count"`
+	DeletedAt     time.Time  `json:"deleted_at"`
+	ExpiresAt     *time.Time `json:"expires_at"` // 自动彻底删除的时间，为空表示不自动删除
+}
+
+// 回收站内容
+type TrashResponse struct {
+	Questions []*TrashQuestionResponse `json:"questions"`
+	Papers    []*TrashPaperResponse    `json:"papers"`
+}
+
+// 恢复题目的结果
+type RestoreQuestionResponse struct {
+	ID     int64            `json:"id"`
+	Papers []*TrashPaperRef `json:"papers"` // 包含该题目的试卷
+}
+
+// 恢复试卷的结果
+type RestorePaperResponse struct {
+	ID                  int64   `json:"id"`
+	RestoredQuestionIDs []int64 `json:"restored_question_ids"` // 随试卷一并恢复的题目
+}
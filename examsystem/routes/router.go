@@ -3,6 +3,7 @@ package routes
 
 import (
 	"examsystem/controllers"
+	"examsystem/dao/model"
 	"examsystem/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -13,7 +14,12 @@ type AppDependencies interface {
 	GetUserController() *controllers.UserController
 	GetAuthController() *controllers.AuthController
 	GetQuestionController() *controllers.QuestionController
-	// GetPaperController() *controllers.PaperController
+	GetPaperController() *controllers.PaperController
+	GetExamController() *controllers.ExamController
+	GetMarkingController() *controllers.MarkingController
+	GetPromptTemplateController() *controllers.PromptTemplateController
+	GetTrashController() *controllers.TrashController
+	GetKnowledgeController() *controllers.KnowledgeController
 }
 
 // SetupRouter 配置所有路由
@@ -27,7 +33,12 @@ func SetupRouter(deps AppDependencies) *gin.Engine {
 		authController := deps.GetAuthController()
 		userController := deps.GetUserController()
 		questionController := deps.GetQuestionController()
-		// paperController := deps.GetPaperController()
+		paperController := deps.GetPaperController()
+		examController := deps.GetExamController()
+		markingController := deps.GetMarkingController()
+		promptTemplateController := deps.GetPromptTemplateController()
+		trashController := deps.GetTrashController()
+		knowledgeController := deps.GetKnowledgeController()
 
 		// 认证相关路由（无需认证）
 		auth := api.Group("/auth")
@@ -64,12 +75,38 @@ func SetupRouter(deps AppDependencies) *gin.Engine {
 			questionGroup := authorized.Group("/questions")
 			{
 				questionGroup.POST("/generate", questionController.GenerateQuestionsHandler)
+				questionGroup.POST("/generate/stream", questionController.GenerateQuestionsStreamHandler)
+				questionGroup.GET("/models", questionController.GetAIModelsHandler)
+				questionGroup.GET("/jobs/:id", questionController.GetQuestionJobHandler)
 				questionGroup.POST("/confirm", questionController.SaveSelectedQuestionsHandler)
 				questionGroup.GET("", questionController.GetQuestionsByUserIDHandler)
+				questionGroup.GET("/search", questionController.SearchQuestionsHandler) // 全文检索
 				// questionGroup.GET("/:id", questionController.GetQuestionByIDHandler)
 				// 普通用户可以编辑和删除自己的题目
 				questionGroup.PUT("/:id", questionController.UpdateQuestionHandler)
 				questionGroup.DELETE("/:id", questionController.DeleteQuestionHandler)
+				questionGroup.PUT("/:id/status", questionController.SetQuestionStatusHandler)                    // 归档或取消归档
+				questionGroup.PUT("/:id/knowledge-points", questionController.SetQuestionKnowledgePointsHandler) // 设置关联的知识点
+			}
+
+			// 提示语模板路由（所有用户可以查看和选用，管理员可以编辑）
+			promptTemplateGroup := authorized.Group("/prompt-templates")
+			{
+				promptTemplateGroup.GET("", promptTemplateController.GetTemplatesHandler)                                  // 获取模板列表
+				promptTemplateGroup.GET("/:id", promptTemplateController.GetTemplateHandler)                               // 获取模板详情及历史版本
+				promptTemplateGroup.POST("", middleware.AdminAuth(), promptTemplateController.CreateTemplateHandler)       // 创建模板
+				promptTemplateGroup.PUT("/:id", middleware.AdminAuth(), promptTemplateController.UpdateTemplateHandler)    // 更新模板
+				promptTemplateGroup.DELETE("/:id", middleware.AdminAuth(), promptTemplateController.DeleteTemplateHandler) // 删除模板
+			}
+
+			// 知识体系路由（所有用户可以查看，管理员可以编辑）
+			knowledgeGroup := authorized.Group("/knowledge-nodes")
+			{
+				knowledgeGroup.GET("", knowledgeController.GetTreeHandler)                                   // 获取知识体系树
+				knowledgeGroup.GET("/:id/statistics", knowledgeController.GetNodeStatisticsHandler)          // 获取节点下的题目统计
+				knowledgeGroup.POST("", middleware.AdminAuth(), knowledgeController.CreateNodeHandler)       // 创建学科、章节或知识点
+				knowledgeGroup.PUT("/:id", middleware.AdminAuth(), knowledgeController.UpdateNodeHandler)    // 更新节点
+				knowledgeGroup.DELETE("/:id", middleware.AdminAuth(), knowledgeController.DeleteNodeHandler) // 删除节点
 			}
 
 			// 试卷管理路由
@@ -90,6 +127,37 @@ func SetupRouter(deps AppDependencies) *gin.Engine {
 				}
 			}
 
+			// 回收站路由
+			trashGroup := authorized.Group("/trash")
+			{
+				trashGroup.GET("", trashController.GetTrashHandler)                               // 获取回收站内容
+				trashGroup.POST("/questions/:id/restore", trashController.RestoreQuestionHandler) // 恢复题目
+				trashGroup.DELETE("/questions/:id", trashController.PurgeQuestionHandler)         // 彻底删除题目
+				trashGroup.POST("/papers/:id/restore", trashController.RestorePaperHandler)       // 恢复试卷
+				trashGroup.DELETE("/papers/:id", trashController.PurgePaperHandler)               // 彻底删除试卷
+			}
+
+			// 考试路由
+			examGroup := authorized.Group("/exams")
+			{
+				examGroup.POST("", examController.StartExamHandler)               // 开始考试
+				examGroup.GET("", examController.GetExamsHandler)                 // 获取考试记录列表
+				examGroup.GET("/:id", examController.GetExamHandler)              // 获取考试详情
+				examGroup.PUT("/:id/answers", examController.SaveAnswersHandler)  // 保存作答
+				examGroup.POST("/:id/submit", examController.SubmitExamHandler)   // 提交考试
+				examGroup.GET("/:id/result", examController.GetExamResultHandler) // 获取考试成绩
+			}
+
+			// 人工阅卷路由（教师批阅自己的试卷，管理员可批阅全部试卷）
+			markingGroup := authorized.Group("/marking")
+			markingGroup.Use(middleware.RoleAuth(model.RoleAdmin, model.RoleTeacher))
+			{
+				markingGroup.GET("/papers", markingController.GetMarkingPapersHandler)                // 获取待阅卷试卷列表
+				markingGroup.GET("/papers/:id/answers", markingController.GetPaperAnswersHandler)     // 获取试卷的作答列表
+				markingGroup.PUT("/answers/:id", markingController.MarkAnswerHandler)                 // 批阅作答
+				markingGroup.POST("/sessions/:id/finalize", markingController.FinalizeSessionHandler) // 完成考试阅卷
+			}
+
 			// 统计路由
 			statsGroup := authorized.Group("/statistics")
 			{
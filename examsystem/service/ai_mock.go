@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"examsystem/config"
+	"examsystem/dao/model"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockProvider 内置模拟模型：不访问网络，按种子为每个请求生成确定的伪随机题目，
+// 或按脚本文件依次返回固定内容。生成的题目中按 MalformedRate 混入不合格的题目，用于测试校验逻辑；
+// 审核请求检查选项是否重复，并按 MalformedRate 将题目标记为答案错误
+type MockProvider struct {
+	config config.AIProviderConfig
+	script []string
+
+	mu   sync.Mutex
+	next int // 下一次返回的脚本内容下标
+}
+
+// NewMockProvider 创建模拟模型，配置了脚本文件时读取脚本
+func NewMockProvider(providerConfig config.AIProviderConfig) (*MockProvider, error) {
+	p := &MockProvider{config: providerConfig}
+	if providerConfig.ScriptFile != "" {
+		data, err := os.ReadFile(providerConfig.ScriptFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取脚本文件失败: %v", err)
+		}
+		if err := json.Unmarshal(data, &p.script); err != nil {
+			return nil, fmt.Errorf("脚本文件应为JSON字符串数组: %v", err)
+		}
+		if len(p.script) == 0 {
+			return nil, fmt.Errorf("脚本文件为空")
+		}
+	}
+	return p, nil
+}
+
+// Name 模型名称
+func (p *MockProvider) Name() string {
+	return p.config.Name
+}
+
+// Model 请求中使用的模型
+func (p *MockProvider) Model() string {
+	return p.config.Model
+}
+
+// Capabilities 模型能力
+func (p *MockProvider) Capabilities() AICapabilities {
+	return AICapabilities{Streaming: true, MaxTokens: p.config.MaxTokens}
+}
+
+// Generate 返回脚本内容或按种子生成的题目JSON
+func (p *MockProvider) Generate(ctx context.Context, req AIRequest) (string, error) {
+	if p.config.DelayMs > 0 {
+		select {
+		case <-time.After(time.Duration(p.config.DelayMs) * time.Millisecond):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	return p.content(req)
+}
+
+// GenerateStream 将生成的内容分段返回，模拟的响应延迟平均分配到每一段
+func (p *MockProvider) GenerateStream(ctx context.Context, req AIRequest, onChunk func(chunk string)) (string, error) {
+	content, err := p.content(req)
+	if err != nil {
+		return "", err
+	}
+
+	chunks := splitMockChunks(content, mockChunkSize)
+	delay := time.Duration(p.config.DelayMs) * time.Millisecond / time.Duration(len(chunks))
+	for _, chunk := range chunks {
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		onChunk(chunk)
+	}
+	return content, nil
+}
+
+// mockChunkSize 流式输出时每段的字符数
+const mockChunkSize = 16
+
+// splitMockChunks 按字符数将内容切分为多段，不会切断多字节字符
+func splitMockChunks(content string, size int) []string {
+	runes := []rune(content)
+	chunks := make([]string, 0, len(runes)/size+1)
+	for start := 0; start < len(runes); start += size {
+		end := start + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		chunks = append(chunks, string(runes[start:end]))
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, "")
+	}
+	return chunks
+}
+
+// content 返回脚本内容或按种子生成的题目JSON
+func (p *MockProvider) content(req AIRequest) (string, error) {
+	if len(p.script) > 0 {
+		p.mu.Lock()
+		content := p.script[p.next%len(p.script)]
+		p.next++
+		p.mu.Unlock()
+		return content, nil
+	}
+
+	// 相同种子和请求总是得到相同的题目
+	h := fnv.New64a()
+	h.Write([]byte(req.Prompt))
+	rng := rand.New(rand.NewSource(p.config.Seed ^ int64(h.Sum64())))
+
+	if len(req.Review) > 0 {
+		return p.review(rng, req.Review)
+	}
+
+	items := make([]map[string]interface{}, 0, req.NumQuestions)
+	for i := 0; i < req.NumQuestions; i++ {
+		item := mockQuestion(rng, req, req.Offset+i+1)
+		if rng.Float64() < p.config.MalformedRate {
+			malformMockQuestion(rng, req.QuestionType, item)
+		}
+		items = append(items, item)
+	}
+
+	content, err := json.MarshalIndent(map[string]interface{}{"questions": items}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return "```json\n" + string(content) + "\n```", nil
+}
+
+// mockQuestion 按题型生成一道合格的模拟题目
+func mockQuestion(rng *rand.Rand, req AIRequest, index int) map[string]interface{} {
+	topic := strings.TrimSpace(req.Keywords)
+	if topic == "" {
+		topic = req.Language
+	}
+	item := map[string]interface{}{
+		"explanation": fmt.Sprintf("模拟题目解析 #%d", index),
+	}
+
+	switch req.QuestionType {
+	case model.QuestionTypeJudge:
+		item["title"] = fmt.Sprintf("[模拟] 关于%s的陈述 #%d 是正确的", topic, index)
+		item["answer"] = rng.Intn(2) == 0
+
+	case model.QuestionTypeBlank:
+		item["title"] = fmt.Sprintf("[模拟] %s中第%d题的第一个空是____，第二个空是____", topic, index)
+		item["answer"] = [][]string{
+			{fmt.Sprintf("答案%d", rng.Intn(100)), fmt.Sprintf("别名%d", rng.Intn(100))},
+			{fmt.Sprintf("答案%d", rng.Intn(100))},
+		}
+
+	case model.QuestionTypeShortAnswer:
+		item["title"] = fmt.Sprintf("[模拟] 简述%s的要点 #%d", topic, index)
+		item["answer"] = fmt.Sprintf("%s的参考答案 #%d", topic, index)
+		item["explanation"] = "评分要点：1. 概念准确；2. 举例恰当"
+
+	case model.QuestionTypeCoding:
+		language := NormalizeCodeLanguage(req.Language)
+		item["title"] = fmt.Sprintf("[模拟] %s #%d：从标准输入读入两个整数，输出它们的和", topic, index)
+		item["code_template"] = mockCodeTemplates[language]
+		item["answer"] = mockCodeSolutions[language]
+		tests := make([]CodeTestCase, 0, 3)
+		for i := 0; i < 3; i++ {
+			a, b := rng.Intn(1000), rng.Intn(1000)
+			tests = append(tests, CodeTestCase{
+				Input:          fmt.Sprintf("%d %d\n", a, b),
+				ExpectedOutput: fmt.Sprintf("%d\n", a+b),
+			})
+		}
+		item["test_cases"] = tests
+
+	default:
+		options := make([]string, 4)
+		for i := range options {
+			options[i] = fmt.Sprintf("%s的说法 %d-%c", topic, index, 'A'+i)
+		}
+		item["title"] = fmt.Sprintf("[模拟] 下列关于%s的说法正确的是 #%d", topic, index)
+		item["options"] = options
+		if req.QuestionType == model.QuestionTypeMultiple {
+			// 多选题随机选择2-3个正确选项，交替使用字母串和数组两种答案格式
+			letters := []string{"A", "B", "C", "D"}
+			rng.Shuffle(len(letters), func(i, j int) {
+				letters[i], letters[j] = letters[j], letters[i]
+			})
+			picked := letters[:2+rng.Intn(2)]
+			sort.Strings(picked)
+			if index%2 == 0 {
+				item["answer"] = picked
+			} else {
+				item["answer"] = strings.Join(picked, "")
+			}
+		} else {
+			item["answer"] = string(rune('A' + rng.Intn(4)))
+		}
+	}
+
+	// 未指定难度和认知层次时随机标注
+	item["difficulty"] = req.Difficulty
+	if req.Difficulty == 0 {
+		item["difficulty"] = MinDifficulty + rng.Intn(MaxDifficulty-MinDifficulty+1)
+	}
+	item["cognitive_level"] = req.CognitiveLevel
+	if req.CognitiveLevel == "" {
+		item["cognitive_level"] = cognitiveLevels[rng.Intn(len(cognitiveLevels))].Level
+	}
+	return item
+}
+
+// review 生成模拟的审核结果
+func (p *MockProvider) review(rng *rand.Rand, questions []*model.Question) (string, error) {
+	reviews := make([]aiReview, 0, len(questions))
+	for i, question := range questions {
+		review := aiReview{Index: i + 1, Verdict: string(model.ReviewVerdictPass), Issues: []ReviewIssue{}}
+
+		var options []string
+		json.Unmarshal([]byte(question.Options), &options)
+		seen := make(map[string]bool)
+		for _, option := range options {
+			if seen[option] {
+				review.Issues = append(review.Issues, ReviewIssue{Type: ReviewIssueDuplicateOptions, Detail: fmt.Sprintf("选项 '%s' 重复", option)})
+				review.Suggestion = "修改重复的选项"
+				break
+			}
+			seen[option] = true
+		}
+
+		if rng.Float64() < p.config.MalformedRate {
+			review.Issues = append(review.Issues, ReviewIssue{Type: ReviewIssueWrongAnswer, Detail: "[模拟] 标准答案与解析不一致"})
+			review.Suggestion = "核对标准答案"
+		}
+		if len(review.Issues) > 0 {
+			review.Verdict = string(model.ReviewVerdictFlagged)
+		}
+		reviews = append(reviews, review)
+	}
+
+	content, err := json.MarshalIndent(map[string]interface{}{"reviews": reviews}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return "```json\n" + string(content) + "\n```", nil
+}
+
+// malformMockQuestion 将题目改为不合格的形式，模拟AI返回的常见问题
+func malformMockQuestion(rng *rand.Rand, questionType model.QuestionType, item map[string]interface{}) {
+	defects := []func(){
+		func() { item["title"] = "" },
+	}
+	switch questionType {
+	case model.QuestionTypeSingle, model.QuestionTypeMultiple:
+		defects = append(defects,
+			func() { item["options"] = item["options"].([]string)[:3] },
+			func() { item["answer"] = "E" },
+		)
+		if questionType == model.QuestionTypeMultiple {
+			defects = append(defects, func() { item["answer"] = "ABCD" })
+		}
+	case model.QuestionTypeJudge:
+		defects = append(defects, func() { item["answer"] = "不确定" })
+	case model.QuestionTypeBlank:
+		defects = append(defects, func() { item["answer"] = []string{} })
+	case model.QuestionTypeCoding:
+		defects = append(defects, func() { item["test_cases"] = []CodeTestCase{} })
+	}
+	defects[rng.Intn(len(defects))]()
+}
+
+// mockCodeTemplates 模拟编程题的代码模板
+var mockCodeTemplates = map[string]string{
+	"python":     "# 在此编写代码\n",
+	"javascript": "// 在此编写代码\n",
+	"go":         "package main\n\nfunc main() {\n\t// 在此编写代码\n}\n",
+	"c":          "#include <stdio.h>\n\nint main() {\n    // 在此编写代码\n    return 0;\n}\n",
+	"cpp":        "#include <iostream>\n\nint main() {\n    // 在此编写代码\n    return 0;\n}\n",
+}
+
+// mockCodeSolutions 模拟编程题的参考实现
+var mockCodeSolutions = map[string]string{
+	"python":     "a, b = map(int, input().split())\nprint(a + b)\n",
+	"javascript": "const [a, b] = require('fs').readFileSync(0, 'utf8').trim().split(/\\s+/).map(Number);\nconsole.log(a + b);\n",
+	"go":         "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tvar a, b int\n\tfmt.Scan(&a, &b)\n\tfmt.Println(a + b)\n}\n",
+	"c":          "#include <stdio.h>\n\nint main() {\n    int a, b;\n    scanf(\"%d %d\", &a, &b);\n    printf(\"%d\\n\", a + b);\n    return 0;\n}\n",
+	"cpp":        "#include <iostream>\n\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n    return 0;\n}\n",
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"examsystem/config"
+	"examsystem/dao/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMockProviderRequiresOptIn(t *testing.T) {
+	t.Setenv("AI_PROVIDERS", "")
+	t.Setenv("AI_MOCK_ENABLED", "")
+	if _, err := NewAIProviderRegistry(config.LoadAIConfig()).Get("mock"); err == nil {
+		t.Fatal("未设置 AI_MOCK_ENABLED 时不应启用模拟模型")
+	}
+	t.Setenv("AI_MOCK_ENABLED", "true")
+	if _, err := NewAIProviderRegistry(config.LoadAIConfig()).Get("mock"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// parseMockOutput 解析模拟模型的输出，返回通过校验的题目
+func parseMockOutput(t *testing.T, content, language string, questionType model.QuestionType) []*model.Question {
+	t.Helper()
+	items, _, err := extractAIQuestions(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var questions []*model.Question
+	for _, raw := range items {
+		if question, _, err := parseAIQuestionItem(raw, GenerateRequest{Language: language, QuestionType: questionType}); err == nil {
+			questions = append(questions, question)
+		}
+	}
+	return questions
+}
+
+func TestMockProviderGenerate(t *testing.T) {
+	req := AIRequest{Prompt: "出3道Go单选题", QuestionType: model.QuestionTypeSingle, NumQuestions: 3, Language: "Go"}
+	generate := func(providerConfig config.AIProviderConfig) string {
+		t.Helper()
+		provider, err := NewMockProvider(providerConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := provider.Generate(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+
+	first := generate(config.AIProviderConfig{Name: "mock", Seed: 1})
+	if first != generate(config.AIProviderConfig{Name: "mock", Seed: 1}) {
+		t.Fatal("相同种子和请求应生成相同的题目")
+	}
+	if first == generate(config.AIProviderConfig{Name: "mock", Seed: 2}) {
+		t.Fatal("不同种子应生成不同的题目")
+	}
+
+	if questions := parseMockOutput(t, first, req.Language, req.QuestionType); len(questions) != req.NumQuestions {
+		t.Fatalf("解析出 %d 道题目，应为 %d", len(questions), req.NumQuestions)
+	}
+
+	// 判断题的每种缺陷都会被校验拦截
+	req.QuestionType = model.QuestionTypeJudge
+	if questions := parseMockOutput(t, generate(config.AIProviderConfig{Name: "mock", Seed: 1}), req.Language, req.QuestionType); len(questions) != req.NumQuestions {
+		t.Fatalf("解析出 %d 道判断题，应为 %d", len(questions), req.NumQuestions)
+	}
+	if questions := parseMockOutput(t, generate(config.AIProviderConfig{Name: "mock", Seed: 1, MalformedRate: 1}), req.Language, req.QuestionType); len(questions) != 0 {
+		t.Fatalf("不合格比例为1时仍有 %d 道题目通过校验", len(questions))
+	}
+}
+
+func TestMockProviderScript(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.json")
+	if err := os.WriteFile(script, []byte(`["第一次", "第二次"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewMockProvider(config.AIProviderConfig{Name: "mock", ScriptFile: script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"第一次", "第二次", "第一次"} {
+		if content, _ := provider.Generate(context.Background(), AIRequest{}); content != want {
+			t.Fatalf("content = %q，应为 %q", content, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"examsystem/config"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OllamaProvider Ollama 风格的 /api/generate 接口，用于本地部署的模型
+type OllamaProvider struct {
+	config config.AIProviderConfig
+	client *http.Client
+}
+
+// NewOllamaProvider 创建 Ollama 接口的AI模型
+func NewOllamaProvider(providerConfig config.AIProviderConfig) *OllamaProvider {
+	return &OllamaProvider{
+		config: providerConfig,
+		client: newAIHTTPClient(time.Duration(providerConfig.TimeoutSeconds) * time.Second),
+	}
+}
+
+// Name 模型名称
+func (p *OllamaProvider) Name() string {
+	return p.config.Name
+}
+
+// Model 请求中使用的模型
+func (p *OllamaProvider) Model() string {
+	return p.config.Model
+}
+
+// Capabilities 模型能力
+func (p *OllamaProvider) Capabilities() AICapabilities {
+	return AICapabilities{Streaming: true, MaxTokens: p.config.MaxTokens}
+}
+
+// Generate 调用 /api/generate 接口生成文本，要求模型输出JSON
+func (p *OllamaProvider) Generate(ctx context.Context, req AIRequest) (string, error) {
+	// 非流式请求整体受超时限制，流式请求只限制等待响应头的时间
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.config.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	resp, err := p.send(ctx, req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var response ollamaResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("Ollama 响应格式错误: %v, 响应内容: %s", err, string(body))
+	}
+	if response.Error != "" {
+		return "", fmt.Errorf("Ollama 返回错误: %s", response.Error)
+	}
+	if response.Response == "" {
+		return "", fmt.Errorf("Ollama 返回空结果")
+	}
+	return response.Response, nil
+}
+
+// GenerateStream 以 stream 模式调用 /api/generate 接口，响应为每行一个JSON对象
+func (p *OllamaProvider) GenerateStream(ctx context.Context, req AIRequest, onChunk func(chunk string)) (string, error) {
+	resp, err := p.send(ctx, req, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var response ollamaResponse
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			return "", fmt.Errorf("Ollama 流式响应格式错误: %v, 响应内容: %s", err, line)
+		}
+		if response.Error != "" {
+			return "", fmt.Errorf("Ollama 返回错误: %s", response.Error)
+		}
+		if response.Response != "" {
+			content.WriteString(response.Response)
+			onChunk(response.Response)
+		}
+		if response.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if content.Len() == 0 {
+		return "", fmt.Errorf("Ollama 返回空结果")
+	}
+	return content.String(), nil
+}
+
+// ollamaResponse /api/generate 接口的响应，流式模式下每行一个
+type ollamaResponse struct {
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error"`
+}
+
+// send 发送请求，返回状态码为200的响应，调用方负责关闭响应体
+func (p *OllamaProvider) send(ctx context.Context, req AIRequest, stream bool) (*http.Response, error) {
+	options := map[string]interface{}{
+		"num_predict": p.config.MaxTokens,
+	}
+	if p.config.Temperature != nil {
+		options["temperature"] = *p.config.Temperature
+	}
+	payload := map[string]interface{}{
+		"model":   p.config.Model,
+		"prompt":  req.Prompt,
+		"stream":  stream,
+		"format":  "json",
+		"options": options,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.URL, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.config.APIKey))
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &AIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return resp, nil
+}
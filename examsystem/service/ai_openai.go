@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"examsystem/config"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider OpenAI 兼容的 /chat/completions 接口，DeepSeek、通义千问以及 vLLM 等本地部署服务均使用此格式
+type OpenAIProvider struct {
+	config config.AIProviderConfig
+	client *http.Client
+}
+
+// NewOpenAIProvider 创建 OpenAI 兼容接口的AI模型
+func NewOpenAIProvider(providerConfig config.AIProviderConfig) *OpenAIProvider {
+	return &OpenAIProvider{
+		config: providerConfig,
+		client: newAIHTTPClient(time.Duration(providerConfig.TimeoutSeconds) * time.Second),
+	}
+}
+
+// Name 模型名称
+func (p *OpenAIProvider) Name() string {
+	return p.config.Name
+}
+
+// Model 请求中使用的模型
+func (p *OpenAIProvider) Model() string {
+	return p.config.Model
+}
+
+// Capabilities 模型能力
+func (p *OpenAIProvider) Capabilities() AICapabilities {
+	return AICapabilities{Streaming: true, MaxTokens: p.config.MaxTokens}
+}
+
+// Generate 调用 /chat/completions 接口生成文本
+func (p *OpenAIProvider) Generate(ctx context.Context, req AIRequest) (string, error) {
+	// 非流式请求整体受超时限制，流式请求只限制等待响应头的时间
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.config.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	resp, err := p.send(ctx, req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("AI API 响应格式错误: %v, 响应内容: %s", err, string(body))
+	}
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("AI API 返回空结果")
+	}
+	return response.Choices[0].Message.Content, nil
+}
+
+// GenerateStream 以 stream 模式调用 /chat/completions 接口，逐段读取服务端推送的 data 事件
+func (p *OpenAIProvider) GenerateStream(ctx context.Context, req AIRequest, onChunk func(chunk string)) (string, error) {
+	resp, err := p.send(ctx, req, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var event struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return "", fmt.Errorf("AI API 流式响应格式错误: %v, 响应内容: %s", err, data)
+		}
+		if len(event.Choices) == 0 || event.Choices[0].Delta.Content == "" {
+			continue
+		}
+		content.WriteString(event.Choices[0].Delta.Content)
+		onChunk(event.Choices[0].Delta.Content)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if content.Len() == 0 {
+		return "", fmt.Errorf("AI API 返回空结果")
+	}
+	return content.String(), nil
+}
+
+// send 发送请求，返回状态码为200的响应，调用方负责关闭响应体
+func (p *OpenAIProvider) send(ctx context.Context, req AIRequest, stream bool) (*http.Response, error) {
+	payload := map[string]interface{}{
+		"model": p.config.Model,
+		"messages": []map[string]string{
+			{
+				"role":    "user",
+				"content": req.Prompt,
+			},
+		},
+		"max_tokens": p.config.MaxTokens,
+		"stream":     stream,
+	}
+	if p.config.Temperature != nil {
+		payload["temperature"] = *p.config.Temperature
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.URL, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	// 本地部署的服务通常不需要密钥
+	if p.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.config.APIKey))
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &AIStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return resp, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"examsystem/config"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestOpenAIProvider 创建指向测试服务器、超时时间为1秒的模型
+func newTestOpenAIProvider(url string) *OpenAIProvider {
+	return NewOpenAIProvider(config.AIProviderConfig{Name: "test", URL: url, Model: "test", TimeoutSeconds: 1})
+}
+
+func TestOpenAIStreamOutlivesTimeout(t *testing.T) {
+	chunks := []string{"第一段", "第二段", "第三段"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", chunk)
+			w.(http.Flusher).Flush()
+			time.Sleep(600 * time.Millisecond)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	var received int
+	content, err := newTestOpenAIProvider(server.URL).GenerateStream(context.Background(), AIRequest{Prompt: "test"}, func(string) { received++ })
+	if err != nil {
+		t.Fatalf("流式输出总时长超过超时时间时不应中断: %v", err)
+	}
+	if content != "第一段第二段第三段" || received != len(chunks) {
+		t.Fatalf("content = %q, received = %d", content, received)
+	}
+}
+
+func TestOpenAITimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	provider := newTestOpenAIProvider(server.URL)
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"等待响应头超时", func() error {
+			_, err := provider.GenerateStream(context.Background(), AIRequest{Prompt: "test"}, func(string) {})
+			return err
+		}},
+		{"非流式请求超时", func() error {
+			_, err := provider.Generate(context.Background(), AIRequest{Prompt: "test"})
+			return err
+		}},
+		{"调用方取消", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			_, err := provider.GenerateStream(ctx, AIRequest{Prompt: "test"}, func(string) {})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			if err := tt.call(); err == nil {
+				t.Fatal("应该返回超时错误")
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Fatalf("请求耗时 %v，超时没有生效", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"examsystem/dao/model"
+	"fmt"
+	"strings"
+)
+
+// QuestionRejection AI返回的题目未被采用的原因
+type QuestionRejection struct {
+	Chunk  int    `json:"chunk"`           // 所在批次，从1开始
+	Index  int    `json:"index"`           // 在该批次输出中的序号，从1开始
+	Title  string `json:"title"`           // 题目内容，无法识别时为空
+	Field  string `json:"field,omitempty"` // 未通过校验的字段
+	Reason string `json:"reason"`
+}
+
+// aiFieldError 题目某个字段未通过校验
+type aiFieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *aiFieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// JSON值的类型
+type jsonKind string
+
+const (
+	jsonString  jsonKind = "字符串"
+	jsonBoolean jsonKind = "布尔值"
+	jsonNumber  jsonKind = "数字"
+	jsonArray   jsonKind = "数组"
+	jsonObject  jsonKind = "对象"
+	jsonNull    jsonKind = "null"
+)
+
+// aiFieldSchema AI返回的题目中一个字段的结构定义
+type aiFieldSchema struct {
+	Name     string
+	Required bool
+	NonEmpty bool            // 字符串不能为空白，数组不能为空
+	Kinds    []jsonKind      // 允许的类型
+	Items    jsonKind        // 数组元素的类型，为空时不检查
+	Fields   []aiFieldSchema // 元素为对象时各字段的结构定义
+}
+
+var (
+	aiTitleField       = aiFieldSchema{Name: "title", Required: true, NonEmpty: true, Kinds: []jsonKind{jsonString}}
+	aiExplanationField = aiFieldSchema{Name: "explanation", Kinds: []jsonKind{jsonString}}
+	aiChoiceFields     = []aiFieldSchema{
+		aiTitleField,
+		{Name: "options", Required: true, NonEmpty: true, Kinds: []jsonKind{jsonArray}, Items: jsonString},
+		{Name: "answer", Required: true, NonEmpty: true, Kinds: []jsonKind{jsonString, jsonArray}, Items: jsonString},
+		aiExplanationField,
+	}
+)
+
+// aiQuestionSchemas 各题型AI返回题目的结构定义
+var aiQuestionSchemas = map[model.QuestionType][]aiFieldSchema{
+	model.QuestionTypeSingle:   aiChoiceFields,
+	model.QuestionTypeMultiple: aiChoiceFields,
+	model.QuestionTypeJudge: {
+		aiTitleField,
+		{Name: "answer", Required: true, NonEmpty: true, Kinds: []jsonKind{jsonBoolean, jsonString}},
+		aiExplanationField,
+	},
+	model.QuestionTypeBlank: {
+		aiTitleField,
+		{Name: "answer", Required: true, NonEmpty: true, Kinds: []jsonKind{jsonArray, jsonString}},
+		aiExplanationField,
+	},
+	model.QuestionTypeShortAnswer: {
+		aiTitleField,
+		{Name: "answer", Kinds: []jsonKind{jsonString}},
+		aiExplanationField,
+	},
+	model.QuestionTypeCoding: {
+		aiTitleField,
+		{Name: "code_template", Kinds: []jsonKind{jsonString}},
+		{Name: "answer", Kinds: []jsonKind{jsonString}},
+		{Name: "test_cases", Required: true, NonEmpty: true, Kinds: []jsonKind{jsonArray}, Items: jsonObject, Fields: []aiFieldSchema{
+			{Name: "input", Kinds: []jsonKind{jsonString}},
+			{Name: "expected_output", Required: true, Kinds: []jsonKind{jsonString}},
+		}},
+		aiExplanationField,
+	},
+}
+
+// checkAIQuestionSchema 按题型的结构定义检查AI返回的单道题目
+func checkAIQuestionSchema(raw json.RawMessage, questionType model.QuestionType) error {
+	return checkJSONObject(raw, aiQuestionSchemas[questionType], "")
+}
+
+// checkJSONObject 检查JSON对象的各字段，prefix 为错误信息中字段名的前缀
+func checkJSONObject(raw json.RawMessage, fields []aiFieldSchema, prefix string) error {
+	var object map[string]json.RawMessage
+	if kindOf(raw) != jsonObject || json.Unmarshal(raw, &object) != nil {
+		return &aiFieldError{Field: strings.TrimSuffix(prefix, "."), Reason: "应为JSON对象"}
+	}
+
+	for _, field := range fields {
+		name := prefix + field.Name
+		value, ok := object[field.Name]
+		if !ok || kindOf(value) == jsonNull {
+			if field.Required {
+				return &aiFieldError{Field: name, Reason: "缺少该字段"}
+			}
+			continue
+		}
+
+		kind := kindOf(value)
+		if !containsKind(field.Kinds, kind) {
+			return &aiFieldError{Field: name, Reason: fmt.Sprintf("类型应为%s，实际为%s", joinKinds(field.Kinds), kind)}
+		}
+
+		switch kind {
+		case jsonString:
+			var text string
+			json.Unmarshal(value, &text)
+			if field.NonEmpty && strings.TrimSpace(text) == "" {
+				return &aiFieldError{Field: name, Reason: "不能为空"}
+			}
+		case jsonArray:
+			var items []json.RawMessage
+			json.Unmarshal(value, &items)
+			if field.NonEmpty && len(items) == 0 {
+				return &aiFieldError{Field: name, Reason: "不能为空"}
+			}
+			for i, item := range items {
+				itemName := fmt.Sprintf("%s[%d]", name, i)
+				if field.Items != "" && kindOf(item) != field.Items {
+					return &aiFieldError{Field: itemName, Reason: fmt.Sprintf("类型应为%s，实际为%s", field.Items, kindOf(item))}
+				}
+				if field.Items == jsonObject {
+					if err := checkJSONObject(item, field.Fields, itemName+"."); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// kindOf 根据JSON值的首个字符判断类型
+func kindOf(raw json.RawMessage) jsonKind {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return jsonNull
+	}
+	switch trimmed[0] {
+	case '"':
+		return jsonString
+	case 't', 'f':
+		return jsonBoolean
+	case '[':
+		return jsonArray
+	case '{':
+		return jsonObject
+	case 'n':
+		return jsonNull
+	}
+	return jsonNumber
+}
+
+func containsKind(kinds []jsonKind, kind jsonKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func joinKinds(kinds []jsonKind) string {
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = string(k)
+	}
+	return strings.Join(names, "或")
+}
+
+// extractAIQuestions 从AI返回的内容中提取题目数组中的各个元素
+// 跳过JSON前后的说明文字和 Markdown 代码块标记、修复常见的格式问题；
+// 输出被截断时保留已经完整的题目，并通过 warning 说明
+func extractAIQuestions(content string) (items []json.RawMessage, warning string, err error) {
+	return extractAIArray(content, "questions")
+}
+
+// extractAIArray 从AI返回的内容中提取 key 字段对应数组中的各个元素，内容也可以直接是数组
+func extractAIArray(content, key string) (items []json.RawMessage, warning string, err error) {
+	// 优先从 key 字段所在的对象开始，避免说明文字中的括号被当作JSON
+	start := -1
+	if pos := strings.Index(content, `"`+key+`"`); pos >= 0 {
+		start = strings.LastIndexByte(content[:pos], '{')
+	}
+	if start < 0 {
+		start = strings.IndexAny(content, "{[")
+	}
+	if start < 0 {
+		return nil, "", fmt.Errorf("AI返回的内容中没有JSON")
+	}
+	text := content[start:]
+
+	end, complete := matchJSONEnd(text)
+	if complete {
+		text = text[:end]
+	}
+	text = repairAIJSON(text)
+
+	if complete {
+		var array []json.RawMessage
+		if text[0] == '[' {
+			err = json.Unmarshal([]byte(text), &array)
+		} else {
+			var wrapped map[string]json.RawMessage
+			if err = json.Unmarshal([]byte(text), &wrapped); err == nil && wrapped[key] != nil {
+				err = json.Unmarshal(wrapped[key], &array)
+			}
+		}
+		if err == nil {
+			if array == nil {
+				return nil, "", fmt.Errorf("AI返回的内容中没有 %s 数组", key)
+			}
+			return array, "", nil
+		}
+	}
+
+	// 输出被截断或无法整体解析时，逐个取出已经完整的题目
+	if text[0] == '[' {
+		text = `{"` + key + `":` + text
+	}
+	parser := &questionStreamParser{key: key}
+	items = parser.Write(text)
+	if len(items) == 0 {
+		if err == nil {
+			err = fmt.Errorf("输出不完整")
+		}
+		return nil, "", fmt.Errorf("AI返回的内容不是有效的JSON: %v", err)
+	}
+	if !complete || !parser.done {
+		return items, fmt.Sprintf("输出不完整，已保留%d道完整的题目", len(items)), nil
+	}
+	return items, fmt.Sprintf("JSON格式有误，已逐题解析出%d道题目", len(items)), nil
+}
+
+// matchJSONEnd 找到从开头的括号开始的JSON值的结束位置，括号没有闭合时返回 false
+func matchJSONEnd(text string) (int, bool) {
+	depth, inString, escaped := 0, false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return len(text), false
+}
+
+// repairAIJSON 修复模型输出中常见的JSON格式问题：
+// 去掉对象和数组末尾多余的逗号、去掉 // 和 /* */ 注释、转义字符串中未转义的换行符和制表符
+func repairAIJSON(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	inString, escaped := false, false
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			case c == '\n':
+				b.WriteString(`\n`)
+				continue
+			case c == '\r':
+				b.WriteString(`\r`)
+				continue
+			case c == '\t':
+				b.WriteString(`\t`)
+				continue
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(text) && text[i+1] == '*':
+			if end := strings.Index(text[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(text)
+			}
+			continue
+		case c == ',':
+			// 逗号后面（忽略空白）紧跟右括号时去掉逗号
+			j := i + 1
+			for j < len(text) && strings.IndexByte(" \t\r\n", text[j]) >= 0 {
+				j++
+			}
+			if j < len(text) && (text[j] == '}' || text[j] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
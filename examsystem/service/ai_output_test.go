@@ -0,0 +1,118 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRepairAIJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		invalid bool // 修复后仍不是有效的JSON，由调用方按截断处理
+	}{
+		{"合法的JSON不变", `{"a": [1, 2], "b": "x"}`, `{"a": [1, 2], "b": "x"}`, false},
+		{"对象末尾多余的逗号", `{"a": 1, }`, `{"a": 1 }`, false},
+		{"数组末尾多余的逗号", "[1, 2,\n]", "[1, 2\n]", false},
+		{"行注释", "{\"a\": 1 // 说明\n}", "{\"a\": 1 }", false},
+		{"块注释", `{"a": /* 说明 */ 1}`, `{"a":  1}`, false},
+		{"未闭合的块注释", `{"a": 1 /* 说明`, `{"a": 1 `, true},
+		{"字符串中的换行和制表符", "{\"a\": \"第一行\n\t第二行\"}", `{"a": "第一行\n\t第二行"}`, false},
+		{"字符串中的注释符号和逗号不处理", `{"url": "http://a.com/*x*/", "s": "a,]"}`, `{"url": "http://a.com/*x*/", "s": "a,]"}`, false},
+		{"字符串中转义的引号", `{"a": "say \"hi\", }", }`, `{"a": "say \"hi\", }" }`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repairAIJSON(tt.input)
+			if got != tt.want {
+				t.Fatalf("repairAIJSON(%q) = %q，应为 %q", tt.input, got, tt.want)
+			}
+			if json.Valid([]byte(got)) == tt.invalid {
+				t.Fatalf("修复后仍不是有效的JSON: %q", got)
+			}
+		})
+	}
+}
+
+func TestExtractAIQuestions(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantTitles  []string // 为空字符串的题目格式错误，逐题解析时会被拒绝
+		wantWarning bool
+		wantErr     bool
+	}{
+		{
+			name:       "题目数组",
+			content:    `[{"title": "a"}, {"title": "b"}]`,
+			wantTitles: []string{"a", "b"},
+		},
+		{
+			name:       "questions 对象",
+			content:    `{"questions": [{"title": "a"}]}`,
+			wantTitles: []string{"a"},
+		},
+		{
+			name:       "Markdown 代码块和说明文字",
+			content:    "以下是生成的题目（共1道）：\n```json\n{\"questions\": [{\"title\": \"a\"}]}\n```\n希望对你有帮助 {^_^}",
+			wantTitles: []string{"a"},
+		},
+		{
+			name:       "多余的逗号和注释",
+			content:    "{\"questions\": [\n  {\"title\": \"a\",}, // 第一题\n  {\"title\": \"b\"},\n]}",
+			wantTitles: []string{"a", "b"},
+		},
+		{
+			name:        "输出被截断",
+			content:     `{"questions": [{"title": "a"}, {"title": "b"}, {"title": "c`,
+			wantTitles:  []string{"a", "b"},
+			wantWarning: true,
+		},
+		{
+			name:        "个别题目格式错误",
+			content:     `{"questions": [{"title": "a"}, {"title": "b" "x"}, {"title": "c"}]}`,
+			wantTitles:  []string{"a", "", "c"},
+			wantWarning: true,
+		},
+		{
+			name:    "没有JSON",
+			content: "抱歉，我无法生成这些题目。",
+			wantErr: true,
+		},
+		{
+			name:    "没有 questions 数组",
+			content: `{"items": [{"title": "a"}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, warning, err := extractAIQuestions(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("应返回错误，实际提取出 %d 道题目", len(items))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (warning != "") != tt.wantWarning {
+				t.Fatalf("warning = %q", warning)
+			}
+			if len(items) != len(tt.wantTitles) {
+				t.Fatalf("提取出 %d 道题目，应为 %d 道", len(items), len(tt.wantTitles))
+			}
+			for i, raw := range items {
+				var item struct {
+					Title string `json:"title"`
+				}
+				err := json.Unmarshal([]byte(repairAIJSON(string(raw))), &item)
+				if (err != nil) != (tt.wantTitles[i] == "") || item.Title != tt.wantTitles[i] {
+					t.Fatalf("第%d道题目为 %s，应为 %q", i+1, raw, tt.wantTitles[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"examsystem/config"
+	"examsystem/dao/model"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var ErrUnknownAIModel = errors.New("未知的AI模型")
+
+// AICapabilities AI模型的能力说明
+type AICapabilities struct {
+	Streaming bool // 是否支持流式输出
+	MaxTokens int  // 单次请求最大生成token数
+}
+
+// AIRequest 题目生成或审核请求：Prompt 为发送给模型的提示语，其余字段供不解析提示语的实现（如模拟模型）使用
+type AIRequest struct {
+	Prompt         string
+	QuestionType   model.QuestionType
+	NumQuestions   int
+	Language       string
+	Keywords       string
+	Offset         int                  // 分批生成时本批第一道题目在全部题目中的序号，从0开始
+	Difficulty     int                  // 目标难度，0表示不指定
+	CognitiveLevel model.CognitiveLevel // 目标认知层次，为空表示不指定
+	Review         []*model.Question    // 待审核的题目，不为空时为审核请求
+}
+
+// AIProvider AI模型提供方，每个可选的 ai_model 对应一个实现
+type AIProvider interface {
+	// Name 生成题目时传入的 ai_model 取值
+	Name() string
+	// Model 请求中实际使用的模型名称
+	Model() string
+	// Capabilities 模型能力
+	Capabilities() AICapabilities
+	// Generate 发送提示语并返回模型生成的文本，只请求一次，重试由调用方负责
+	Generate(ctx context.Context, req AIRequest) (string, error)
+}
+
+// AIStreamProvider 支持流式输出的AI模型，Capabilities().Streaming 为 true 的模型实现此接口
+type AIStreamProvider interface {
+	AIProvider
+	// GenerateStream 发送提示语，每收到一段输出调用一次 onChunk，返回模型生成的完整文本
+	GenerateStream(ctx context.Context, req AIRequest, onChunk func(chunk string)) (string, error)
+}
+
+// AIStatusError AI接口返回的非成功状态码
+type AIStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *AIStatusError) Error() string {
+	return fmt.Sprintf("AI API 错误: %d", e.StatusCode)
+}
+
+// 可以重试的AI接口状态码
+var retryableAIStatusCodes = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// isRetryableAIError 判断AI调用错误是否可以重试：网络错误、限流和服务端错误可以重试
+func isRetryableAIError(err error) bool {
+	var statusErr *AIStatusError
+	if errors.As(err, &statusErr) {
+		return retryableAIStatusCodes[statusErr.StatusCode]
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// aiConnectTimeout 连接AI接口（含TLS握手）的超时时间
+const aiConnectTimeout = 10 * time.Second
+
+// newAIHTTPClient 创建调用AI接口的HTTP客户端。不设置 Client.Timeout，否则流式响应读到一半会被截断；
+// timeout 只限制连接和等待响应头，读取响应体由请求的 context 控制
+func newAIHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: aiConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = aiConnectTimeout
+	transport.ResponseHeaderTimeout = timeout
+	return &http.Client{Transport: transport}
+}
+
+// AIProviderRegistry AI模型注册表，按 ai_model 取值查找提供方
+type AIProviderRegistry struct {
+	providers map[string]AIProvider
+}
+
+// NewAIProviderRegistry 根据配置创建AI模型注册表，配置有误的模型会被跳过并记录日志
+func NewAIProviderRegistry(aiConfig config.AIConfig) *AIProviderRegistry {
+	r := &AIProviderRegistry{providers: make(map[string]AIProvider)}
+	for _, providerConfig := range aiConfig.Providers {
+		provider, err := newAIProvider(providerConfig)
+		if err != nil {
+			log.Printf("警告: AI模型 %q 配置无效，已跳过: %v", providerConfig.Name, err)
+			continue
+		}
+		r.Register(provider)
+	}
+	return r
+}
+
+// newAIProvider 按接口类型创建AI模型提供方
+func newAIProvider(providerConfig config.AIProviderConfig) (AIProvider, error) {
+	if providerConfig.Name == "" {
+		return nil, fmt.Errorf("缺少名称")
+	}
+
+	switch providerConfig.Type {
+	case config.AIProviderTypeOpenAI:
+		if providerConfig.URL == "" {
+			return nil, fmt.Errorf("缺少接口地址")
+		}
+		return NewOpenAIProvider(providerConfig), nil
+	case config.AIProviderTypeOllama:
+		if providerConfig.URL == "" {
+			return nil, fmt.Errorf("缺少接口地址")
+		}
+		if providerConfig.Model == "" {
+			return nil, fmt.Errorf("缺少模型名称")
+		}
+		return NewOllamaProvider(providerConfig), nil
+	case config.AIProviderTypeMock:
+		return NewMockProvider(providerConfig)
+	default:
+		return nil, fmt.Errorf("不支持的接口类型: %s", providerConfig.Type)
+	}
+}
+
+// Register 注册AI模型，已存在的同名模型会被替换
+func (r *AIProviderRegistry) Register(provider AIProvider) {
+	r.providers[provider.Name()] = provider
+}
+
+// Get 根据 ai_model 取值获取AI模型
+func (r *AIProviderRegistry) Get(name string) (AIProvider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q，可用的模型: %v", ErrUnknownAIModel, name, r.Names())
+	}
+	return provider, nil
+}
+
+// Providers 获取全部AI模型，按名称排序
+func (r *AIProviderRegistry) Providers() []AIProvider {
+	providers := make([]AIProvider, 0, len(r.providers))
+	for _, provider := range r.providers {
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name() < providers[j].Name()
+	})
+	return providers
+}
+
+// Names 获取全部AI模型名称，按名称排序
+func (r *AIProviderRegistry) Names() []string {
+	providers := r.Providers()
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
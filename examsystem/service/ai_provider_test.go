@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"examsystem/config"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAIProviderRegistry(t *testing.T) {
+	t.Setenv("DEEPSEEK_API_URL", "")
+	t.Setenv("TONGYI_API_URL", "")
+	t.Setenv("LOCAL_LLM_API_URL", "")
+	t.Setenv("OLLAMA_API_URL", "")
+	t.Setenv("AI_MOCK_ENABLED", "")
+	t.Setenv("LOCAL_API_KEY", "secret")
+	t.Setenv("AI_PROVIDERS", `[
+		{"name": "local", "url": "http://localhost/v1/chat/completions", "api_key_env": "LOCAL_API_KEY", "temperature": 0},
+		{"name": "default", "url": "http://localhost/v1/chat/completions"},
+		{"name": "no-url"},
+		{"name": "unknown-type", "type": "grpc", "url": "http://localhost"}
+	]`)
+	aiConfig := config.LoadAIConfig()
+
+	providers := make(map[string]config.AIProviderConfig)
+	for _, provider := range aiConfig.Providers {
+		providers[provider.Name] = provider
+	}
+	if local := providers["local"]; local.APIKey != "secret" || local.Temperature == nil || *local.Temperature != 0 {
+		t.Errorf("local = %+v，应从环境变量读取密钥并保留温度0", local)
+	}
+	if def := providers["default"]; def.Temperature == nil || *def.Temperature != 0.7 || def.MaxTokens != 2000 || def.TimeoutSeconds != 30 {
+		t.Errorf("default = %+v，应使用默认参数", def)
+	}
+
+	registry := NewAIProviderRegistry(aiConfig)
+	if names := registry.Names(); !reflect.DeepEqual(names, []string{"default", "local"}) {
+		t.Fatalf("可用的模型为 %v，配置无效的模型应被跳过", names)
+	}
+	if _, err := registry.Get("no-url"); !errors.Is(err, ErrUnknownAIModel) {
+		t.Fatalf("err = %v，应为 %v", err, ErrUnknownAIModel)
+	}
+}
+
+func TestOpenAIProviderGenerate(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Write([]byte(`{"choices":[{"message":{"content":"生成的题目"}}]}`))
+	}))
+	defer server.Close()
+
+	temperature := 0.0
+	provider := NewOpenAIProvider(config.AIProviderConfig{Name: "local", URL: server.URL, APIKey: "secret", Model: "qwen", MaxTokens: 100, Temperature: &temperature, TimeoutSeconds: 1})
+	content, err := provider.Generate(context.Background(), AIRequest{Prompt: "出一道题"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "生成的题目" {
+		t.Fatalf("content = %q", content)
+	}
+	if payload["model"] != "qwen" || payload["temperature"] != 0.0 || payload["max_tokens"] != 100.0 {
+		t.Fatalf("请求参数为 %v", payload)
+	}
+
+	provider = NewOpenAIProvider(config.AIProviderConfig{Name: "local", URL: server.URL, TimeoutSeconds: 1})
+	var statusErr *AIStatusError
+	if _, err := provider.Generate(context.Background(), AIRequest{Prompt: "出一道题"}); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized || isRetryableAIError(err) {
+		t.Fatalf("密钥错误时 err = %v，应为不可重试的状态码错误", err)
+	}
+}
+
+func TestOllamaProviderGenerate(t *testing.T) {
+	var payload struct {
+		Model   string                 `json:"model"`
+		Format  string                 `json:"format"`
+		Stream  bool                   `json:"stream"`
+		Options map[string]interface{} `json:"options"`
+	}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&payload)
+		if payload.Model != "qwen2.5" {
+			w.Write([]byte(`{"error":"model not found"}`))
+			return
+		}
+		w.Write([]byte(`{"response":"[]","done":true}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("OLLAMA_MODEL", "")
+	t.Setenv("AI_PROVIDERS", "")
+	registry := NewAIProviderRegistry(config.LoadAIConfig())
+	provider, err := registry.Get("ollama")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := provider.Generate(context.Background(), AIRequest{Prompt: "出一道题"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "[]" || authorization != "" {
+		t.Fatalf("content = %q, Authorization = %q", content, authorization)
+	}
+	if payload.Format != "json" || payload.Stream || payload.Options["temperature"] != 0.7 || payload.Options["num_predict"] != 2000.0 {
+		t.Fatalf("请求参数为 %+v", payload)
+	}
+
+	provider = NewOllamaProvider(config.AIProviderConfig{Name: "ollama", URL: server.URL, Model: "llama3", TimeoutSeconds: 1})
+	if _, err := provider.Generate(context.Background(), AIRequest{Prompt: "出一道题"}); err == nil {
+		t.Fatal("模型不存在时应返回错误")
+	}
+}
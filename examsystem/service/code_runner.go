@@ -0,0 +1,475 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"examsystem/config"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 测试用例运行状态
+const (
+	TestStatusPassed       = "passed"
+	TestStatusWrongAnswer  = "wrong_answer"
+	TestStatusTimeLimit    = "time_limit_exceeded"
+	TestStatusOutputLimit  = "output_limit_exceeded"
+	TestStatusRuntimeError = "runtime_error"
+	TestStatusCompileError = "compile_error"
+)
+
+// 错误信息最多保留的字符数，避免评分明细过大
+const testMessageLimit = 500
+
+// 编译时的进程（含线程）数上限，编译器会启动多个进程和线程
+const compileProcessLimit = 512
+
+// 沙箱内的工作目录和 Go 构建缓存目录
+const (
+	sandboxWorkDir    = "/work"
+	sandboxGoCacheDir = "/gocache"
+)
+
+// goCacheWarmupSource 预热 Go 构建缓存的程序，引用考生代码常用的标准库
+const goCacheWarmupSource = `package main
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"container/list"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var _ = []interface{}{bufio.NewReader, bytes.NewBuffer, heap.Init, list.New, errors.New, fmt.Println, maps.Keys[map[int]int],
+	math.Abs, big.NewInt, os.Exit, regexp.MustCompile, slices.Sort[[]int], sort.Ints, strconv.Itoa, strings.Fields, time.Now, unicode.IsDigit}
+
+func main() {}
+`
+
+// CodeTestCase 编程题测试用例：以 Input 作为标准输入运行程序，标准输出需与 ExpectedOutput 一致
+type CodeTestCase struct {
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+}
+
+// TestCaseResult 单个测试用例的运行结果，测试用例对考生隐藏，因此不包含输入和期望输出
+type TestCaseResult struct {
+	Index   int    `json:"index"` // 测试用例序号，从1开始
+	Status  string `json:"status"`
+	TimeMs  int64  `json:"time_ms"`
+	Message string `json:"message,omitempty"` // 编译错误或运行错误信息
+}
+
+// codeLanguage 编程语言的编译和运行命令，命令在代码所在的临时目录中执行
+type codeLanguage struct {
+	SourceFile string
+	Compile    []string // 解释型语言为空
+	Run        []string
+	GoCache    bool // 编译时需要 Go 构建缓存
+}
+
+// codeLanguages 代码运行器支持的语言
+var codeLanguages = map[string]codeLanguage{
+	"python":     {SourceFile: "main.py", Run: []string{"python3", "main.py"}},
+	"javascript": {SourceFile: "main.js", Run: []string{"node", "main.js"}},
+	"go":         {SourceFile: "main.go", Compile: []string{"go", "build", "-o", "main", "main.go"}, Run: []string{"./main"}, GoCache: true},
+	"c":          {SourceFile: "main.c", Compile: []string{"gcc", "-O2", "-o", "main", "main.c", "-lm"}, Run: []string{"./main"}},
+	"cpp":        {SourceFile: "main.cpp", Compile: []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"}, Run: []string{"./main"}},
+}
+
+// codeLanguageAliases 题目中常见的语言写法
+var codeLanguageAliases = map[string]string{
+	"python3": "python",
+	"py":      "python",
+	"js":      "javascript",
+	"node":    "javascript",
+	"golang":  "go",
+	"c++":     "cpp",
+}
+
+// NormalizeCodeLanguage 将题目的语言名称规范为代码运行器支持的语言标识，不支持时返回空字符串
+func NormalizeCodeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := codeLanguageAliases[language]; ok {
+		language = alias
+	}
+	if _, ok := codeLanguages[language]; !ok {
+		return ""
+	}
+	return language
+}
+
+// CodeRunner 在沙箱中编译运行考生代码，限制运行时间、内存、进程数和输出大小。
+// 沙箱只能看到只读的系统目录和本次运行的临时目录，不能访问网络，在宿主机上以单独的用户运行
+type CodeRunner struct {
+	config config.CodeRunnerConfig
+
+	// Go 构建缓存的只读下层，只由预热程序写入；每次编译的写入保存在沙箱内存中，不会影响其他考生
+	goCacheMu    sync.Mutex
+	goCacheReady bool
+}
+
+// NewCodeRunner 创建代码运行器实例
+func NewCodeRunner(runnerConfig config.CodeRunnerConfig) *CodeRunner {
+	return &CodeRunner{config: runnerConfig}
+}
+
+// RunTests 编译考生代码并依次运行全部测试用例
+// 编译失败时所有用例均记为编译错误；只有运行环境本身出错时才返回 error
+func (r *CodeRunner) RunTests(language, source string, tests []CodeTestCase) ([]TestCaseResult, error) {
+	lang, ok := codeLanguages[NormalizeCodeLanguage(language)]
+	if !ok {
+		return nil, fmt.Errorf("不支持的编程语言: %s", language)
+	}
+
+	if lang.GoCache && r.config.Sandbox {
+		if err := r.warmGoCache(); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := r.makeRunDir(lang.SourceFile, source)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	results := make([]TestCaseResult, len(tests))
+	if lang.Compile != nil {
+		out, err := r.execute(dir, lang, lang.Compile, "", r.config.CompileTimeout, true)
+		if err != nil {
+			return nil, err
+		}
+		if out.TimedOut || out.ExitErr != nil {
+			message := truncateMessage(out.Stderr + out.Stdout)
+			if out.TimedOut {
+				message = "编译超时"
+			}
+			for i := range results {
+				results[i] = TestCaseResult{Index: i + 1, Status: TestStatusCompileError, Message: message}
+			}
+			return results, nil
+		}
+	}
+
+	for i, test := range tests {
+		out, err := r.execute(dir, lang, lang.Run, test.Input, r.config.TimeLimit, false)
+		if err != nil {
+			return nil, err
+		}
+
+		result := TestCaseResult{Index: i + 1, TimeMs: out.Elapsed.Milliseconds()}
+		switch {
+		case out.OutputExceeded:
+			result.Status = TestStatusOutputLimit
+		case out.TimedOut:
+			result.Status = TestStatusTimeLimit
+		case out.ExitErr != nil:
+			result.Status = TestStatusRuntimeError
+			result.Message = truncateMessage(strings.TrimSpace(out.Stderr + "\n" + out.ExitErr.Error()))
+		case normalizeProgramOutput(out.Stdout) == normalizeProgramOutput(test.ExpectedOutput):
+			result.Status = TestStatusPassed
+		default:
+			result.Status = TestStatusWrongAnswer
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// runOutput 一次子进程执行的结果
+type runOutput struct {
+	Stdout         string
+	Stderr         string
+	Elapsed        time.Duration
+	TimedOut       bool
+	OutputExceeded bool
+	ExitErr        error // 程序非正常退出
+}
+
+// makeRunDir 创建本次运行的临时目录并写入代码：work 子目录挂载为沙箱内的工作目录，root 子目录作为沙箱根目录的挂载点
+func (r *CodeRunner) makeRunDir(sourceFile, source string) (string, error) {
+	dir, err := os.MkdirTemp(r.config.WorkDir, "examsystem-code-")
+	if err != nil {
+		return "", fmt.Errorf("创建代码目录失败: %v", err)
+	}
+	for _, sub := range []string{"work", "root"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("创建代码目录失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "work", sourceFile), []byte(source), 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("写入代码失败: %v", err)
+	}
+	if err := r.chownToSandbox(dir, filepath.Join(dir, "work"), filepath.Join(dir, "root")); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// warmGoCache 首次编译 Go 代码前在沙箱中编译预热程序，填充 Go 构建缓存的只读下层
+func (r *CodeRunner) warmGoCache() error {
+	r.goCacheMu.Lock()
+	defer r.goCacheMu.Unlock()
+	if r.goCacheReady {
+		return nil
+	}
+
+	cacheDir := r.goCacheDir()
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return fmt.Errorf("创建 Go 构建缓存目录失败: %v", err)
+	}
+	if err := r.chownToSandbox(cacheDir); err != nil {
+		return err
+	}
+	dir, err := r.makeRunDir("main.go", goCacheWarmupSource)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	spec := r.sandboxSpec(dir, codeLanguages["go"].Compile, r.compileLimits(), true)
+	spec.Binds = append(spec.Binds, sandboxMount{Source: cacheDir, Target: sandboxGoCacheDir, Writable: true})
+	out, err := r.run(spec, dir, "", 5*r.config.CompileTimeout)
+	if err != nil {
+		return err
+	}
+	if out.TimedOut || out.ExitErr != nil {
+		return fmt.Errorf("预热 Go 构建缓存失败: %s", truncateMessage(out.Stderr+out.Stdout))
+	}
+	r.goCacheReady = true
+	return nil
+}
+
+// goCacheDir Go 构建缓存只读下层在宿主机上的目录
+func (r *CodeRunner) goCacheDir() string {
+	return filepath.Join(r.config.WorkDir, "examsystem-gocache")
+}
+
+// chownToSandbox 服务以 root 运行时将目录交给沙箱用户，沙箱中的进程才能进入和写入
+func (r *CodeRunner) chownToSandbox(paths ...string) error {
+	if !r.config.Sandbox || os.Getuid() != 0 {
+		return nil
+	}
+	for _, path := range paths {
+		if err := os.Chown(path, r.config.SandboxUID, r.config.SandboxGID); err != nil {
+			return fmt.Errorf("设置代码目录权限失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// compileLimits 编译的资源限制，编译器的内存和CPU时间只受编译超时限制
+func (r *CodeRunner) compileLimits() sandboxLimits {
+	return sandboxLimits{
+		Processes:     compileProcessLimit,
+		FileSizeBytes: uint64(r.config.FileSizeLimitMB) << 20,
+	}
+}
+
+// runLimits 运行考生程序的资源限制：内存使用 RLIMIT_DATA，避免限制虚拟地址空间导致 Go、Node 等运行时无法启动
+func (r *CodeRunner) runLimits(timeout time.Duration) sandboxLimits {
+	return sandboxLimits{
+		MemoryBytes:   uint64(r.config.MemoryLimitMB) << 20,
+		CPUSeconds:    uint64(timeout/time.Second) + 1,
+		Processes:     uint64(r.config.ProcessLimit),
+		FileSizeBytes: uint64(r.config.FileSizeLimitMB) << 20,
+	}
+}
+
+// sandboxSpec 在 dir 中运行 args 的沙箱配置，只读挂载系统目录，dir/work 挂载为工作目录，只在编译时可写
+func (r *CodeRunner) sandboxSpec(dir string, args []string, limits sandboxLimits, compile bool) *sandboxSpec {
+	spec := &sandboxSpec{
+		Root:   filepath.Join(dir, "root"),
+		Dir:    sandboxWorkDir,
+		Args:   args,
+		Env:    codeEnv(sandboxGoCacheDir),
+		Limits: limits,
+	}
+	for _, path := range r.config.SandboxMounts {
+		if _, err := os.Lstat(path); err == nil {
+			spec.Binds = append(spec.Binds, sandboxMount{Source: path, Target: path})
+		}
+	}
+	// 代码目录只在编译时可写，用于写入编译结果
+	spec.Binds = append(spec.Binds, sandboxMount{Source: filepath.Join(dir, "work"), Target: sandboxWorkDir, Writable: compile})
+	return spec
+}
+
+// codeEnv 编译和运行代码的环境变量，不继承服务进程的环境变量
+func codeEnv(goCache string) []string {
+	return []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=/tmp",
+		"TMPDIR=/tmp",
+		"LANG=C.UTF-8",
+		"GOCACHE=" + goCache,
+		"GOPATH=/tmp/go",
+		"GOENV=off",
+		"GOTOOLCHAIN=local",
+		"CGO_ENABLED=0",
+	}
+}
+
+// execute 在沙箱中执行编译或运行命令；编译时工作目录可写，运行时只读
+// 返回的 error 表示沙箱无法启动，程序自身的失败记录在 runOutput 中
+func (r *CodeRunner) execute(dir string, lang codeLanguage, args []string, stdin string, timeout time.Duration, compile bool) (*runOutput, error) {
+	limits := r.runLimits(timeout)
+	if compile {
+		limits = r.compileLimits()
+	}
+	if !r.config.Sandbox {
+		return r.runDirect(dir, args, stdin, timeout, limits)
+	}
+
+	spec := r.sandboxSpec(dir, args, limits, compile)
+	if compile && lang.GoCache {
+		spec.Overlays = append(spec.Overlays, sandboxMount{Source: r.goCacheDir(), Target: sandboxGoCacheDir})
+	}
+	return r.run(spec, dir, stdin, timeout)
+}
+
+// run 启动沙箱并等待程序结束
+func (r *CodeRunner) run(spec *sandboxSpec, dir, stdin string, timeout time.Duration) (*runOutput, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		uid, gid = r.config.SandboxUID, r.config.SandboxGID
+	}
+	stdout := &limitedBuffer{limit: r.config.OutputLimitBytes}
+	stderr := &limitedBuffer{limit: r.config.OutputLimitBytes}
+	proc, err := startSandbox(ctx, spec, uid, gid, strings.NewReader(stdin), stdout, stderr)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	return collectOutput(ctx, proc.Wait(), start, stdout, stderr)
+}
+
+// runDirect 不使用沙箱，以服务进程的身份直接运行命令，只通过 shell 的 ulimit 限制资源，仅用于本地开发
+func (r *CodeRunner) runDirect(dir string, args []string, stdin string, timeout time.Duration, limits sandboxLimits) (*runOutput, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var script strings.Builder
+	for _, limit := range []struct {
+		command string
+		value   uint64
+	}{
+		{"ulimit -d %d", limits.MemoryBytes >> 10},
+		{"ulimit -t %d", limits.CPUSeconds},
+		{"{ ulimit -u %[1]d || ulimit -p %[1]d; } 2>/dev/null", limits.Processes}, // dash 使用 -p 限制进程数
+		{"ulimit -f %d", limits.FileSizeBytes >> 9},                               // 以512字节为单位
+	} {
+		if limit.value > 0 {
+			fmt.Fprintf(&script, limit.command+" && ", limit.value)
+		}
+	}
+	script.WriteString(`exec "$@"`)
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", script.String(), "sh"}, args...)...)
+	cmd.Dir = filepath.Join(dir, "work")
+	// 每次运行使用单独的 Go 构建缓存，避免考生代码修改其他考生的编译结果
+	cmd.Env = codeEnv(filepath.Join(dir, "gocache"))
+	cmd.Stdin = strings.NewReader(stdin)
+	stdout := &limitedBuffer{limit: r.config.OutputLimitBytes}
+	stderr := &limitedBuffer{limit: r.config.OutputLimitBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	configureProcessGroup(cmd)
+	// 考生程序退出后，留在后台的子进程可能一直占用输出管道
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动代码运行进程失败: %v", err)
+	}
+	err := cmd.Wait()
+	killProcessGroup(cmd)
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
+	}
+	return collectOutput(ctx, err, start, stdout, stderr)
+}
+
+// collectOutput 根据程序的退出状态整理运行结果
+func collectOutput(ctx context.Context, err error, start time.Time, stdout, stderr *limitedBuffer) (*runOutput, error) {
+	out := &runOutput{
+		Stdout:         stdout.String(),
+		Stderr:         stderr.String(),
+		Elapsed:        time.Since(start),
+		TimedOut:       errors.Is(ctx.Err(), context.DeadlineExceeded),
+		OutputExceeded: stdout.exceeded || stderr.exceeded,
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) && !out.TimedOut {
+			return nil, fmt.Errorf("运行代码失败: %v", err)
+		}
+		out.ExitErr = err
+	}
+	return out, nil
+}
+
+// limitedBuffer 最多保留 limit 字节的输出，超出部分丢弃并记录
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining < len(p) {
+		b.exceeded = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// normalizeProgramOutput 比较输出前去除每行末尾空白和末尾空行
+func normalizeProgramOutput(output string) string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// truncateMessage 截断过长的错误信息
+func truncateMessage(message string) string {
+	runes := []rune(message)
+	if len(runes) <= testMessageLimit {
+		return message
+	}
+	return string(runes[:testMessageLimit]) + "..."
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"examsystem/config"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// 代码运行沙箱以当前测试程序作为初始化进程
+	InitSandbox()
+	os.Exit(m.Run())
+}
+
+// newTestCodeRunner 创建使用沙箱的代码运行器，没有 gcc 时跳过测试
+func newTestCodeRunner(t *testing.T) *CodeRunner {
+	t.Helper()
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("需要 gcc")
+	}
+	runnerConfig := config.LoadCodeRunnerConfig()
+	runnerConfig.WorkDir = publicTempDir(t)
+	runnerConfig.Sandbox = true
+	return NewCodeRunner(runnerConfig)
+}
+
+// publicTempDir 创建所有用户都可以进入的临时目录，t.TempDir 的上级目录只有当前用户可以进入
+func publicTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "examsystem-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	if err := os.Chmod(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// runC 编译运行一段 C 代码，返回唯一测试用例的结果
+func runC(t *testing.T, runner *CodeRunner, source, input, expected string) TestCaseResult {
+	t.Helper()
+	results, err := runner.RunTests("c", source, []CodeTestCase{{Input: input, ExpectedOutput: expected}})
+	if err != nil {
+		t.Fatalf("RunTests: %v", err)
+	}
+	return results[0]
+}
+
+func TestCodeRunnerStatuses(t *testing.T) {
+	runner := newTestCodeRunner(t)
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+		status   string
+	}{
+		{"passed", `#include <stdio.h>
+int main() { int a, b; scanf("%d %d", &a, &b); printf("%d\n", a + b); return 0; }`, "3", TestStatusPassed},
+		{"wrong answer", `#include <stdio.h>
+int main() { printf("4\n"); return 0; }`, "3", TestStatusWrongAnswer},
+		{"runtime error", `int main() { return 1; }`, "3", TestStatusRuntimeError},
+		{"time limit", `int main() { for (;;) {} }`, "3", TestStatusTimeLimit},
+		{"compile error", `int main() { return }`, "3", TestStatusCompileError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runC(t, runner, tt.source, "1 2\n", tt.expected)
+			if result.Status != tt.status {
+				t.Errorf("status = %s, want %s (%s)", result.Status, tt.status, result.Message)
+			}
+		})
+	}
+}
+
+func TestCodeRunnerCannotOpenFilesOutsideWorkDir(t *testing.T) {
+	runner := newTestCodeRunner(t)
+
+	// 宿主机上所有用户都可以读写的文件，只有沙箱的文件系统隔离能阻止访问
+	secret := filepath.Join(publicTempDir(t), "examsystem.db")
+	if err := os.WriteFile(secret, []byte("hidden test cases"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(secret, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	source := fmt.Sprintf(`#include <stdio.h>
+int try_open(const char *path, const char *mode) {
+	FILE *f = fopen(path, mode);
+	if (f) { fclose(f); return 1; }
+	return 0;
+}
+int main() {
+	printf("%%d%%d%%d%%d%%d\n",
+		try_open("%s", "r"),
+		try_open("%s", "a"),
+		try_open("/usr/examsystem-sandbox-test", "w"),
+		try_open("/work/main.c", "a"),
+		try_open("/tmp/scratch", "w"));
+	return 0;
+}`, secret, secret)
+	// 依次为：读取和写入宿主机文件、写入系统目录、运行时写入工作目录都失败，/tmp 可以写入
+	result := runC(t, runner, source, "", "00001")
+	if result.Status != TestStatusPassed {
+		t.Errorf("status = %s (%s), sandbox allowed access outside its directories", result.Status, result.Message)
+	}
+	if data, err := os.ReadFile(secret); err != nil || string(data) != "hidden test cases" {
+		t.Errorf("secret file changed: %q, %v", data, err)
+	}
+	if _, err := os.Stat("/usr/examsystem-sandbox-test"); err == nil {
+		t.Error("sandbox created a file in /usr")
+	}
+}
+
+func TestCodeRunnerCannotReachNetwork(t *testing.T) {
+	runner := newTestCodeRunner(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	accepted := make(chan struct{}, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+			accepted <- struct{}{}
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	source := fmt.Sprintf(`#include <stdio.h>
+#include <string.h>
+#include <arpa/inet.h>
+#include <sys/socket.h>
+int main() {
+	struct sockaddr_in addr;
+	int fd = socket(AF_INET, SOCK_STREAM, 0);
+	memset(&addr, 0, sizeof(addr));
+	addr.sin_family = AF_INET;
+	addr.sin_port = htons(%d);
+	addr.sin_addr.s_addr = inet_addr("127.0.0.1");
+	printf("%%s\n", fd >= 0 && connect(fd, (struct sockaddr *)&addr, sizeof(addr)) == 0 ? "connected" : "blocked");
+	return 0;
+}`, port)
+	result := runC(t, runner, source, "", "blocked")
+	if result.Status != TestStatusPassed {
+		t.Errorf("status = %s (%s), sandbox reached the network", result.Status, result.Message)
+	}
+	select {
+	case <-accepted:
+		t.Error("listener accepted a connection from the sandbox")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCodeRunnerLimitsProcesses(t *testing.T) {
+	runner := newTestCodeRunner(t)
+
+	source := `#include <stdio.h>
+#include <unistd.h>
+int main() {
+	int created = 0;
+	for (int i = 0; i < 500; i++) {
+		pid_t pid = fork();
+		if (pid == 0) { pause(); _exit(0); }
+		if (pid < 0) break;
+		created++;
+	}
+	printf("%s\n", created < 500 ? "limited" : "unlimited");
+	return 0;
+}`
+	result := runC(t, runner, source, "", "limited")
+	if result.Status != TestStatusPassed {
+		t.Errorf("status = %s (%s), process limit not applied", result.Status, result.Message)
+	}
+}
+
+func TestCodeRunnerHidesServerEnvironment(t *testing.T) {
+	runner := newTestCodeRunner(t)
+	t.Setenv("JWT_SECRET_KEY", "server-secret")
+
+	source := `#include <stdio.h>
+#include <stdlib.h>
+int main() { printf("%s\n", getenv("JWT_SECRET_KEY") ? "leaked" : "hidden"); return 0; }`
+	result := runC(t, runner, source, "", "hidden")
+	if result.Status != TestStatusPassed {
+		t.Errorf("status = %s (%s), server environment leaked into the sandbox", result.Status, result.Message)
+	}
+}
+
+func TestCodeRunnerGoCacheIsReadOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("预热 Go 构建缓存较慢")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("需要 go")
+	}
+	runner := newTestCodeRunner(t)
+	if err := runner.warmGoCache(); err != nil {
+		t.Fatal(err)
+	}
+	seed := cacheEntries(t, runner.goCacheDir())
+
+	// 使用预热时没有编译过的包，编译结果只能写入本次沙箱中的缓存
+	source := `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	data, _ := json.Marshal([]int{1, 2})
+	fmt.Println(string(data))
+}`
+	results, err := runner.RunTests("go", source, []CodeTestCase{{ExpectedOutput: "[1,2]"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != TestStatusPassed {
+		t.Fatalf("status = %s (%s)", results[0].Status, results[0].Message)
+	}
+	if after := cacheEntries(t, runner.goCacheDir()); after != seed {
+		t.Errorf("submission wrote to the shared Go build cache: %d entries before, %d after", seed, after)
+	}
+}
+
+// cacheEntries 统计 Go 构建缓存中的文件数量
+func cacheEntries(t *testing.T, dir string) int {
+	t.Helper()
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestNormalizeProgramOutput(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"3\n", "3"},
+		{"1 2  \r\n3\t\n\n\n", "1 2\n3"},
+		{"  leading", "  leading"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeProgramOutput(tt.output); got != tt.want {
+			t.Errorf("normalizeProgramOutput(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeCodeLanguage(t *testing.T) {
+	for language, want := range map[string]string{
+		"Go": "go", "golang": "go", " Python3 ": "python", "C++": "cpp", "js": "javascript", "rust": "",
+	} {
+		if got := NormalizeCodeLanguage(language); got != want {
+			t.Errorf("NormalizeCodeLanguage(%q) = %q, want %q", language, got, want)
+		}
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+	long := strings.Repeat("错", testMessageLimit+10)
+	if got := truncateMessage(long); got != strings.Repeat("错", testMessageLimit)+"..." {
+		t.Errorf("truncateMessage kept %d runes", len([]rune(got)))
+	}
+	if got := truncateMessage("short"); got != "short" {
+		t.Errorf("truncateMessage(short) = %q", got)
+	}
+}
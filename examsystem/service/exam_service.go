@@ -0,0 +1,324 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExamNotFound         = errors.New("考试记录不存在")
+	ErrExamPermissionDenied = errors.New("无权访问该考试记录")
+	ErrExamNotInProgress    = errors.New("考试已提交，不能再作答")
+	ErrExamNotSubmitted     = errors.New("考试尚未提交")
+	ErrQuestionNotInExam    = errors.New("题目不在本次考试中")
+	ErrPaperHasNoQuestions  = errors.New("试卷中没有题目")
+	ErrExamNotOpen          = errors.New("考试尚未开放")
+	ErrExamClosed           = errors.New("考试已截止")
+	ErrExamTimeUp           = errors.New("考试时间已到，不能再作答")
+)
+
+// 自动提交任务每轮最多处理的考试数量
+const autoSubmitBatchSize = 100
+
+// ExamService 考试服务
+type ExamService struct {
+	examDAO        *dao.ExamDAO
+	paperDAO       *dao.PaperDAO
+	paperService   *PaperService
+	gradingService *GradingService
+}
+
+// NewExamService 创建考试服务实例
+func NewExamService(examDAO *dao.ExamDAO, paperDAO *dao.PaperDAO, paperService *PaperService, gradingService *GradingService) *ExamService {
+	return &ExamService{
+		examDAO:        examDAO,
+		paperDAO:       paperDAO,
+		paperService:   paperService,
+		gradingService: gradingService,
+	}
+}
+
+// StartExam 开始考试，如果该试卷已有进行中的考试则直接返回，便于刷新后继续作答
+// 未发布的试卷只有创建者可以参加，其他用户按试卷不存在处理
+func (s *ExamService) StartExam(userID, paperID int64) (*model.ExamSession, error) {
+	paper, err := s.paperDAO.GetPaperByID(paperID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaperNotFound
+		}
+		return nil, err
+	}
+	if !paper.Published && paper.CreatorID != userID {
+		return nil, ErrPaperNotFound
+	}
+
+	now := time.Now()
+	session, err := s.examDAO.GetInProgressSession(userID, paperID)
+	if err == nil {
+		if !isExpired(session, now) {
+			return session, nil
+		}
+		// 已超时但尚未被后台任务处理的考试，先完成提交再开始新的考试
+		if err := s.finishSession(session, *session.Deadline, true); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if paper.OpenAt != nil && now.Before(*paper.OpenAt) {
+		return nil, ErrExamNotOpen
+	}
+	if paper.CloseAt != nil && !now.Before(*paper.CloseAt) {
+		return nil, ErrExamClosed
+	}
+
+	paperQuestions, err := s.paperDAO.GetPaperQuestions(paperID)
+	if err != nil {
+		return nil, err
+	}
+	if len(paperQuestions) == 0 {
+		return nil, ErrPaperHasNoQuestions
+	}
+
+	session = &model.ExamSession{
+		PaperID:          paperID,
+		UserID:           userID,
+		Status:           model.ExamStatusInProgress,
+		StartedAt:        now,
+		Deadline:         examDeadline(paper, now),
+		ShuffleSeed:      rand.Int63(),
+		ShuffleQuestions: paper.ShuffleQuestions,
+		ShuffleOptions:   paper.ShuffleOptions,
+	}
+	if err := s.examDAO.CreateSession(session); err != nil {
+		return nil, fmt.Errorf("创建考试记录失败: %v", err)
+	}
+
+	return session, nil
+}
+
+// GetSessions 获取用户的考试记录列表
+func (s *ExamService) GetSessions(userID int64, page, pageSize int) ([]*model.ExamSession, int64, error) {
+	return s.examDAO.GetSessionsByUserID(userID, page, pageSize)
+}
+
+// GetSession 获取考试会话并校验归属
+func (s *ExamService) GetSession(userID, sessionID int64) (*model.ExamSession, error) {
+	session, err := s.examDAO.GetSessionByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExamNotFound
+		}
+		return nil, err
+	}
+
+	if session.UserID != userID {
+		return nil, ErrExamPermissionDenied
+	}
+
+	return session, nil
+}
+
+// GetExamPaper 获取考试对应的试卷（允许试卷已被删除，保证历史记录可查看）
+func (s *ExamService) GetExamPaper(session *model.ExamSession) (*model.Paper, error) {
+	return s.paperDAO.GetPaperIncludingDeleted(session.PaperID)
+}
+
+// GetExamQuestions 获取考生看到的题目列表，已按考试的乱序设置排列
+func (s *ExamService) GetExamQuestions(session *model.ExamSession) ([]*ExamQuestion, error) {
+	details, err := s.paperService.GetPaperQuestions(session.PaperID)
+	if err != nil {
+		return nil, err
+	}
+	return buildExamQuestions(session, details), nil
+}
+
+// GetAnswers 获取考试的作答，按题目ID索引
+func (s *ExamService) GetAnswers(sessionID int64) (map[int64]*model.ExamAnswer, error) {
+	answers, err := s.examDAO.GetAnswersBySessionID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	answerMap := make(map[int64]*model.ExamAnswer, len(answers))
+	for _, answer := range answers {
+		answerMap[answer.QuestionID] = answer
+	}
+	return answerMap, nil
+}
+
+// GetExamResult 获取已提交考试的会话，用于查看成绩和得分明细
+func (s *ExamService) GetExamResult(userID, sessionID int64) (*model.ExamSession, error) {
+	session, err := s.GetSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status == model.ExamStatusInProgress {
+		return nil, ErrExamNotSubmitted
+	}
+
+	return session, nil
+}
+
+// SaveAnswers 保存作答，可多次调用逐步保存，同一题目以最后一次为准
+func (s *ExamService) SaveAnswers(userID, sessionID int64, answers map[int64]string) error {
+	session, err := s.GetSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.Status != model.ExamStatusInProgress {
+		return ErrExamNotInProgress
+	}
+	if isExpired(session, time.Now()) {
+		return ErrExamTimeUp
+	}
+
+	paperQuestions, err := s.paperDAO.GetPaperQuestions(session.PaperID)
+	if err != nil {
+		return err
+	}
+
+	inPaper := make(map[int64]bool, len(paperQuestions))
+	for _, pq := range paperQuestions {
+		inPaper[pq.QuestionID] = true
+	}
+
+	examAnswers := make([]*model.ExamAnswer, 0, len(answers))
+	for questionID, answer := range answers {
+		if !inPaper[questionID] {
+			return fmt.Errorf("%w: %d", ErrQuestionNotInExam, questionID)
+		}
+		examAnswers = append(examAnswers, &model.ExamAnswer{
+			SessionID:  sessionID,
+			QuestionID: questionID,
+			Answer:     strings.TrimSpace(answer),
+		})
+	}
+
+	saved, err := s.examDAO.SaveAnswers(sessionID, examAnswers)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return ErrExamNotInProgress
+	}
+	return nil
+}
+
+// SubmitExam 提交考试并自动评分，提交后不能再修改作答
+// 超过截止时间后仍允许提交，提交时间记为截止时间，已保存的作答照常评分
+func (s *ExamService) SubmitExam(userID, sessionID int64) (*model.ExamSession, error) {
+	session, err := s.GetSession(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status != model.ExamStatusInProgress {
+		return nil, ErrExamNotInProgress
+	}
+
+	submittedAt, autoSubmitted := time.Now(), false
+	if isExpired(session, submittedAt) {
+		submittedAt, autoSubmitted = *session.Deadline, true
+	}
+
+	if err := s.finishSession(session, submittedAt, autoSubmitted); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// RunAutoSubmitWorker 定期自动提交并评分已超时的考试，直到 stop 被关闭
+func (s *ExamService) RunAutoSubmitWorker(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		log.Printf("未配置考试自动提交间隔，超时的考试在考生再次访问时提交")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.autoSubmitExpiredSessions()
+		}
+	}
+}
+
+// autoSubmitExpiredSessions 自动提交已超时的考试
+func (s *ExamService) autoSubmitExpiredSessions() {
+	for {
+		sessions, err := s.examDAO.GetExpiredSessions(time.Now(), autoSubmitBatchSize)
+		if err != nil {
+			log.Printf("查询超时考试失败: %v", err)
+			return
+		}
+
+		for _, session := range sessions {
+			if err := s.finishSession(session, *session.Deadline, true); err != nil {
+				if errors.Is(err, ErrExamNotInProgress) {
+					continue
+				}
+				log.Printf("自动提交考试 %d 失败: %v", session.ID, err)
+				return
+			}
+			log.Printf("考试 %d 已超时，系统自动提交", session.ID)
+		}
+
+		if len(sessions) < autoSubmitBatchSize {
+			return
+		}
+	}
+}
+
+// finishSession 将考试标记为已提交并评分
+// 如果考试已被其他请求提交（如用户提交与后台任务同时发生），返回 ErrExamNotInProgress
+func (s *ExamService) finishSession(session *model.ExamSession, submittedAt time.Time, autoSubmitted bool) error {
+	ok, err := s.examDAO.MarkSubmitted(session, submittedAt, autoSubmitted)
+	if err != nil {
+		return fmt.Errorf("提交考试失败: %v", err)
+	}
+	if !ok {
+		return ErrExamNotInProgress
+	}
+
+	// 评分失败不影响提交结果，考试保持已提交状态，可稍后重新评分
+	if err := s.gradingService.SubmitSession(session); err != nil {
+		log.Printf("考试 %d 自动评分失败: %v", session.ID, err)
+	}
+	return nil
+}
+
+// examDeadline 计算考试的截止时间：开始时间加考试时长，且不晚于试卷的截止作答时间
+func examDeadline(paper *model.Paper, startedAt time.Time) *time.Time {
+	var deadline *time.Time
+	if paper.DurationMinutes > 0 {
+		d := startedAt.Add(time.Duration(paper.DurationMinutes) * time.Minute)
+		deadline = &d
+	}
+	if paper.CloseAt != nil && (deadline == nil || paper.CloseAt.Before(*deadline)) {
+		d := *paper.CloseAt
+		deadline = &d
+	}
+	return deadline
+}
+
+// isExpired 判断考试是否已超过截止时间
+func isExpired(session *model.ExamSession, now time.Time) bool {
+	return session.Deadline != nil && !now.Before(*session.Deadline)
+}
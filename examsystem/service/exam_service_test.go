@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"examsystem/config"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"testing"
+)
+
+// newTestExamService 创建考试服务、考生（用户2）和一张只有一道单选题的试卷，返回试卷ID和题目ID
+func newTestExamService(t *testing.T, published bool) (*ExamService, int64, int64) {
+	t.Helper()
+	paperService, paperID, questionIDs := newTestPaper(t, 1)
+	if err := paperService.AddQuestionToPaper(1, paperID, questionIDs[0], nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := paperService.UpdatePaper(1, &model.Paper{ID: paperID, Title: "期中考试", Published: published}); err != nil {
+		t.Fatal(err)
+	}
+	// 用户2作为考生
+	db := paperService.paperDAO.DB
+	if err := db.Exec("INSERT INTO users (id, username, password_hash) VALUES (2, 'student', 'x')").Error; err != nil {
+		t.Fatal(err)
+	}
+	examDAO := dao.NewExamDAO(db)
+	gradingService := NewGradingService(examDAO, paperService, config.GradingConfig{MultipleChoiceRule: MultipleChoiceAllOrNothing}, nil)
+	return NewExamService(examDAO, paperService.paperDAO, paperService, gradingService), paperID, questionIDs[0]
+}
+
+func TestStartExamUnpublishedPaper(t *testing.T) {
+	s, paperID, _ := newTestExamService(t, false)
+	if _, err := s.StartExam(2, paperID); !errors.Is(err, ErrPaperNotFound) {
+		t.Fatalf("其他用户参加未发布的试卷: err = %v，应为 %v", err, ErrPaperNotFound)
+	}
+	if _, err := s.StartExam(1, paperID); err != nil {
+		t.Fatalf("创建者参加未发布的试卷: %v", err)
+	}
+}
+
+func TestSaveAnswersAfterSubmit(t *testing.T) {
+	s, paperID, questionID := newTestExamService(t, true)
+	session, err := s.StartExam(2, paperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAnswers(2, session.ID, map[int64]string{questionID: "A"}); err != nil {
+		t.Fatal(err)
+	}
+	submitted, err := s.SubmitExam(2, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submitted.Status != model.ExamStatusGraded || submitted.Score != defaultQuestionScore {
+		t.Fatalf("提交后状态为 %s，得分 %.2f", submitted.Status, submitted.Score)
+	}
+
+	// 提交前读取的会话仍为进行中，保存时在事务中发现考试已提交
+	saved, err := s.examDAO.SaveAnswers(session.ID, []*model.ExamAnswer{{SessionID: session.ID, QuestionID: questionID, Answer: "B"}})
+	if err != nil || saved {
+		t.Fatalf("提交后保存作答 = %v, %v", saved, err)
+	}
+	answers, err := s.GetAnswers(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answers[questionID].Answer != "A" {
+		t.Fatalf("提交后的作答被修改为 %q", answers[questionID].Answer)
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"examsystem/dao/model"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// 多选题计分规则
+const (
+	MultipleChoiceAllOrNothing = "all_or_nothing" // 与标准答案完全一致才得分
+	MultipleChoiceProportional = "proportional"   // 少选按选对比例得分，有错选不得分
+	MultipleChoicePenalty      = "penalty"        // 每个正确选项加分，每个错误选项倒扣，最低0分
+)
+
+// GradeResult 单题评分结果
+type GradeResult struct {
+	Score       float64 // 得分
+	IsCorrect   bool    // 是否完全正确
+	NeedsManual bool    // 是否需要人工阅卷
+	Feedback    string  // 评分说明
+	// 编程题每个测试用例的运行结果，其他题型为空
+	TestResults []TestCaseResult
+}
+
+// Grader 题目评分器，每种题型注册一个实现
+type Grader interface {
+	// Grade 根据题目的标准答案为作答评分，fullScore 为该题在试卷中的分值
+	Grade(question *model.Question, answer string, fullScore int) GradeResult
+}
+
+// SingleChoiceGrader 单选题评分器：答案完全一致得满分
+type SingleChoiceGrader struct{}
+
+// Grade 单选题评分
+func (g *SingleChoiceGrader) Grade(question *model.Question, answer string, fullScore int) GradeResult {
+	if answer == "" {
+		return GradeResult{Feedback: "未作答"}
+	}
+
+	if normalizeChoiceAnswer(answer) == normalizeChoiceAnswer(question.Answer) {
+		return GradeResult{Score: float64(fullScore), IsCorrect: true}
+	}
+	return GradeResult{Feedback: "答案错误"}
+}
+
+// MultipleChoiceGrader 多选题评分器，Rule 决定部分得分的计算方式
+type MultipleChoiceGrader struct {
+	Rule string
+}
+
+// Grade 多选题评分
+func (g *MultipleChoiceGrader) Grade(question *model.Question, answer string, fullScore int) GradeResult {
+	if answer == "" {
+		return GradeResult{Feedback: "未作答"}
+	}
+
+	correct := choiceSet(normalizeChoiceAnswer(question.Answer))
+	picked := choiceSet(normalizeChoiceAnswer(answer))
+	if len(correct) == 0 {
+		return GradeResult{NeedsManual: true, Feedback: "题目缺少标准答案"}
+	}
+
+	var hits, misses int
+	for choice := range picked {
+		if correct[choice] {
+			hits++
+		} else {
+			misses++
+		}
+	}
+
+	if hits == len(correct) && misses == 0 {
+		return GradeResult{Score: float64(fullScore), IsCorrect: true}
+	}
+
+	perChoice := float64(fullScore) / float64(len(correct))
+	var score float64
+	switch g.Rule {
+	case MultipleChoiceProportional:
+		if misses == 0 {
+			score = perChoice * float64(hits)
+		}
+	case MultipleChoicePenalty:
+		score = math.Max(0, perChoice*float64(hits-misses))
+	}
+
+	return GradeResult{
+		Score:    roundScore(score),
+		Feedback: fmt.Sprintf("选对%d项，错选%d项，共%d个正确选项", hits, misses, len(correct)),
+	}
+}
+
+// JudgeGrader 判断题评分器
+type JudgeGrader struct{}
+
+// Grade 判断题评分
+func (g *JudgeGrader) Grade(question *model.Question, answer string, fullScore int) GradeResult {
+	if answer == "" {
+		return GradeResult{Feedback: "未作答"}
+	}
+
+	picked, ok := normalizeJudgeAnswer(answer)
+	if !ok {
+		return GradeResult{Feedback: "无法识别的作答"}
+	}
+	correct, _ := normalizeJudgeAnswer(question.Answer)
+	if picked == correct {
+		return GradeResult{Score: float64(fullScore), IsCorrect: true}
+	}
+	return GradeResult{Feedback: "答案错误"}
+}
+
+// BlankGrader 填空题评分器：每个空与可接受答案列表比较（忽略大小写和多余空白），按答对的空数比例得分
+type BlankGrader struct{}
+
+// Grade 填空题评分
+func (g *BlankGrader) Grade(question *model.Question, answer string, fullScore int) GradeResult {
+	blanks, err := parseBlankAnswer(question.Answer)
+	if err != nil {
+		return GradeResult{NeedsManual: true, Feedback: "题目答案格式错误: " + err.Error()}
+	}
+
+	responses := parseBlankResponse(answer)
+	if len(responses) == 0 {
+		return GradeResult{Feedback: "未作答"}
+	}
+
+	hits := 0
+	for i, variants := range blanks {
+		if i >= len(responses) {
+			break
+		}
+		response := normalizeBlankText(responses[i])
+		for _, variant := range variants {
+			if response != "" && response == normalizeBlankText(variant) {
+				hits++
+				break
+			}
+		}
+	}
+
+	if hits == len(blanks) {
+		return GradeResult{Score: float64(fullScore), IsCorrect: true}
+	}
+	return GradeResult{
+		Score:    roundScore(float64(fullScore) * float64(hits) / float64(len(blanks))),
+		Feedback: fmt.Sprintf("答对%d/%d个空", hits, len(blanks)),
+	}
+}
+
+// ShortAnswerGrader 简答题评分器：不自动评分，全部交由人工阅卷
+type ShortAnswerGrader struct{}
+
+// Grade 简答题标记为待人工阅卷
+func (g *ShortAnswerGrader) Grade(question *model.Question, answer string, fullScore int) GradeResult {
+	return GradeResult{NeedsManual: true, Feedback: "待人工阅卷"}
+}
+
+// CodingGrader 编程题评分器：在沙箱中运行隐藏测试用例，按通过的用例比例得分
+type CodingGrader struct {
+	Runner *CodeRunner
+}
+
+// Grade 编程题评分，运行环境异常时转为人工阅卷
+func (g *CodingGrader) Grade(question *model.Question, answer string, fullScore int) GradeResult {
+	if strings.TrimSpace(answer) == "" {
+		return GradeResult{Feedback: "未作答"}
+	}
+
+	tests, err := parseCodeTestCases(question.TestCases)
+	if err != nil {
+		return GradeResult{NeedsManual: true, Feedback: "题目测试用例格式错误: " + err.Error()}
+	}
+
+	results, err := g.Runner.RunTests(question.Language, answer, tests)
+	if err != nil {
+		return GradeResult{NeedsManual: true, Feedback: "代码运行失败，需要人工阅卷: " + err.Error()}
+	}
+
+	passed := 0
+	for _, result := range results {
+		if result.Status == TestStatusPassed {
+			passed++
+		}
+	}
+
+	gradeResult := GradeResult{
+		Score:       roundScore(float64(fullScore) * float64(passed) / float64(len(tests))),
+		IsCorrect:   passed == len(tests),
+		TestResults: results,
+	}
+	if !gradeResult.IsCorrect {
+		gradeResult.Feedback = fmt.Sprintf("通过%d/%d个测试用例", passed, len(tests))
+	}
+	return gradeResult
+}
+
+// normalizeChoiceAnswer 将选择题答案规范为去重、排序后的大写字母，如 "c, a" -> "AC"
+func normalizeChoiceAnswer(answer string) string {
+	set := make(map[rune]bool)
+	for _, r := range strings.ToUpper(answer) {
+		if r >= 'A' && r <= 'Z' {
+			set[r] = true
+		}
+	}
+
+	letters := make([]string, 0, len(set))
+	for r := range set {
+		letters = append(letters, string(r))
+	}
+	sort.Strings(letters)
+	return strings.Join(letters, "")
+}
+
+// choiceSet 将规范化后的答案转换为选项集合
+func choiceSet(answer string) map[rune]bool {
+	set := make(map[rune]bool, len(answer))
+	for _, r := range answer {
+		set[r] = true
+	}
+	return set
+}
+
+// roundScore 分数保留两位小数
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"examsystem/dao/model"
+	"testing"
+)
+
+func TestSingleChoiceGrader(t *testing.T) {
+	question := &model.Question{QuestionType: model.QuestionTypeSingle, Answer: "B"}
+	tests := []struct {
+		answer    string
+		wantScore float64
+	}{
+		{" b ", 5},
+		{"A", 0},
+		{"AB", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		result := (&SingleChoiceGrader{}).Grade(question, tt.answer, 5)
+		if result.Score != tt.wantScore || result.IsCorrect != (tt.wantScore == 5) || result.NeedsManual {
+			t.Errorf("Grade(%q) = %+v，应为 %.2f 分", tt.answer, result, tt.wantScore)
+		}
+	}
+}
+
+func TestMultipleChoiceGrader(t *testing.T) {
+	// 标准答案有3个正确选项，满分6分，每个正确选项2分
+	question := &model.Question{QuestionType: model.QuestionTypeMultiple, Answer: "ACD"}
+	tests := []struct {
+		name   string
+		answer string
+		want   map[string]float64 // 各计分规则的得分
+	}{
+		{"顺序和大小写不同", "d,c,a", map[string]float64{MultipleChoiceAllOrNothing: 6, MultipleChoiceProportional: 6, MultipleChoicePenalty: 6}},
+		{"少选", "AC", map[string]float64{MultipleChoiceAllOrNothing: 0, MultipleChoiceProportional: 4, MultipleChoicePenalty: 4}},
+		{"多选一个错误选项", "ABCD", map[string]float64{MultipleChoiceAllOrNothing: 0, MultipleChoiceProportional: 0, MultipleChoicePenalty: 4}},
+		{"倒扣后最低0分", "B", map[string]float64{MultipleChoiceAllOrNothing: 0, MultipleChoiceProportional: 0, MultipleChoicePenalty: 0}},
+	}
+	for _, tt := range tests {
+		for rule, want := range tt.want {
+			result := (&MultipleChoiceGrader{Rule: rule}).Grade(question, tt.answer, 6)
+			if result.Score != want || result.IsCorrect != (want == 6) {
+				t.Errorf("%s（%s）: %+v，应为 %.2f 分", tt.name, rule, result, want)
+			}
+		}
+	}
+
+	// 3个正确选项平分5分，得分保留两位小数
+	question.Answer = "ABC"
+	if result := (&MultipleChoiceGrader{Rule: MultipleChoiceProportional}).Grade(question, "AB", 5); result.Score != 3.33 {
+		t.Errorf("得分 %v，应为 3.33", result.Score)
+	}
+}
+
+func TestJudgeGrader(t *testing.T) {
+	question := &model.Question{QuestionType: model.QuestionTypeJudge, Answer: JudgeAnswerTrue}
+	tests := []struct {
+		answer  string
+		correct bool
+	}{
+		{"正确", true},
+		{" √ ", true},
+		{"false", false},
+		{"不确定", false},
+	}
+	for _, tt := range tests {
+		result := (&JudgeGrader{}).Grade(question, tt.answer, 2)
+		if result.IsCorrect != tt.correct || (result.Score == 2) != tt.correct || result.NeedsManual {
+			t.Errorf("Grade(%q) = %+v", tt.answer, result)
+		}
+	}
+}
+
+func TestBlankGrader(t *testing.T) {
+	tests := []struct {
+		name      string
+		answer    string // 标准答案
+		response  string // 考生作答
+		wantScore float64
+		manual    bool
+	}{
+		{"使用另一个可接受答案", `[["go","golang"],["8"]]`, `["GoLang"," 8 "]`, 6, false},
+		{"答对一个空", `[["go","golang"],["8"]]`, `["go","9"]`, 3, false},
+		{"单个空的纯文本答案", `go|golang`, `golang`, 6, false},
+		{"标准答案格式错误", `[1, 2]`, `["1","2"]`, 0, true},
+	}
+	for _, tt := range tests {
+		question := &model.Question{QuestionType: model.QuestionTypeBlank, Answer: tt.answer}
+		result := (&BlankGrader{}).Grade(question, tt.response, 6)
+		if result.Score != tt.wantScore || result.NeedsManual != tt.manual {
+			t.Errorf("%s: Grade(%q) = %+v，应为 %.2f 分", tt.name, tt.response, result, tt.wantScore)
+		}
+	}
+
+	// 简答题交由人工阅卷
+	question := &model.Question{QuestionType: model.QuestionTypeShortAnswer, Answer: "参考答案"}
+	if result := (&ShortAnswerGrader{}).Grade(question, "参考答案", 10); !result.NeedsManual || result.Score != 0 {
+		t.Errorf("简答题应交由人工阅卷，实际: %+v", result)
+	}
+}
+
+func TestCodingGrader(t *testing.T) {
+	grader := &CodingGrader{Runner: newTestCodeRunner(t)}
+	question := &model.Question{
+		QuestionType: model.QuestionTypeCoding,
+		Language:     "c",
+		TestCases:    `[{"input":"1 2","expected_output":"3"},{"input":"2 3","expected_output":"5"},{"input":"10 20","expected_output":"30"}]`,
+	}
+	tests := []struct {
+		name      string
+		answer    string
+		wantScore float64
+	}{
+		{"全部通过", `#include <stdio.h>
+int main() { int a, b; scanf("%d %d", &a, &b); printf("%d\n", a + b); return 0; }`, 6},
+		{"按通过的用例比例得分", `#include <stdio.h>
+int main() { int a, b; scanf("%d %d", &a, &b); printf("%d\n", a < 10 ? a + b : 0); return 0; }`, 4},
+		{"编译错误不得分", `int main() { return }`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := grader.Grade(question, tt.answer, 6)
+			if result.Score != tt.wantScore || result.IsCorrect != (tt.wantScore == 6) || result.NeedsManual {
+				t.Fatalf("Grade = %+v，应为 %.2f 分", result, tt.wantScore)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"encoding/json"
+	"examsystem/config"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"fmt"
+	"log"
+	"time"
+)
+
+// GradingService 阅卷服务，按题型分发给注册的评分器
+type GradingService struct {
+	examDAO      *dao.ExamDAO
+	paperService *PaperService
+	graders      map[model.QuestionType]Grader
+	workers      chan struct{} // 限制同时在后台评分的考试数量
+}
+
+// NewGradingService 创建阅卷服务实例，并注册内置题型的评分器
+func NewGradingService(examDAO *dao.ExamDAO, paperService *PaperService, gradingConfig config.GradingConfig, codeRunner *CodeRunner) *GradingService {
+	rule := gradingConfig.MultipleChoiceRule
+	switch rule {
+	case MultipleChoiceAllOrNothing, MultipleChoiceProportional, MultipleChoicePenalty:
+	default:
+		log.Printf("警告: 未知的多选题计分规则 %q，使用 %s", rule, MultipleChoiceAllOrNothing)
+		rule = MultipleChoiceAllOrNothing
+	}
+
+	workers := gradingConfig.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	s := &GradingService{
+		examDAO:      examDAO,
+		paperService: paperService,
+		graders:      make(map[model.QuestionType]Grader),
+		workers:      make(chan struct{}, workers),
+	}
+	s.RegisterGrader(model.QuestionTypeSingle, &SingleChoiceGrader{})
+	s.RegisterGrader(model.QuestionTypeMultiple, &MultipleChoiceGrader{Rule: rule})
+	s.RegisterGrader(model.QuestionTypeJudge, &JudgeGrader{})
+	s.RegisterGrader(model.QuestionTypeBlank, &BlankGrader{})
+	s.RegisterGrader(model.QuestionTypeShortAnswer, &ShortAnswerGrader{})
+	s.RegisterGrader(model.QuestionTypeCoding, &CodingGrader{Runner: codeRunner})
+	return s
+}
+
+// RegisterGrader 注册题型评分器，已存在的同题型评分器会被替换
+func (s *GradingService) RegisterGrader(questionType model.QuestionType, grader Grader) {
+	s.graders[questionType] = grader
+}
+
+// SubmitSession 为刚提交的考试评分。包含编程题的考试需要运行测试用例，
+// 标记为评分中后交给后台评分，同时评分的考试数量受 GRADING_WORKERS 限制；其他考试直接评分
+func (s *GradingService) SubmitSession(session *model.ExamSession) error {
+	details, err := s.paperService.GetPaperQuestions(session.PaperID)
+	if err != nil {
+		return err
+	}
+	hasCoding := false
+	for _, detail := range details {
+		if detail.Question.QuestionType == model.QuestionTypeCoding {
+			hasCoding = true
+			break
+		}
+	}
+	if !hasCoding {
+		return s.GradeSession(session)
+	}
+
+	ok, err := s.examDAO.UpdateSessionStatus(session, model.ExamStatusSubmitted, model.ExamStatusGrading)
+	if err != nil || !ok {
+		return err
+	}
+	// 后台评分修改的是副本，调用方返回的考试记录保持评分中
+	background := *session
+	go s.gradeInBackground(&background)
+	return nil
+}
+
+// ResumeGrading 继续评分上次服务退出时仍在评分中的考试
+func (s *GradingService) ResumeGrading() error {
+	sessions, err := s.examDAO.GetSessionsByStatus(model.ExamStatusGrading)
+	if err != nil {
+		return fmt.Errorf("查询评分中的考试失败: %v", err)
+	}
+	if len(sessions) > 0 {
+		log.Printf("继续评分 %d 场中断的考试", len(sessions))
+	}
+	for _, session := range sessions {
+		go s.gradeInBackground(session)
+	}
+	return nil
+}
+
+// gradeInBackground 等待空闲的评分名额后评分，失败时恢复为已提交，可稍后重新评分或人工阅卷
+func (s *GradingService) gradeInBackground(session *model.ExamSession) {
+	s.workers <- struct{}{}
+	defer func() { <-s.workers }()
+
+	if err := s.GradeSession(session); err != nil {
+		log.Printf("考试 %d 自动评分失败: %v", session.ID, err)
+		if _, err := s.examDAO.UpdateSessionStatus(session, model.ExamStatusGrading, model.ExamStatusSubmitted); err != nil {
+			log.Printf("恢复考试 %d 的状态失败: %v", session.ID, err)
+		}
+	}
+}
+
+// GradeSession 为已提交的考试评分并保存每题得分明细
+// 所有题目都能自动评分时考试状态变为已评分，否则保持已提交，等待人工阅卷
+func (s *GradingService) GradeSession(session *model.ExamSession) error {
+	details, err := s.paperService.GetPaperQuestions(session.PaperID)
+	if err != nil {
+		return err
+	}
+
+	existingAnswers, err := s.examDAO.GetAnswersBySessionID(session.ID)
+	if err != nil {
+		return err
+	}
+	answerMap := make(map[int64]*model.ExamAnswer, len(existingAnswers))
+	for _, answer := range existingAnswers {
+		answerMap[answer.QuestionID] = answer
+	}
+
+	now := time.Now()
+	var totalScore float64
+	needsManual := false
+	answers := make([]*model.ExamAnswer, 0, len(details))
+	for _, eq := range buildExamQuestions(session, details) {
+		detail := eq.PaperQuestionDetail
+		// 未作答的题目也记录一条，保证得分明细完整
+		answer, ok := answerMap[detail.Question.ID]
+		if !ok {
+			answer = &model.ExamAnswer{
+				SessionID:  session.ID,
+				QuestionID: detail.Question.ID,
+			}
+		}
+
+		grader, ok := s.graders[detail.Question.QuestionType]
+		if !ok {
+			answer.Score = 0
+			answer.IsCorrect = false
+			answer.Feedback = fmt.Sprintf("题型 %s 需要人工阅卷", detail.Question.QuestionType)
+			answer.TestResults = ""
+			answer.GradedAt = nil
+			needsManual = true
+			answers = append(answers, answer)
+			continue
+		}
+
+		// 选项乱序时考生作答的是显示字母，需要先映射回原题字母再评分
+		result := grader.Grade(detail.Question, eq.ToCanonicalAnswer(answer.Answer), detail.PaperQuestion.Score)
+		answer.Score = result.Score
+		answer.IsCorrect = result.IsCorrect
+		answer.Feedback = result.Feedback
+		answer.TestResults = ""
+		if len(result.TestResults) > 0 {
+			testResultsJSON, err := json.Marshal(result.TestResults)
+			if err != nil {
+				return err
+			}
+			answer.TestResults = string(testResultsJSON)
+		}
+		if result.NeedsManual {
+			answer.GradedAt = nil
+			needsManual = true
+		} else {
+			answer.GradedAt = &now
+		}
+
+		totalScore += result.Score
+		answers = append(answers, answer)
+	}
+
+	session.Score = roundScore(totalScore)
+	if needsManual {
+		session.Status = model.ExamStatusSubmitted
+		session.GradedAt = nil
+	} else {
+		session.Status = model.ExamStatusGraded
+		session.GradedAt = &now
+	}
+
+	if err := s.examDAO.SaveGradingResult(session, answers); err != nil {
+		return fmt.Errorf("保存评分结果失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrKnowledgeNodeNotFound = errors.New("知识体系节点不存在")
+	ErrInvalidKnowledgeNode  = errors.New("无效的知识体系节点")
+	ErrKnowledgeNodeInUse    = errors.New("节点下还有章节或知识点，不能删除")
+)
+
+// childLevels 各层级节点的下级层级，知识点没有下级
+var childLevels = map[model.KnowledgeLevel]model.KnowledgeLevel{
+	model.KnowledgeLevelSubject: model.KnowledgeLevelChapter,
+	model.KnowledgeLevelChapter: model.KnowledgeLevelPoint,
+}
+
+// KnowledgeService 知识体系服务：维护学科、章节、知识点，以及题目与知识点的关联
+type KnowledgeService struct {
+	knowledgeDAO *dao.KnowledgeDAO
+	questionDAO  *dao.QuestionDAO
+}
+
+// NewKnowledgeService 创建知识体系服务实例
+func NewKnowledgeService(knowledgeDAO *dao.KnowledgeDAO, questionDAO *dao.QuestionDAO) *KnowledgeService {
+	return &KnowledgeService{
+		knowledgeDAO: knowledgeDAO,
+		questionDAO:  questionDAO,
+	}
+}
+
+// KnowledgeTreeNode 知识体系树中的节点及用户题库中关联的题目数量
+type KnowledgeTreeNode struct {
+	Node          *model.KnowledgeNode
+	QuestionCount int64 // 关联到该节点或其下级知识点的题目数量
+	Children      []*KnowledgeTreeNode
+}
+
+// KnowledgeNodeStatistics 用户题库中某个节点下题目的统计
+type KnowledgeNodeStatistics struct {
+	Node          *model.KnowledgeNode
+	Path          []*model.KnowledgeNode // 从学科到该节点的路径
+	QuestionCount int64
+	ByType        map[model.QuestionType]int64
+	ByDifficulty  map[int]int64
+	Children      []*KnowledgeTreeNode // 下级节点及其题目数量，不包含更下一级
+}
+
+// GetTree 获取完整的知识体系树，并统计用户题库中每个节点的题目数量
+func (s *KnowledgeService) GetTree(userID int64) ([]*KnowledgeTreeNode, error) {
+	nodes, err := s.knowledgeDAO.GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.knowledgeDAO.CountQuestionsByNode(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 节点已按上级节点和排列顺序排序，先建立全部节点再挂到上级节点下
+	treeNodes := make(map[int64]*KnowledgeTreeNode, len(nodes))
+	for _, node := range nodes {
+		treeNodes[node.ID] = &KnowledgeTreeNode{Node: node, QuestionCount: counts[node.ID]}
+	}
+	roots := make([]*KnowledgeTreeNode, 0)
+	for _, node := range nodes {
+		treeNode := treeNodes[node.ID]
+		if parent, ok := treeNodes[node.ParentID]; ok {
+			parent.Children = append(parent.Children, treeNode)
+		} else if node.ParentID == 0 {
+			roots = append(roots, treeNode)
+		}
+	}
+	return roots, nil
+}
+
+// GetNode 获取节点
+func (s *KnowledgeService) GetNode(id int64) (*model.KnowledgeNode, error) {
+	node, err := s.knowledgeDAO.GetNodeByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKnowledgeNodeNotFound
+		}
+		return nil, err
+	}
+	return node, nil
+}
+
+// GetNodePath 获取从学科到该节点的路径
+func (s *KnowledgeService) GetNodePath(id int64) ([]*model.KnowledgeNode, error) {
+	var path []*model.KnowledgeNode
+	for id != 0 {
+		node, err := s.GetNode(id)
+		if err != nil {
+			return nil, err
+		}
+		path = append([]*model.KnowledgeNode{node}, path...)
+		id = node.ParentID
+	}
+	return path, nil
+}
+
+// CreateNode 创建节点，层级由上级节点决定：没有上级节点时为学科，学科下为章节，章节下为知识点
+func (s *KnowledgeService) CreateNode(node *model.KnowledgeNode) error {
+	node.Name = strings.TrimSpace(node.Name)
+	if node.Name == "" {
+		return fmt.Errorf("%w: 名称不能为空", ErrInvalidKnowledgeNode)
+	}
+
+	node.Level = model.KnowledgeLevelSubject
+	if node.ParentID != 0 {
+		parent, err := s.GetNode(node.ParentID)
+		if err != nil {
+			return err
+		}
+		level, ok := childLevels[parent.Level]
+		if !ok {
+			return fmt.Errorf("%w: 知识点下不能再添加节点", ErrInvalidKnowledgeNode)
+		}
+		node.Level = level
+	}
+
+	if err := s.checkSiblingName(node); err != nil {
+		return err
+	}
+	return s.knowledgeDAO.CreateNode(node)
+}
+
+// UpdateNode 更新节点的名称、说明和排列顺序
+func (s *KnowledgeService) UpdateNode(id int64, update *model.KnowledgeNode) (*model.KnowledgeNode, error) {
+	node, err := s.GetNode(id)
+	if err != nil {
+		return nil, err
+	}
+
+	node.Name = strings.TrimSpace(update.Name)
+	node.Description = update.Description
+	node.SortOrder = update.SortOrder
+	if node.Name == "" {
+		return nil, fmt.Errorf("%w: 名称不能为空", ErrInvalidKnowledgeNode)
+	}
+	if err := s.checkSiblingName(node); err != nil {
+		return nil, err
+	}
+
+	if err := s.knowledgeDAO.UpdateNode(node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// checkSiblingName 检查同一上级节点下是否已有同名节点
+func (s *KnowledgeService) checkSiblingName(node *model.KnowledgeNode) error {
+	exists, err := s.knowledgeDAO.ExistsSiblingName(node.ParentID, node.Name, node.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: 同一层级下已有名为 %s 的节点", ErrInvalidKnowledgeNode, node.Name)
+	}
+	return nil
+}
+
+// DeleteNode 删除没有下级节点的节点，题目与该知识点的关联一并删除
+func (s *KnowledgeService) DeleteNode(id int64) error {
+	if _, err := s.GetNode(id); err != nil {
+		return err
+	}
+	children, err := s.knowledgeDAO.CountChildren(id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return ErrKnowledgeNodeInUse
+	}
+	return s.knowledgeDAO.DeleteNode(id)
+}
+
+// ResolveKnowledgePoints 检查 ids 都是知识点（而不是学科或章节），返回去重后的知识点
+func (s *KnowledgeService) ResolveKnowledgePoints(ids []int64) ([]*model.KnowledgeNode, error) {
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]bool)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	nodes, err := s.knowledgeDAO.GetNodesByIDs(unique)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int64]*model.KnowledgeNode, len(nodes))
+	for _, node := range nodes {
+		found[node.ID] = node
+	}
+
+	points := make([]*model.KnowledgeNode, 0, len(unique))
+	for _, id := range unique {
+		node, ok := found[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrKnowledgeNodeNotFound, id)
+		}
+		if node.Level != model.KnowledgeLevelPoint {
+			return nil, fmt.Errorf("%w: %s 不是知识点，题目只能关联知识点", ErrInvalidKnowledgeNode, node.Name)
+		}
+		points = append(points, node)
+	}
+	return points, nil
+}
+
+// GetQuestionKnowledgePoints 获取题目关联的知识点，按题目ID分组
+func (s *KnowledgeService) GetQuestionKnowledgePoints(questionIDs []int64) (map[int64][]*model.KnowledgeNode, error) {
+	return s.knowledgeDAO.GetKnowledgePointsByQuestionIDs(questionIDs)
+}
+
+// SetQuestionKnowledgePoints 将用户题目关联的知识点替换为 pointIDs，pointIDs 为空时取消全部关联
+func (s *KnowledgeService) SetQuestionKnowledgePoints(userID, questionID int64, pointIDs []int64) ([]*model.KnowledgeNode, error) {
+	question, err := s.questionDAO.GetUndeletedQuestionByID(questionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuestionNotFound
+		}
+		return nil, err
+	}
+	if question.UserID != userID {
+		return nil, ErrQuestionNotFound
+	}
+
+	points, err := s.ResolveKnowledgePoints(pointIDs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(points))
+	for _, point := range points {
+		ids = append(ids, point.ID)
+	}
+	if err := s.knowledgeDAO.SetQuestionKnowledgePoints(questionID, ids); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// GetNodeStatistics 统计用户题库中关联到该节点或其下级知识点的题目，按题型、难度和下级节点分组
+func (s *KnowledgeService) GetNodeStatistics(userID, nodeID int64) (*KnowledgeNodeStatistics, error) {
+	path, err := s.GetNodePath(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, ErrKnowledgeNodeNotFound
+	}
+	stats := &KnowledgeNodeStatistics{
+		Node:         path[len(path)-1],
+		Path:         path,
+		ByType:       make(map[model.QuestionType]int64),
+		ByDifficulty: make(map[int]int64),
+		Children:     make([]*KnowledgeTreeNode, 0),
+	}
+
+	filter := QuestionFilter{KnowledgeNodeID: nodeID}
+	byType, err := s.questionDAO.CountFilteredQuestionsBy(userID, filter, "question_type")
+	if err != nil {
+		return nil, err
+	}
+	for questionType, count := range byType {
+		stats.ByType[model.QuestionType(questionType)] = count
+		stats.QuestionCount += count
+	}
+	byDifficulty, err := s.questionDAO.CountFilteredQuestionsBy(userID, filter, "difficulty")
+	if err != nil {
+		return nil, err
+	}
+	for difficulty, count := range byDifficulty {
+		d, _ := strconv.Atoi(difficulty)
+		stats.ByDifficulty[d] = count
+	}
+
+	nodes, err := s.knowledgeDAO.GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.knowledgeDAO.CountQuestionsByNode(userID)
+	if err != nil {
+		return nil, err
+	}
+	// 全部节点已按排列顺序排序
+	for _, node := range nodes {
+		if node.ParentID == nodeID {
+			stats.Children = append(stats.Children, &KnowledgeTreeNode{Node: node, QuestionCount: counts[node.ID]})
+		}
+	}
+	return stats, nil
+}
+
+// knowledgePathName 将节点路径拼接为“学科 > 章节 > 知识点”
+func knowledgePathName(path []*model.KnowledgeNode) string {
+	names := make([]string, 0, len(path))
+	for _, node := range path {
+		names = append(names, node.Name)
+	}
+	return strings.Join(names, " > ")
+}
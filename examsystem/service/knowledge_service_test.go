@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"examsystem/config"
+	"examsystem/dao/model"
+	"testing"
+)
+
+// newTestKnowledgeTree 创建“Go > 并发 > 通道”三级节点
+func newTestKnowledgeTree(t *testing.T, s *KnowledgeService) (subject, chapter, point *model.KnowledgeNode) {
+	t.Helper()
+	subject = &model.KnowledgeNode{Name: "Go"}
+	if err := s.CreateNode(subject); err != nil {
+		t.Fatal(err)
+	}
+	chapter = &model.KnowledgeNode{ParentID: subject.ID, Name: "并发"}
+	if err := s.CreateNode(chapter); err != nil {
+		t.Fatal(err)
+	}
+	point = &model.KnowledgeNode{ParentID: chapter.ID, Name: " 通道 "}
+	if err := s.CreateNode(point); err != nil {
+		t.Fatal(err)
+	}
+	return subject, chapter, point
+}
+
+func TestKnowledgeNodeHierarchy(t *testing.T) {
+	s := newTestQuestionService(t, nil, config.AIGenerationConfig{}).knowledge
+	subject, chapter, point := newTestKnowledgeTree(t, s)
+	if subject.Level != model.KnowledgeLevelSubject || chapter.Level != model.KnowledgeLevelChapter || point.Level != model.KnowledgeLevelPoint {
+		t.Fatalf("层级为 %s、%s、%s", subject.Level, chapter.Level, point.Level)
+	}
+
+	tests := []struct {
+		name string
+		node model.KnowledgeNode
+		want error
+	}{
+		{"名称为空", model.KnowledgeNode{Name: "  "}, ErrInvalidKnowledgeNode},
+		{"同级重名", model.KnowledgeNode{ParentID: chapter.ID, Name: "通道"}, ErrInvalidKnowledgeNode},
+		{"知识点下添加节点", model.KnowledgeNode{ParentID: point.ID, Name: "缓冲通道"}, ErrInvalidKnowledgeNode},
+		{"上级节点不存在", model.KnowledgeNode{ParentID: point.ID + 1, Name: "接口"}, ErrKnowledgeNodeNotFound},
+	}
+	for _, tt := range tests {
+		node := tt.node
+		if err := s.CreateNode(&node); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v，应为 %v", tt.name, err, tt.want)
+		}
+	}
+
+	path, err := s.GetNodePath(point.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := knowledgePathName(path); name != "Go > 并发 > 通道" {
+		t.Fatalf("路径为 %q", name)
+	}
+
+	// 题目只能关联知识点
+	if _, err := s.ResolveKnowledgePoints([]int64{point.ID, chapter.ID}); !errors.Is(err, ErrInvalidKnowledgeNode) {
+		t.Fatalf("关联章节: err = %v", err)
+	}
+	if points, err := s.ResolveKnowledgePoints([]int64{point.ID, point.ID}); err != nil || len(points) != 1 {
+		t.Fatalf("重复的知识点得到 %d 个, err = %v", len(points), err)
+	}
+
+	if err := s.DeleteNode(chapter.ID); !errors.Is(err, ErrKnowledgeNodeInUse) {
+		t.Fatalf("删除有下级节点的章节: err = %v", err)
+	}
+	if err := s.DeleteNode(point.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteNode(chapter.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateQuestionsForKnowledgePoint(t *testing.T) {
+	s := newTestQuestionJobService(t, config.QuestionJobConfig{Workers: 1})
+	knowledge := s.questionService.knowledge
+	subject, chapter, point := newTestKnowledgeTree(t, knowledge)
+
+	if _, err := s.SubmitJob(1, GenerateRequest{AIModel: "mock", Language: "Go", QuestionType: model.QuestionTypeSingle, NumQuestions: 3, KnowledgePointID: chapter.ID}); !errors.Is(err, ErrInvalidKnowledgeNode) {
+		t.Fatalf("按章节生成: err = %v", err)
+	}
+	job, err := s.SubmitJob(1, GenerateRequest{AIModel: "mock", Language: "Go", QuestionType: model.QuestionTypeSingle, NumQuestions: 3, KnowledgePointID: point.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := s.RunWorkers(stop); err != nil {
+		t.Fatal(err)
+	}
+	detail := waitForJob(t, s, job.ID)
+	if detail.Job.Status != model.QuestionJobStatusSucceeded || len(detail.Questions) != 3 {
+		t.Fatalf("任务状态为 %s，生成 %d 道题目，错误: %s", detail.Job.Status, len(detail.Questions), detail.Job.Error)
+	}
+
+	// 生成的题目自动关联知识点
+	ids := make([]int64, 0, len(detail.Questions))
+	for _, question := range detail.Questions {
+		ids = append(ids, question.ID)
+	}
+	points, err := knowledge.GetQuestionKnowledgePoints(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if len(points[id]) != 1 || points[id][0].ID != point.ID {
+			t.Fatalf("题目 %d 关联的知识点为 %v", id, points[id])
+		}
+	}
+
+	// 确认入库后计入各级节点的题目数量
+	if _, err := s.questionService.SaveSelectedQuestions(1, job.BatchID, ids, false); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := knowledge.GetTree(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 1 || tree[0].Node.ID != subject.ID || tree[0].QuestionCount != 3 || tree[0].Children[0].Children[0].QuestionCount != 3 {
+		t.Fatalf("知识体系树的题目数量不正确: %+v", tree)
+	}
+	stats, err := knowledge.GetNodeStatistics(1, chapter.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.QuestionCount != 3 || stats.ByType[model.QuestionTypeSingle] != 3 || len(stats.Path) != 2 || len(stats.Children) != 1 {
+		t.Fatalf("章节统计为 %+v", stats)
+	}
+	if other, err := knowledge.GetNodeStatistics(2, chapter.ID); err != nil || other.QuestionCount != 0 {
+		t.Fatalf("其他用户的统计为 %+v, err = %v", other, err)
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMarkingPermissionDenied = errors.New("无权批阅该试卷")
+	ErrAnswerNotFound          = errors.New("作答记录不存在")
+	ErrExamNotMarkable         = errors.New("考试尚未提交，不能批阅")
+	ErrExamGrading             = errors.New("考试正在自动评分，请稍后再批阅")
+	ErrExamAlreadyFinalized    = errors.New("考试已完成阅卷")
+	ErrMarkingIncomplete       = errors.New("还有未批阅的题目，不能完成阅卷")
+	ErrInvalidMarkScore        = errors.New("分数超出范围")
+)
+
+// MarkingAnswer 阅卷列表中的作答，附带题目和该题在试卷中的分值
+type MarkingAnswer struct {
+	*dao.MarkingAnswerRow
+	Question  *model.Question
+	FullScore int
+}
+
+// MarkingService 人工阅卷服务：教师批阅自己试卷的考试，管理员可以批阅全部试卷
+type MarkingService struct {
+	examDAO      *dao.ExamDAO
+	paperDAO     *dao.PaperDAO
+	paperService *PaperService
+}
+
+// NewMarkingService 创建阅卷服务实例
+func NewMarkingService(examDAO *dao.ExamDAO, paperDAO *dao.PaperDAO, paperService *PaperService) *MarkingService {
+	return &MarkingService{
+		examDAO:      examDAO,
+		paperDAO:     paperDAO,
+		paperService: paperService,
+	}
+}
+
+// GetMarkingPapers 获取有待阅卷作答的试卷列表
+func (s *MarkingService) GetMarkingPapers(userID int64, role string) ([]*dao.MarkingPaperRow, error) {
+	// 管理员查看全部试卷，教师只查看自己创建的试卷
+	creatorID := userID
+	if role == model.RoleAdmin {
+		creatorID = 0
+	}
+	return s.examDAO.GetMarkingPapers(creatorID)
+}
+
+// GetPaperAnswers 获取试卷已提交考试的作答列表，pendingOnly 为 true 时只返回待阅卷的作答
+func (s *MarkingService) GetPaperAnswers(userID int64, role string, paperID int64, pendingOnly bool, page, pageSize int) ([]*MarkingAnswer, int64, error) {
+	if err := s.checkPaperAccess(userID, role, paperID); err != nil {
+		return nil, 0, err
+	}
+
+	rows, total, err := s.examDAO.GetMarkingAnswers(paperID, pendingOnly, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	details, err := s.paperService.GetPaperQuestions(paperID)
+	if err != nil {
+		return nil, 0, err
+	}
+	detailMap := make(map[int64]*PaperQuestionDetail, len(details))
+	for _, detail := range details {
+		detailMap[detail.Question.ID] = detail
+	}
+
+	answers := make([]*MarkingAnswer, 0, len(rows))
+	for _, row := range rows {
+		answer := &MarkingAnswer{MarkingAnswerRow: row}
+		// 考试后从试卷中移除的题目不再有分值，仍然列出便于核对
+		if detail, ok := detailMap[row.QuestionID]; ok {
+			answer.Question = detail.Question
+			answer.FullScore = detail.PaperQuestion.Score
+		}
+		answers = append(answers, answer)
+	}
+	return answers, total, nil
+}
+
+// MarkAnswer 为作答打分并填写评语，考试总分随之重新计算
+// 已完成阅卷的考试也可以修改分数
+func (s *MarkingService) MarkAnswer(userID int64, role string, answerID int64, score float64, comment string) (*model.ExamAnswer, error) {
+	answer, err := s.examDAO.GetAnswerByID(answerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAnswerNotFound
+		}
+		return nil, err
+	}
+
+	session, err := s.getMarkableSession(userID, role, answer.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	paperQuestion, err := s.paperDAO.GetPaperQuestion(session.PaperID, answer.QuestionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuestionNotInExam
+		}
+		return nil, err
+	}
+
+	if score < 0 || score > float64(paperQuestion.Score) {
+		return nil, fmt.Errorf("%w: 应在0到%d之间", ErrInvalidMarkScore, paperQuestion.Score)
+	}
+
+	now := time.Now()
+	answer.Score = roundScore(score)
+	answer.IsCorrect = answer.Score == float64(paperQuestion.Score)
+	answer.Comment = strings.TrimSpace(comment)
+	answer.MarkedBy = &userID
+	answer.GradedAt = &now
+
+	if err := s.examDAO.SaveMark(answer); err != nil {
+		return nil, fmt.Errorf("保存阅卷结果失败: %v", err)
+	}
+	return answer, nil
+}
+
+// FinalizeSession 完成考试阅卷：所有题目都已评分后将考试标记为已评分
+func (s *MarkingService) FinalizeSession(userID int64, role string, sessionID int64) (*model.ExamSession, error) {
+	session, err := s.getMarkableSession(userID, role, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == model.ExamStatusGraded {
+		return nil, ErrExamAlreadyFinalized
+	}
+
+	// 每道题都需要有已评分的作答记录
+	details, err := s.paperService.GetPaperQuestions(session.PaperID)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := s.examDAO.GetAnswersBySessionID(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	graded := make(map[int64]bool, len(answers))
+	for _, answer := range answers {
+		graded[answer.QuestionID] = answer.GradedAt != nil
+	}
+	for _, detail := range details {
+		if !graded[detail.Question.ID] {
+			return nil, ErrMarkingIncomplete
+		}
+	}
+
+	finalized, err := s.examDAO.FinalizeSession(session, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("完成阅卷失败: %v", err)
+	}
+	if !finalized {
+		return nil, ErrExamAlreadyFinalized
+	}
+
+	return s.examDAO.GetSessionByID(session.ID)
+}
+
+// getMarkableSession 获取当前用户可以批阅的已提交考试
+func (s *MarkingService) getMarkableSession(userID int64, role string, sessionID int64) (*model.ExamSession, error) {
+	session, err := s.examDAO.GetSessionByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExamNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.checkPaperAccess(userID, role, session.PaperID); err != nil {
+		return nil, err
+	}
+
+	switch session.Status {
+	case model.ExamStatusInProgress:
+		return nil, ErrExamNotMarkable
+	case model.ExamStatusGrading:
+		return nil, ErrExamGrading
+	}
+	return session, nil
+}
+
+// checkPaperAccess 检查用户是否可以批阅试卷，已删除试卷的考试仍可批阅
+func (s *MarkingService) checkPaperAccess(userID int64, role string, paperID int64) error {
+	paper, err := s.paperDAO.GetPaperIncludingDeleted(paperID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaperNotFound
+		}
+		return err
+	}
+
+	if role != model.RoleAdmin && paper.CreatorID != userID {
+		return ErrMarkingPermissionDenied
+	}
+	return nil
+}
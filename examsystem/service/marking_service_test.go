@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao/model"
+	"testing"
+)
+
+func TestMarkAndFinalizeSession(t *testing.T) {
+	s, paperID, choiceID := newTestExamService(t, true)
+	shortAnswer := &model.Question{Title: "简述切片和数组的区别", QuestionType: model.QuestionTypeShortAnswer, Options: "[]", Answer: "参考答案", Language: "Go", AIModel: "manual", UserID: 1}
+	if err := s.paperService.questionDAO.CreateQuestion(shortAnswer); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.paperService.AddQuestionToPaper(1, paperID, shortAnswer.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := s.StartExam(2, paperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveAnswers(2, session.ID, map[int64]string{choiceID: "A", shortAnswer.ID: "切片是数组的引用"}); err != nil {
+		t.Fatal(err)
+	}
+	if session, err = s.SubmitExam(2, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if session.Status != model.ExamStatusSubmitted || session.Score != defaultQuestionScore {
+		t.Fatalf("简答题待人工阅卷时状态为 %s，得分 %.2f", session.Status, session.Score)
+	}
+	answers, err := s.GetAnswers(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answerID := answers[shortAnswer.ID].ID
+
+	marking := NewMarkingService(s.examDAO, s.paperDAO, s.paperService)
+	if _, err := marking.FinalizeSession(1, model.RoleTeacher, session.ID); !errors.Is(err, ErrMarkingIncomplete) {
+		t.Fatalf("未批阅完成阅卷: err = %v", err)
+	}
+	if _, err := marking.MarkAnswer(2, model.RoleTeacher, answerID, 3, ""); !errors.Is(err, ErrMarkingPermissionDenied) {
+		t.Fatalf("批阅他人的试卷: err = %v", err)
+	}
+	if _, err := marking.MarkAnswer(1, model.RoleTeacher, answerID, 6, ""); !errors.Is(err, ErrInvalidMarkScore) {
+		t.Fatalf("超出分值: err = %v", err)
+	}
+	if _, err := marking.MarkAnswer(1, model.RoleTeacher, answerID, 3.5, " 要点不全 "); err != nil {
+		t.Fatal(err)
+	}
+
+	finalized, err := marking.FinalizeSession(1, model.RoleTeacher, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finalized.Status != model.ExamStatusGraded || finalized.Score != defaultQuestionScore+3.5 {
+		t.Fatalf("完成阅卷后状态为 %s，得分 %.2f", finalized.Status, finalized.Score)
+	}
+	if _, err := marking.FinalizeSession(1, model.RoleTeacher, session.ID); !errors.Is(err, ErrExamAlreadyFinalized) {
+		t.Fatalf("重复完成阅卷: err = %v", err)
+	}
+
+	// 完成阅卷后仍可修改分数，总分随之更新
+	if _, err := marking.MarkAnswer(1, model.RoleAdmin, answerID, 5, ""); err != nil {
+		t.Fatal(err)
+	}
+	if session, err = s.GetSession(2, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if session.Score != 2*defaultQuestionScore {
+		t.Fatalf("修改分数后总分 %.2f，应为 %d", session.Score, 2*defaultQuestionScore)
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"examsystem/models/dto"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPaperNotFound          = errors.New("试卷不存在")
+	ErrPaperPermissionDenied  = errors.New("无权操作该试卷")
+	ErrQuestionAlreadyInPaper = errors.New("题目已在试卷中")
+	ErrQuestionNotInPaper     = errors.New("题目不在试卷中")
+	ErrQuestionUnavailable    = errors.New("题目不存在或无权使用")
+	ErrInvalidPaperParam      = errors.New("参数错误")
+)
+
+// 默认题目分值
+const defaultQuestionScore = 5
+
+// PaperQuestionDetail 试卷中的题目及其分值、顺序
+type PaperQuestionDetail struct {
+	PaperQuestion *model.PaperQuestion
+	Question      *model.Question
+}
+
+// PaperService 试卷服务
+type PaperService struct {
+	paperDAO    *dao.PaperDAO
+	questionDAO *dao.QuestionDAO
+}
+
+// NewPaperService 创建试卷服务实例
+func NewPaperService(paperDAO *dao.PaperDAO, questionDAO *dao.QuestionDAO) *PaperService {
+	return &PaperService{
+		paperDAO:    paperDAO,
+		questionDAO: questionDAO,
+	}
+}
+
+// CreatePaper 创建试卷
+func (s *PaperService) CreatePaper(paper *model.Paper) error {
+	if err := validatePaperSchedule(paper); err != nil {
+		return err
+	}
+
+	paper.TotalScore = 0
+	return s.paperDAO.CreatePaper(paper)
+}
+
+// GetPapers 获取用户的试卷列表，同时返回每张试卷的题目数量
+func (s *PaperService) GetPapers(userID int64, keyword string, page, pageSize int) ([]*model.Paper, map[int64]int, int64, error) {
+	papers, total, err := s.paperDAO.GetPapersByCreatorID(userID, keyword, page, pageSize)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	paperIDs := make([]int64, 0, len(papers))
+	for _, paper := range papers {
+		paperIDs = append(paperIDs, paper.ID)
+	}
+
+	questionCounts, err := s.paperDAO.CountQuestionsByPaperIDs(paperIDs)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return papers, questionCounts, total, nil
+}
+
+// GetPaper 获取试卷并校验归属
+func (s *PaperService) GetPaper(userID, paperID int64) (*model.Paper, error) {
+	paper, err := s.paperDAO.GetPaperByID(paperID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaperNotFound
+		}
+		return nil, err
+	}
+
+	if paper.CreatorID != userID {
+		return nil, ErrPaperPermissionDenied
+	}
+
+	return paper, nil
+}
+
+// GetPaperQuestions 获取试卷的题目列表（按题目顺序）
+func (s *PaperService) GetPaperQuestions(paperID int64) ([]*PaperQuestionDetail, error) {
+	paperQuestions, err := s.paperDAO.GetPaperQuestions(paperID)
+	if err != nil {
+		return nil, err
+	}
+
+	questionIDs := make([]int64, 0, len(paperQuestions))
+	for _, pq := range paperQuestions {
+		questionIDs = append(questionIDs, pq.QuestionID)
+	}
+
+	questions, err := s.questionDAO.GetQuestionsByIDs(questionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	questionMap := make(map[int64]*model.Question, len(questions))
+	for _, q := range questions {
+		questionMap[q.ID] = q
+	}
+
+	details := make([]*PaperQuestionDetail, 0, len(paperQuestions))
+	for _, pq := range paperQuestions {
+		question, ok := questionMap[pq.QuestionID]
+		if !ok {
+			continue
+		}
+		details = append(details, &PaperQuestionDetail{
+			PaperQuestion: pq,
+			Question:      question,
+		})
+	}
+
+	return details, nil
+}
+
+// UpdatePaper 更新试卷基本信息
+func (s *PaperService) UpdatePaper(userID int64, paper *model.Paper) error {
+	existingPaper, err := s.GetPaper(userID, paper.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := validatePaperSchedule(paper); err != nil {
+		return err
+	}
+
+	existingPaper.Title = paper.Title
+	existingPaper.Description = paper.Description
+	existingPaper.DurationMinutes = paper.DurationMinutes
+	existingPaper.OpenAt = paper.OpenAt
+	existingPaper.CloseAt = paper.CloseAt
+	existingPaper.ShuffleQuestions = paper.ShuffleQuestions
+	existingPaper.ShuffleOptions = paper.ShuffleOptions
+	existingPaper.Published = paper.Published
+	return s.paperDAO.UpdatePaper(existingPaper)
+}
+
+// validatePaperSchedule 校验试卷的考试时长和开放时间窗口
+func validatePaperSchedule(paper *model.Paper) error {
+	if paper.DurationMinutes < 0 {
+		return fmt.Errorf("%w: 考试时长不能为负数", ErrInvalidPaperParam)
+	}
+	if paper.OpenAt != nil && paper.CloseAt != nil && !paper.CloseAt.After(*paper.OpenAt) {
+		return fmt.Errorf("%w: 截止时间必须晚于开放时间", ErrInvalidPaperParam)
+	}
+	return nil
+}
+
+// DeletePaper 软删除试卷
+func (s *PaperService) DeletePaper(userID, paperID int64) error {
+	if _, err := s.GetPaper(userID, paperID); err != nil {
+		return err
+	}
+
+	return s.paperDAO.DeletePaper(paperID)
+}
+
+// AddQuestionToPaper 添加题目到试卷末尾，试卷总分随之更新，score 为空时使用默认分值
+func (s *PaperService) AddQuestionToPaper(userID, paperID, questionID int64, score *int) error {
+	if _, err := s.GetPaper(userID, paperID); err != nil {
+		return err
+	}
+
+	// 只能添加自己题库中未删除的题目，待确认和已归档的题目不能添加
+	question, err := s.questionDAO.GetUndeletedQuestionByID(questionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrQuestionUnavailable
+		}
+		return err
+	}
+	if question.UserID != userID || question.Status != model.QuestionStatusActive {
+		return ErrQuestionUnavailable
+	}
+
+	// 同一题目在一张试卷中只能出现一次
+	if _, err := s.paperDAO.GetPaperQuestion(paperID, questionID); err == nil {
+		return ErrQuestionAlreadyInPaper
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	questionScore := defaultQuestionScore
+	if score != nil {
+		if *score <= 0 {
+			return fmt.Errorf("%w: 无效的题目分值 %d", ErrInvalidPaperParam, *score)
+		}
+		questionScore = *score
+	}
+
+	maxOrder, err := s.paperDAO.GetMaxQuestionOrder(paperID)
+	if err != nil {
+		return err
+	}
+
+	err = s.paperDAO.AddQuestionToPaper(&model.PaperQuestion{
+		PaperID:       paperID,
+		QuestionID:    questionID,
+		QuestionOrder: maxOrder + 1,
+		Score:         questionScore,
+	})
+	// 并发添加同一题目时，上面的检查可能都通过，由唯一约束拦截
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrQuestionAlreadyInPaper
+	}
+	return err
+}
+
+// RemoveQuestionFromPaper 从试卷中移除题目，试卷总分随之更新
+func (s *PaperService) RemoveQuestionFromPaper(userID, paperID, questionID int64) error {
+	if _, err := s.GetPaper(userID, paperID); err != nil {
+		return err
+	}
+
+	if _, err := s.paperDAO.GetPaperQuestion(paperID, questionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrQuestionNotInPaper
+		}
+		return err
+	}
+
+	return s.paperDAO.RemoveQuestionFromPaper(paperID, questionID)
+}
+
+// UpdateQuestionOrder 更新试卷题目顺序，questionIDs 必须恰好包含试卷中的全部题目
+func (s *PaperService) UpdateQuestionOrder(userID, paperID int64, questionIDs []int64) error {
+	if _, err := s.GetPaper(userID, paperID); err != nil {
+		return err
+	}
+
+	paperQuestions, err := s.paperDAO.GetPaperQuestions(paperID)
+	if err != nil {
+		return err
+	}
+
+	if len(questionIDs) != len(paperQuestions) {
+		return fmt.Errorf("%w: 题目数量不匹配，试卷共有%d道题目", ErrInvalidPaperParam, len(paperQuestions))
+	}
+
+	existing := make(map[int64]bool, len(paperQuestions))
+	for _, pq := range paperQuestions {
+		existing[pq.QuestionID] = true
+	}
+
+	seen := make(map[int64]bool, len(questionIDs))
+	for _, id := range questionIDs {
+		if !existing[id] {
+			return ErrQuestionNotInPaper
+		}
+		if seen[id] {
+			return fmt.Errorf("%w: 题目ID重复 %d", ErrInvalidPaperParam, id)
+		}
+		seen[id] = true
+	}
+
+	return s.paperDAO.UpdateQuestionOrder(paperID, questionIDs)
+}
+
+// GetUserStatistics 获取用户的题目与试卷统计信息
+func (s *PaperService) GetUserStatistics(userID int64) (*dto.UserStatisticsResponse, error) {
+	typeCounts, err := s.questionDAO.CountQuestionsByType(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	paperCount, err := s.paperDAO.CountPapersByCreatorID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	paperQuestionCount, err := s.paperDAO.CountPaperQuestionsByCreatorID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &dto.UserStatisticsResponse{
+		QuestionTypeCounts: make(map[string]int64, len(typeCounts)),
+		PaperCount:         paperCount,
+		PaperQuestionCount: paperQuestionCount,
+	}
+	for questionType, count := range typeCounts {
+		stats.QuestionTypeCounts[string(questionType)] = count
+		stats.QuestionCount += count
+	}
+
+	return stats, nil
+}
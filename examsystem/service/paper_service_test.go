@@ -0,0 +1,166 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// newTestDB 在临时目录中创建空数据库，管理员账号的ID为1
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	migrations, err := filepath.Abs(filepath.Join("..", "migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(migrations, filepath.Join(dir, "migrations")); err != nil {
+		t.Fatal(err)
+	}
+	// InitDB 在当前目录下打开数据库并读取 migrations/init.sql
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	db, err := dao.InitDB(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newTestPaper 创建试卷和 n 道题目，返回试卷服务、试卷ID和题目ID
+func newTestPaper(t *testing.T, n int) (*PaperService, int64, []int64) {
+	t.Helper()
+	db := newTestDB(t)
+	questionDAO := dao.NewQuestionDAO(db)
+	s := NewPaperService(dao.NewPaperDAO(db), questionDAO)
+
+	paper := &model.Paper{Title: "期中考试", CreatorID: 1}
+	if err := s.CreatePaper(paper); err != nil {
+		t.Fatal(err)
+	}
+	questionIDs := make([]int64, n)
+	for i := range questionIDs {
+		question := &model.Question{Title: "题目", QuestionType: model.QuestionTypeSingle, Options: `["a","b"]`, Answer: "A", Language: "Go", AIModel: "manual", UserID: 1}
+		if err := questionDAO.CreateQuestion(question); err != nil {
+			t.Fatal(err)
+		}
+		questionIDs[i] = question.ID
+	}
+	return s, paper.ID, questionIDs
+}
+
+func TestAddQuestionToPaper(t *testing.T) {
+	s, paperID, questionIDs := newTestPaper(t, 3)
+	score := 8
+	if err := s.AddQuestionToPaper(1, paperID, questionIDs[0], nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddQuestionToPaper(1, paperID, questionIDs[1], &score); err != nil {
+		t.Fatal(err)
+	}
+
+	zero, negative := 0, -1
+	tests := []struct {
+		name       string
+		userID     int64
+		questionID int64
+		score      *int
+		want       error
+	}{
+		{"分值为0", 1, questionIDs[2], &zero, ErrInvalidPaperParam},
+		{"分值为负数", 1, questionIDs[2], &negative, ErrInvalidPaperParam},
+		{"重复添加", 1, questionIDs[0], nil, ErrQuestionAlreadyInPaper},
+		{"题目不存在", 1, questionIDs[2] + 1, nil, ErrQuestionUnavailable},
+		{"不是自己的试卷", 2, questionIDs[2], nil, ErrPaperPermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.AddQuestionToPaper(tt.userID, paperID, tt.questionID, tt.score); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v，应为 %v", err, tt.want)
+			}
+		})
+	}
+
+	paper, err := s.GetPaper(1, paperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paper.TotalScore != defaultQuestionScore+score {
+		t.Fatalf("总分 %d，应为 %d", paper.TotalScore, defaultQuestionScore+score)
+	}
+
+	// 并发添加时检查可能都通过，由唯一约束拦截
+	err = s.paperDAO.AddQuestionToPaper(&model.PaperQuestion{PaperID: paperID, QuestionID: questionIDs[0], QuestionOrder: 3, Score: 5})
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("重复插入的错误为 %v，应为 %v", err, gorm.ErrDuplicatedKey)
+	}
+}
+
+func TestUpdateQuestionOrder(t *testing.T) {
+	s, paperID, questionIDs := newTestPaper(t, 3)
+	for _, id := range questionIDs {
+		if err := s.AddQuestionToPaper(1, paperID, id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, b, c := questionIDs[0], questionIDs[1], questionIDs[2]
+	tests := []struct {
+		name string
+		ids  []int64
+		want error
+	}{
+		{"题目数量不匹配", []int64{c, b}, ErrInvalidPaperParam},
+		{"题目重复", []int64{c, c, a}, ErrInvalidPaperParam},
+		{"题目不在试卷中", []int64{c, b, c + 1}, ErrQuestionNotInPaper},
+	}
+	for _, tt := range tests {
+		if err := s.UpdateQuestionOrder(1, paperID, tt.ids); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v，应为 %v", tt.name, err, tt.want)
+		}
+	}
+
+	if err := s.UpdateQuestionOrder(1, paperID, []int64{c, a, b}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveQuestionFromPaper(1, paperID, a); err != nil {
+		t.Fatal(err)
+	}
+	details, err := s.GetPaperQuestions(paperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, detail := range details {
+		got = append(got, detail.Question.ID)
+	}
+	if !reflect.DeepEqual(got, []int64{c, b}) {
+		t.Fatalf("题目顺序为 %v，应为 %v", got, []int64{c, b})
+	}
+
+	paper, err := s.GetPaper(1, paperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paper.TotalScore != 2*defaultQuestionScore {
+		t.Fatalf("移除题目后总分 %d，应为 %d", paper.TotalScore, 2*defaultQuestionScore)
+	}
+}
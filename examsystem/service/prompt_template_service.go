@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPromptTemplateNotFound = errors.New("提示语模板不存在")
+	ErrInvalidPromptTemplate  = errors.New("无效的提示语模板")
+)
+
+// defaultPromptTemplate 没有指定模板、也没有配置默认模板时使用的内置模板
+const defaultPromptTemplate = `
+    请严格按照以下JSON格式生成{{.Count}}道关于"{{.Topic}}"的{{.Language}}编程{{.TypeName}}，{{.Instruction}}：
+{{.Format}}
+    `
+
+// PromptTemplateVars 提示语模板中可以使用的变量，如 {{.Count}}、{{.Topic}}
+type PromptTemplateVars struct {
+	Count              int    // 本次请求生成的题目数量
+	Topic              string // 题目主题，即生成时填写的关键词
+	Language           string // 编程语言或科目，如 Go、高等数学
+	QuestionType       string // 题型，如 single
+	TypeName           string // 题型的中文名称，如 单选题
+	Difficulty         int    // 目标难度 1-5，0表示未指定
+	DifficultyName     string // 目标难度的中文说明，如 中等
+	CognitiveLevel     string // 目标认知层次，如 apply
+	CognitiveLevelName string // 目标认知层次的中文名称，如 应用
+	KnowledgePoint     string // 考查的知识点，如 Go语言 > 并发 > channel，未指定时为空
+	Instruction        string // 题型对输出内容的要求
+	Format             string // 题型对应的JSON输出格式，模板中没有使用时自动附加在末尾
+}
+
+// newPromptTemplateVars 根据生成参数构造模板变量
+func newPromptTemplateVars(genReq GenerateRequest, numQuestions int) PromptTemplateVars {
+	vars := PromptTemplateVars{
+		Count:          numQuestions,
+		Topic:          genReq.Keywords,
+		Language:       genReq.Language,
+		QuestionType:   string(genReq.QuestionType),
+		TypeName:       questionTypeDesc(genReq.QuestionType),
+		Difficulty:     genReq.Difficulty,
+		DifficultyName: difficultyDescs[genReq.Difficulty],
+		CognitiveLevel: string(genReq.CognitiveLevel),
+		KnowledgePoint: genReq.knowledgePath,
+	}
+	if info, ok := lookupCognitiveLevel(string(genReq.CognitiveLevel)); ok {
+		vars.CognitiveLevelName = info.Name
+	}
+	vars.Instruction, vars.Format = questionFormat(genReq.QuestionType)
+	return vars
+}
+
+// renderPromptTemplate 使用变量渲染模板内容
+func renderPromptTemplate(content string, vars PromptTemplateVars) (string, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+	return b.String(), nil
+}
+
+// PromptTemplateService 提示语模板服务
+type PromptTemplateService struct {
+	templateDAO *dao.PromptTemplateDAO
+}
+
+// NewPromptTemplateService 创建提示语模板服务实例
+func NewPromptTemplateService(templateDAO *dao.PromptTemplateDAO) *PromptTemplateService {
+	return &PromptTemplateService{
+		templateDAO: templateDAO,
+	}
+}
+
+// validatePromptTemplate 校验模板信息，并使用示例变量试渲染模板内容
+func validatePromptTemplate(template *model.PromptTemplate, content string) error {
+	if strings.TrimSpace(template.Name) == "" {
+		return fmt.Errorf("%w: 模板名称不能为空", ErrInvalidPromptTemplate)
+	}
+	if template.QuestionType != "" && !IsValidQuestionType(template.QuestionType) {
+		return fmt.Errorf("%w: 无效的题目类型 %s", ErrInvalidPromptTemplate, template.QuestionType)
+	}
+
+	questionType := template.QuestionType
+	if questionType == "" {
+		questionType = model.QuestionTypeSingle
+	}
+	_, err := renderPromptTemplate(content, newPromptTemplateVars(GenerateRequest{
+		Language:       "Go",
+		QuestionType:   questionType,
+		Keywords:       "并发",
+		Difficulty:     DefaultDifficulty,
+		CognitiveLevel: model.CognitiveLevelApply,
+	}, 5))
+	return err
+}
+
+// CreateTemplate 创建模板
+func (s *PromptTemplateService) CreateTemplate(template *model.PromptTemplate, content string) error {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("%w: 模板内容不能为空", ErrInvalidPromptTemplate)
+	}
+	if err := validatePromptTemplate(template, content); err != nil {
+		return err
+	}
+	return s.templateDAO.CreateTemplate(template, content)
+}
+
+// UpdateTemplate 更新模板信息，content 不为空且与当前版本不同时新增一个版本
+func (s *PromptTemplateService) UpdateTemplate(userID, templateID int64, update *model.PromptTemplate, content string) (*model.PromptTemplate, error) {
+	template, current, err := s.GetTemplateVersion(templateID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Name = update.Name
+	template.Subject = update.Subject
+	template.QuestionType = update.QuestionType
+	template.Description = update.Description
+	template.IsDefault = update.IsDefault
+
+	if strings.TrimSpace(content) == "" || content == current.Content {
+		content = ""
+	}
+	checkContent := content
+	if checkContent == "" {
+		checkContent = current.Content
+	}
+	if err := validatePromptTemplate(template, checkContent); err != nil {
+		return nil, err
+	}
+
+	if err := s.templateDAO.UpdateTemplate(template, content, userID); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// DeleteTemplate 删除模板，已有的生成任务记录的版本仍可查询
+func (s *PromptTemplateService) DeleteTemplate(templateID int64) error {
+	if _, err := s.getTemplate(templateID); err != nil {
+		return err
+	}
+	return s.templateDAO.DeleteTemplate(templateID)
+}
+
+// GetTemplates 获取模板列表
+func (s *PromptTemplateService) GetTemplates(subject string, questionType model.QuestionType) ([]*model.PromptTemplate, error) {
+	return s.templateDAO.GetTemplates(subject, questionType)
+}
+
+// GetTemplate 获取模板及其全部版本
+func (s *PromptTemplateService) GetTemplate(templateID int64) (*model.PromptTemplate, []*model.PromptTemplateVersion, error) {
+	template, err := s.getTemplate(templateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	versions, err := s.templateDAO.GetVersions(templateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return template, versions, nil
+}
+
+// GetTemplateVersion 获取模板的指定版本，version 为0时获取当前版本
+func (s *PromptTemplateService) GetTemplateVersion(templateID int64, version int) (*model.PromptTemplate, *model.PromptTemplateVersion, error) {
+	template, err := s.getTemplate(templateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version == 0 {
+		version = template.Version
+	}
+
+	templateVersion, err := s.templateDAO.GetVersion(templateID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("%w: 版本 %d 不存在", ErrPromptTemplateNotFound, version)
+		}
+		return nil, nil, err
+	}
+	return template, templateVersion, nil
+}
+
+// ResolveTemplate 确定生成题目使用的模板版本
+// 未指定模板时使用该题型的默认模板，没有默认模板时返回 nil，表示使用内置模板
+func (s *PromptTemplateService) ResolveTemplate(templateID int64, version int, questionType model.QuestionType) (*model.PromptTemplate, *model.PromptTemplateVersion, error) {
+	if templateID == 0 {
+		template, err := s.templateDAO.GetDefaultTemplate(questionType)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, nil
+			}
+			return nil, nil, err
+		}
+		templateID, version = template.ID, 0
+	}
+
+	template, templateVersion, err := s.GetTemplateVersion(templateID, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if template.QuestionType != "" && template.QuestionType != questionType {
+		return nil, nil, fmt.Errorf("%w: 模板 %s 只适用于%s", ErrInvalidPromptTemplate, template.Name, questionTypeDesc(template.QuestionType))
+	}
+	return template, templateVersion, nil
+}
+
+// getTemplate 获取未删除的模板
+func (s *PromptTemplateService) getTemplate(templateID int64) (*model.PromptTemplate, error) {
+	template, err := s.templateDAO.GetTemplateByID(templateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromptTemplateNotFound
+		}
+		return nil, err
+	}
+	return template, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"strings"
+	"testing"
+)
+
+func TestPromptTemplateVersions(t *testing.T) {
+	s := NewPromptTemplateService(dao.NewPromptTemplateDAO(newTestDB(t)))
+
+	if err := s.CreateTemplate(&model.PromptTemplate{Name: "未知变量", CreatorID: 1}, "出{{.Total}}道题"); !errors.Is(err, ErrInvalidPromptTemplate) {
+		t.Fatalf("使用未知变量: err = %v", err)
+	}
+
+	template := &model.PromptTemplate{Name: "判断题", QuestionType: model.QuestionTypeJudge, IsDefault: true, CreatorID: 1}
+	if err := s.CreateTemplate(template, "请出{{.Count}}道关于{{.Topic}}的{{.TypeName}}"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateTemplate(1, template.ID, template, "请出{{.Count}}道{{.Language}}{{.TypeName}}，答案为 true 或 false"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未指定模板时使用该题型的默认模板的当前版本，旧版本仍可指定
+	_, current, err := s.ResolveTemplate(0, 0, model.QuestionTypeJudge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, first, err := s.ResolveTemplate(template.ID, 1, model.QuestionTypeJudge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Version != 2 || first.Version != 1 || !strings.Contains(first.Content, "{{.Topic}}") {
+		t.Fatalf("当前版本为 %d，指定的版本为 %d: %s", current.Version, first.Version, first.Content)
+	}
+
+	if _, _, err := s.ResolveTemplate(template.ID, 3, model.QuestionTypeJudge); !errors.Is(err, ErrPromptTemplateNotFound) {
+		t.Fatalf("版本不存在: err = %v", err)
+	}
+	if _, _, err := s.ResolveTemplate(template.ID, 0, model.QuestionTypeSingle); !errors.Is(err, ErrInvalidPromptTemplate) {
+		t.Fatalf("用于其他题型: err = %v", err)
+	}
+	if template, version, err := s.ResolveTemplate(0, 0, model.QuestionTypeSingle); err != nil || template != nil || version != nil {
+		t.Fatalf("没有默认模板时应使用内置模板: %v, %v", template, err)
+	}
+
+	// 模板中没有使用输出格式时自动附加在末尾
+	prompt, err := (&QuestionService{}).constructPrompt(GenerateRequest{Language: "Go", QuestionType: model.QuestionTypeJudge, Keywords: "通道"}, 3, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, format := questionFormat(model.QuestionTypeJudge)
+	if !strings.HasPrefix(prompt, "请出3道Go判断题") || !strings.Contains(prompt, format) {
+		t.Fatalf("prompt = %s", prompt)
+	}
+}
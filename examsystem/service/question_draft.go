@@ -0,0 +1,116 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"examsystem/dao/model"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDraftBatchNotFound    = errors.New("待确认的题目不存在，可能已确认或已过期")
+	ErrInvalidDraftSelection = errors.New("无效的题目选择")
+	ErrInvalidQuestionStatus = errors.New("无效的题目状态")
+)
+
+// newBatchID 生成随机的生成批次ID
+func newBatchID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// 系统随机数不可用时退化为时间戳，同一用户同一时刻的两次生成极少见
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// resolveDraftBatch 确定要确认的生成批次：指定了批次时直接使用，否则要求选中的题目都是同一批次的待确认题目
+func (s *QuestionService) resolveDraftBatch(userID int64, batchID string, selectedIDs []int64) (string, error) {
+	if batchID != "" {
+		return batchID, nil
+	}
+	if len(selectedIDs) == 0 {
+		return "", fmt.Errorf("%w: 未选择题目时需要指定批次", ErrInvalidDraftSelection)
+	}
+
+	questions, err := s.questionDAO.GetQuestionsByIDs(selectedIDs)
+	if err != nil {
+		return "", err
+	}
+	batches := make(map[string]bool)
+	for _, q := range questions {
+		if q.UserID == userID && q.Status == model.QuestionStatusDraft && !q.DeletedAt.Valid {
+			batches[q.BatchID] = true
+		}
+	}
+	switch {
+	case len(batches) == 0:
+		return "", ErrDraftBatchNotFound
+	case len(batches) > 1:
+		return "", fmt.Errorf("%w: 选中的题目属于多个生成批次，请分别确认", ErrInvalidDraftSelection)
+	}
+	for id := range batches {
+		batchID = id
+	}
+	return batchID, nil
+}
+
+// SetQuestionStatus 归档题库中的题目或将归档的题目放回题库，待确认的题目需要通过确认入库
+func (s *QuestionService) SetQuestionStatus(userID, questionID int64, status model.QuestionStatus) error {
+	if status != model.QuestionStatusActive && status != model.QuestionStatusArchived {
+		return fmt.Errorf("%w: %s", ErrInvalidQuestionStatus, status)
+	}
+
+	question, err := s.questionDAO.GetUndeletedQuestionByID(questionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrQuestionNotFound
+		}
+		return err
+	}
+	if question.UserID != userID {
+		return ErrQuestionNotFound
+	}
+	if question.Status == model.QuestionStatusDraft {
+		return fmt.Errorf("%w: 待确认的题目需要先确认", ErrInvalidQuestionStatus)
+	}
+
+	return s.questionDAO.UpdateQuestionsStatus([]int64{questionID}, status)
+}
+
+// RunDraftCleanupWorker 定期删除超过保留时间仍未确认的题目，直到 stop 关闭
+func (s *QuestionService) RunDraftCleanupWorker(stop <-chan struct{}) {
+	if s.genConfig.DraftTTL <= 0 || s.genConfig.DraftCleanupInterval <= 0 {
+		log.Printf("未配置待确认题目的保留时间，不清理过期的待确认题目")
+		return
+	}
+
+	ticker := time.NewTicker(s.genConfig.DraftCleanupInterval)
+	defer ticker.Stop()
+
+	s.cleanupExpiredDrafts()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.cleanupExpiredDrafts()
+		}
+	}
+}
+
+// cleanupExpiredDrafts 删除超过保留时间的待确认题目
+func (s *QuestionService) cleanupExpiredDrafts() {
+	count, err := s.questionDAO.DeleteExpiredDrafts(time.Now().Add(-s.genConfig.DraftTTL))
+	if err != nil {
+		log.Printf("清理过期的待确认题目失败: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("已清理%d道过期的待确认题目", count)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"examsystem/config"
+	"examsystem/dao/model"
+	"testing"
+	"time"
+)
+
+// generateDrafts 使用模拟模型生成一批待确认题目
+func generateDrafts(t *testing.T, s *QuestionService, numQuestions int) ([]*model.Question, string) {
+	t.Helper()
+	questions, report, err := s.GenerateQuestions(1, GenerateRequest{AIModel: "mock", Language: "Go", QuestionType: model.QuestionTypeSingle, NumQuestions: numQuestions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return questions, report.BatchID
+}
+
+func newTestDraftService(t *testing.T) *QuestionService {
+	t.Helper()
+	provider, err := NewMockProvider(config.AIProviderConfig{Name: "mock", Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTestQuestionService(t, provider, config.AIGenerationConfig{ChunkSize: 5, Concurrency: 1, MaxQuestions: 10, DraftTTL: 72 * time.Hour})
+}
+
+func TestSaveSelectedQuestions(t *testing.T) {
+	s := newTestDraftService(t)
+	first, firstBatch := generateDrafts(t, s, 3)
+	second, _ := generateDrafts(t, s, 2)
+
+	if _, err := s.SaveSelectedQuestions(1, "", []int64{first[0].ID, second[0].ID}, false); !errors.Is(err, ErrInvalidDraftSelection) {
+		t.Fatalf("选中多个批次的题目: err = %v", err)
+	}
+
+	result, err := s.SaveSelectedQuestions(1, "", []int64{first[1].ID}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BatchID != firstBatch || len(result.Confirmed) != 1 || result.Confirmed[0] != first[1].ID {
+		t.Fatalf("result = %+v", result)
+	}
+	if _, err := s.SaveSelectedQuestions(1, firstBatch, nil, false); !errors.Is(err, ErrDraftBatchNotFound) {
+		t.Fatalf("重复确认: err = %v", err)
+	}
+
+	// 选中的题目入库，同批次未选中的题目被删除，其他批次不受影响
+	want := map[int64]model.QuestionStatus{first[1].ID: model.QuestionStatusActive, second[0].ID: model.QuestionStatusDraft, second[1].ID: model.QuestionStatusDraft}
+	saved, err := s.questionDAO.GetQuestionsByIDs([]int64{first[0].ID, first[1].ID, first[2].ID, second[0].ID, second[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != len(want) {
+		t.Fatalf("剩余 %d 道题目，应为 %d 道", len(saved), len(want))
+	}
+	for _, q := range saved {
+		if q.Status != want[q.ID] {
+			t.Fatalf("题目 %d 的状态为 %s，应为 %s", q.ID, q.Status, want[q.ID])
+		}
+	}
+}
+
+func TestEditedDraftDoesNotExpire(t *testing.T) {
+	s := newTestDraftService(t)
+	drafts, _ := generateDrafts(t, s, 2)
+	expired, edited := drafts[0], drafts[1]
+	if err := s.questionDAO.DB.Model(expired).Update("created_at", time.Now().Add(-100*time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 编辑接口不传入创建时间
+	update := &model.Question{ID: edited.ID, UserID: 1, Title: "修改后的题目", QuestionType: model.QuestionTypeSingle, Options: edited.Options, Answer: edited.Answer}
+	if err := s.UpdateQuestion(update, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	s.cleanupExpiredDrafts()
+	remaining, err := s.questionDAO.GetQuestionsByIDs([]int64{expired.ID, edited.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != edited.ID || remaining[0].Status != model.QuestionStatusDraft || remaining[0].Title != "修改后的题目" {
+		t.Fatalf("清理后剩余 %+v，应只保留修改过的待确认题目", remaining)
+	}
+}
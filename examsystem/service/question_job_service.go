@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"examsystem/config"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrQuestionJobNotFound = errors.New("任务不存在")
+
+// defaultQuestionJobPollInterval 未配置或配置有误时检查等待中任务的间隔
+const defaultQuestionJobPollInterval = 5 * time.Second
+
+// QuestionJobService 在后台执行AI生成题目的任务
+// 任务保存在数据库中，服务重启后未完成的任务会重新执行
+type QuestionJobService struct {
+	jobDAO          *dao.QuestionJobDAO
+	questionDAO     *dao.QuestionDAO
+	questionService *QuestionService
+	config          config.QuestionJobConfig
+	notify          chan struct{} // 有新任务时唤醒空闲的执行者
+}
+
+// QuestionJobDetail 任务详情
+type QuestionJobDetail struct {
+	Job           *model.QuestionJob
+	QueuePosition int64                      // 排在该任务之前的等待中任务数量，仅等待中的任务有效
+	Questions     []*model.Question          // 生成的题目，仅已完成的任务有效
+	Similar       map[int64]*SimilarQuestion // 题目ID到题库中相似题目的映射，没有相似题目的不包含在内
+	Report        *GenerateReport            // 生成结果统计，仅已结束的任务有效
+}
+
+func NewQuestionJobService(jobDAO *dao.QuestionJobDAO, questionDAO *dao.QuestionDAO, questionService *QuestionService, jobConfig config.QuestionJobConfig) *QuestionJobService {
+	if jobConfig.Workers <= 0 {
+		jobConfig.Workers = 1
+	}
+	if jobConfig.PollInterval <= 0 {
+		log.Printf("生成任务检查间隔 %v 无效，使用默认值 %v", jobConfig.PollInterval, defaultQuestionJobPollInterval)
+		jobConfig.PollInterval = defaultQuestionJobPollInterval
+	}
+	return &QuestionJobService{
+		jobDAO:          jobDAO,
+		questionDAO:     questionDAO,
+		questionService: questionService,
+		config:          jobConfig,
+		notify:          make(chan struct{}, jobConfig.Workers),
+	}
+}
+
+// SubmitJob 校验参数并创建生成任务，任务由后台执行者异步执行
+func (s *QuestionJobService) SubmitJob(userID int64, req GenerateRequest) (*model.QuestionJob, error) {
+	if err := s.questionService.ValidateGenerateRequest(req); err != nil {
+		return nil, err
+	}
+
+	job := &model.QuestionJob{
+		UserID:           userID,
+		AIModel:          req.AIModel,
+		Language:         req.Language,
+		QuestionType:     req.QuestionType,
+		Keywords:         req.Keywords,
+		NumQuestions:     req.NumQuestions,
+		Difficulty:       req.Difficulty,
+		CognitiveLevel:   req.CognitiveLevel,
+		TemplateID:       req.TemplateID,
+		TemplateVersion:  req.TemplateVersion,
+		Review:           req.Review,
+		ReviewModel:      req.ReviewModel,
+		KnowledgePointID: req.KnowledgePointID,
+		BatchID:          newBatchID(),
+		Status:           model.QuestionJobStatusPending,
+	}
+	if err := s.jobDAO.CreateJob(job); err != nil {
+		return nil, fmt.Errorf("创建生成任务失败: %v", err)
+	}
+
+	// 唤醒空闲的执行者，执行者都在忙时由其完成当前任务后领取
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// GetJob 获取任务详情，只能查看自己创建的任务
+func (s *QuestionJobService) GetJob(userID, jobID int64) (*QuestionJobDetail, error) {
+	job, err := s.jobDAO.GetJobByID(jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuestionJobNotFound
+		}
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrQuestionJobNotFound
+	}
+
+	detail := &QuestionJobDetail{Job: job}
+	if job.Report != "" {
+		detail.Report = &GenerateReport{}
+		if err := json.Unmarshal([]byte(job.Report), detail.Report); err != nil {
+			return nil, fmt.Errorf("解析任务结果统计失败: %v", err)
+		}
+	}
+	switch job.Status {
+	case model.QuestionJobStatusPending:
+		if detail.QueuePosition, err = s.jobDAO.CountPendingBefore(job.ID); err != nil {
+			return nil, err
+		}
+	case model.QuestionJobStatusSucceeded:
+		var ids []int64
+		if job.QuestionIDs != "" {
+			if err := json.Unmarshal([]byte(job.QuestionIDs), &ids); err != nil {
+				return nil, fmt.Errorf("解析任务题目失败: %v", err)
+			}
+		}
+		questions, err := s.questionDAO.GetQuestionsByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		// 按生成顺序返回，已确认后被清除的题目不再返回
+		byID := make(map[int64]*model.Question, len(questions))
+		for _, q := range questions {
+			byID[q.ID] = q
+		}
+		for _, id := range ids {
+			if q, ok := byID[id]; ok {
+				detail.Questions = append(detail.Questions, q)
+			}
+		}
+		if detail.Similar, err = s.questionService.FindSimilarQuestions(userID, detail.Questions); err != nil {
+			return nil, err
+		}
+	}
+
+	return detail, nil
+}
+
+// RunWorkers 启动执行者执行生成任务，直到 stop 被关闭
+// 启动时先将上次服务退出时仍在执行中的任务重新置为等待中，失败时不启动执行者
+func (s *QuestionJobService) RunWorkers(stop <-chan struct{}) error {
+	requeued, err := s.jobDAO.RequeueRunningJobs()
+	if err != nil {
+		return fmt.Errorf("恢复中断的生成任务失败: %v", err)
+	}
+	if requeued > 0 {
+		log.Printf("已恢复 %d 个中断的生成任务", requeued)
+	}
+
+	for i := 0; i < s.config.Workers; i++ {
+		go s.runWorker(stop)
+	}
+	return nil
+}
+
+// runWorker 依次领取并执行等待中的任务，没有任务时等待新任务通知或定期检查
+func (s *QuestionJobService) runWorker(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := s.jobDAO.ClaimNextJob(time.Now())
+		if err != nil {
+			log.Printf("领取生成任务失败: %v", err)
+		}
+		if job != nil {
+			s.runJob(job)
+			continue
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-s.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob 执行生成任务并记录结果
+func (s *QuestionJobService) runJob(job *model.QuestionJob) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("生成任务 %d 异常: %v", job.ID, p)
+			s.finishJob(job, nil, nil, fmt.Errorf("任务执行异常: %v", p))
+		}
+	}()
+
+	// 多次执行都因服务退出而中断的任务不再重试
+	if s.config.MaxAttempts > 0 && job.Attempts > s.config.MaxAttempts {
+		s.finishJob(job, nil, nil, fmt.Errorf("任务已中断 %d 次，不再重试", job.Attempts-1))
+		return
+	}
+
+	log.Printf("开始执行生成任务 %d (第 %d 次)", job.ID, job.Attempts)
+	questions, report, err := s.questionService.generate(context.Background(), job.UserID, GenerateRequest{
+		AIModel:          job.AIModel,
+		Language:         job.Language,
+		QuestionType:     job.QuestionType,
+		Keywords:         job.Keywords,
+		NumQuestions:     job.NumQuestions,
+		Difficulty:       job.Difficulty,
+		CognitiveLevel:   job.CognitiveLevel,
+		TemplateID:       job.TemplateID,
+		TemplateVersion:  job.TemplateVersion,
+		Review:           job.Review,
+		ReviewModel:      job.ReviewModel,
+		KnowledgePointID: job.KnowledgePointID,
+		BatchID:          job.BatchID,
+	}, nil)
+	s.finishJob(job, questions, report, err)
+}
+
+// finishJob 保存任务的执行结果，生成的题目与任务状态在同一事务中保存，
+// 保存前服务退出时任务会重新执行，不会留下重复的题目
+func (s *QuestionJobService) finishJob(job *model.QuestionJob, questions []*model.Question, report *GenerateReport, jobErr error) {
+	now := time.Now()
+	reportJSON := ""
+	if report != nil {
+		data, _ := json.Marshal(report)
+		reportJSON = string(data)
+	}
+
+	if jobErr != nil {
+		log.Printf("生成任务 %d 失败: %v", job.ID, jobErr)
+		if err := s.jobDAO.MarkFailed(job.ID, jobErr.Error(), reportJSON, now); err != nil {
+			log.Printf("保存生成任务 %d 结果失败: %v", job.ID, err)
+		}
+		return
+	}
+
+	if err := s.jobDAO.MarkSucceeded(job.ID, job.BatchID, questions, knowledgePointIDs(job.KnowledgePointID), reportJSON, now); err != nil {
+		log.Printf("保存生成任务 %d 结果失败: %v", job.ID, err)
+		return
+	}
+	log.Printf("生成任务 %d 完成，共生成 %d 道题目", job.ID, len(questions))
+}
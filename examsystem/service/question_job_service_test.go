@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"examsystem/config"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"testing"
+	"time"
+)
+
+// newTestQuestionJobService 创建使用模拟模型的生成任务服务
+func newTestQuestionJobService(t *testing.T, jobConfig config.QuestionJobConfig) *QuestionJobService {
+	t.Helper()
+	db := newTestDB(t)
+	questionDAO := dao.NewQuestionDAO(db)
+	registry := NewAIProviderRegistry(config.AIConfig{Providers: []config.AIProviderConfig{{Name: "mock", Type: config.AIProviderTypeMock, Seed: 1}}})
+	return NewQuestionJobService(dao.NewQuestionJobDAO(db), questionDAO, NewQuestionService(questionDAO, registry, config.AIGenerationConfig{ChunkSize: 5, Concurrency: 1, MaxQuestions: 20}, NewPromptTemplateService(dao.NewPromptTemplateDAO(db)), NewKnowledgeService(dao.NewKnowledgeDAO(db), questionDAO)), jobConfig)
+}
+
+// waitForJob 等待任务结束
+func waitForJob(t *testing.T, s *QuestionJobService, jobID int64) *QuestionJobDetail {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		detail, err := s.GetJob(1, jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detail.Job.Status == model.QuestionJobStatusSucceeded || detail.Job.Status == model.QuestionJobStatusFailed {
+			return detail
+		}
+	}
+	t.Fatalf("任务 %d 没有在规定时间内结束", jobID)
+	return nil
+}
+
+func TestSubmitQuestionJob(t *testing.T) {
+	// 检查间隔未配置时使用默认值
+	s := newTestQuestionJobService(t, config.QuestionJobConfig{Workers: 1})
+
+	tests := []struct {
+		name         string
+		aiModel      string
+		questionType model.QuestionType
+		count        int
+		want         error
+	}{
+		{"无效的题型", "mock", "essay", 3, ErrInvalidQuestionType},
+		{"数量为0", "mock", model.QuestionTypeSingle, 0, ErrInvalidQuestionCount},
+		{"未配置的模型", "gpt", model.QuestionTypeSingle, 3, ErrUnknownAIModel},
+	}
+	for _, tt := range tests {
+		if _, err := s.SubmitJob(1, GenerateRequest{AIModel: tt.aiModel, Language: "Go", QuestionType: tt.questionType, NumQuestions: tt.count}); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v，应为 %v", tt.name, err, tt.want)
+		}
+	}
+
+	job, err := s.SubmitJob(1, GenerateRequest{AIModel: "mock", Language: "Go", QuestionType: model.QuestionTypeSingle, Keywords: "切片", NumQuestions: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := s.RunWorkers(stop); err != nil {
+		t.Fatal(err)
+	}
+
+	detail := waitForJob(t, s, job.ID)
+	if detail.Job.Status != model.QuestionJobStatusSucceeded || len(detail.Questions) != 3 {
+		t.Fatalf("任务状态为 %s，生成 %d 道题目，错误: %s", detail.Job.Status, len(detail.Questions), detail.Job.Error)
+	}
+	if _, err := s.GetJob(2, job.ID); !errors.Is(err, ErrQuestionJobNotFound) {
+		t.Fatalf("查看他人的任务: err = %v", err)
+	}
+}
+
+func TestRunWorkersResumesInterruptedJobs(t *testing.T) {
+	s := newTestQuestionJobService(t, config.QuestionJobConfig{Workers: 2, PollInterval: time.Second, MaxAttempts: 2})
+
+	// 上次服务退出时正在执行的任务
+	resumed := &model.QuestionJob{UserID: 1, AIModel: "mock", Language: "Go", QuestionType: model.QuestionTypeJudge, NumQuestions: 2, BatchID: "resumed", Status: model.QuestionJobStatusRunning, Attempts: 1}
+	exhausted := &model.QuestionJob{UserID: 1, AIModel: "mock", Language: "Go", QuestionType: model.QuestionTypeJudge, NumQuestions: 2, Status: model.QuestionJobStatusRunning, Attempts: 2}
+	for _, job := range []*model.QuestionJob{resumed, exhausted} {
+		if err := s.jobDAO.CreateJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 上次执行留下的同一批次的待确认题目
+	stale := &model.Question{UserID: 1, Title: "上次生成的题目", QuestionType: model.QuestionTypeJudge, Options: "[]", Answer: "true", Status: model.QuestionStatusDraft, BatchID: "resumed"}
+	if err := s.jobDAO.DB.Create(stale).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := s.RunWorkers(stop); err != nil {
+		t.Fatal(err)
+	}
+
+	if detail := waitForJob(t, s, resumed.ID); detail.Job.Status != model.QuestionJobStatusSucceeded || detail.Job.Attempts != 2 || len(detail.Questions) != 2 {
+		t.Fatalf("中断的任务重新执行后状态为 %s，执行 %d 次，生成 %d 道题目", detail.Job.Status, detail.Job.Attempts, len(detail.Questions))
+	}
+	if detail := waitForJob(t, s, exhausted.ID); detail.Job.Status != model.QuestionJobStatusFailed {
+		t.Fatalf("超过最大执行次数的任务状态为 %s", detail.Job.Status)
+	}
+
+	// 生成的题目与任务结果一起保存，上次执行留下的题目被替换，没有多余的题目
+	var count int64
+	s.jobDAO.DB.Unscoped().Model(&model.Question{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("共保存 %d 道题目，应为 2", count)
+	}
+	// 生成的题目同时写入检索索引
+	s.jobDAO.DB.Table("questions_fts").Count(&count)
+	if count != 2 {
+		t.Fatalf("检索索引中有 %d 道题目，应为 2", count)
+	}
+}
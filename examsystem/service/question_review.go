@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"examsystem/dao/model"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// 审核发现的问题类型
+const (
+	ReviewIssueWrongAnswer      = "wrong_answer"      // 标准答案错误
+	ReviewIssueAmbiguous        = "ambiguous"         // 题干有歧义或存在多个合理答案
+	ReviewIssueDuplicateOptions = "duplicate_options" // 选项重复或含义相同
+	ReviewIssueOther            = "other"             // 其他问题
+)
+
+// ReviewIssue 审核发现的一个问题
+type ReviewIssue struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+// aiReview AI返回的一道题目的审核结果
+type aiReview struct {
+	Index      int           `json:"index"`
+	Verdict    string        `json:"verdict"`
+	Issues     []ReviewIssue `json:"issues"`
+	Suggestion string        `json:"suggestion"`
+}
+
+// reviewModelName 确定审核使用的AI模型，返回空字符串表示不审核
+// 请求中指定审核模型时总是审核；只要求审核时使用配置的审核模型，未配置时使用生成题目的模型
+func (s *QuestionService) reviewModelName(req GenerateRequest) string {
+	switch {
+	case req.ReviewModel != "":
+		return req.ReviewModel
+	case !req.Review:
+		return ""
+	case s.genConfig.ReviewModel != "":
+		return s.genConfig.ReviewModel
+	default:
+		return req.AIModel
+	}
+}
+
+// reviewQuestions 使用 reviewer 分批审核题目，将审核结论写入题目
+// 审核失败不影响题目的保存，失败批次的题目保持未审核状态，原因通过返回的警告说明
+func (s *QuestionService) reviewQuestions(ctx context.Context, reviewer AIProvider, genReq GenerateRequest, questions []*model.Question) []string {
+	var batches [][]*model.Question
+	for _, count := range splitQuestionCount(len(questions), s.genConfig.ChunkSize) {
+		batches = append(batches, questions[:count])
+		questions = questions[count:]
+	}
+
+	warnings := make([]string, len(batches))
+	sem := make(chan struct{}, s.genConfig.Concurrency)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []*model.Question) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			warning, err := s.reviewBatch(ctx, reviewer, genReq, batch)
+			if err != nil {
+				log.Printf("第 %d/%d 批题目审核失败: %v", i+1, len(batches), err)
+				warning = fmt.Sprintf("审核失败: %v", err)
+			}
+			if warning != "" {
+				warnings[i] = fmt.Sprintf("审核第%d批: %s", i+1, warning)
+			}
+		}(i, batch)
+	}
+	wg.Wait()
+
+	var result []string
+	for _, warning := range warnings {
+		if warning != "" {
+			result = append(result, warning)
+		}
+	}
+	return result
+}
+
+// reviewBatch 请求审核一批题目，返回的题目审核结果不完整时通过 warning 说明
+func (s *QuestionService) reviewBatch(ctx context.Context, reviewer AIProvider, genReq GenerateRequest, batch []*model.Question) (string, error) {
+	prompt, err := reviewPrompt(genReq, batch)
+	if err != nil {
+		return "", err
+	}
+	req := AIRequest{
+		Prompt:       prompt,
+		QuestionType: genReq.QuestionType,
+		NumQuestions: len(batch),
+		Language:     genReq.Language,
+		Keywords:     genReq.Keywords,
+		Review:       batch,
+	}
+
+	content, err := s.callWithRetry(ctx, func(ctx context.Context) (string, error) {
+		return reviewer.Generate(ctx, req)
+	}, func() bool {
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	items, warning, err := extractAIArray(content, "reviews")
+	if err != nil {
+		log.Printf("AI审核内容解析失败: %v, 内容: %s", err, content)
+		return "", err
+	}
+
+	reviewed := 0
+	for _, raw := range items {
+		var review aiReview
+		if err := json.Unmarshal(raw, &review); err != nil || review.Index < 1 || review.Index > len(batch) {
+			continue
+		}
+		question := batch[review.Index-1]
+		if question.ReviewVerdict != "" {
+			continue
+		}
+		applyReview(question, review, reviewer.Name())
+		reviewed++
+	}
+
+	if reviewed < len(batch) {
+		missing := fmt.Sprintf("%d道题目没有审核结果", len(batch)-reviewed)
+		if warning != "" {
+			return warning + "，" + missing, nil
+		}
+		return missing, nil
+	}
+	return warning, nil
+}
+
+// applyReview 将审核结果写入题目：有问题或结论不是通过时标记为需要人工检查
+func applyReview(question *model.Question, review aiReview, reviewModel string) {
+	issues := make([]ReviewIssue, 0, len(review.Issues))
+	for _, issue := range review.Issues {
+		issue.Type = normalizeReviewIssueType(issue.Type)
+		issue.Detail = strings.TrimSpace(issue.Detail)
+		issues = append(issues, issue)
+	}
+
+	question.ReviewVerdict = model.ReviewVerdictPass
+	if len(issues) > 0 || !isPassVerdict(review.Verdict) {
+		question.ReviewVerdict = model.ReviewVerdictFlagged
+	}
+	issuesJSON, _ := json.Marshal(issues)
+	question.ReviewIssues = string(issuesJSON)
+	question.ReviewSuggestion = strings.TrimSpace(review.Suggestion)
+	question.ReviewModel = reviewModel
+}
+
+// isPassVerdict 判断模型给出的结论是否为通过
+func isPassVerdict(verdict string) bool {
+	switch strings.ToLower(strings.TrimSpace(verdict)) {
+	case "pass", "passed", "ok", "通过", "无问题":
+		return true
+	}
+	return false
+}
+
+// normalizeReviewIssueType 规范问题类型，无法识别的类型记为 other
+func normalizeReviewIssueType(issueType string) string {
+	issueType = strings.ToLower(strings.TrimSpace(issueType))
+	switch issueType {
+	case ReviewIssueWrongAnswer, ReviewIssueAmbiguous, ReviewIssueDuplicateOptions:
+		return issueType
+	}
+	return ReviewIssueOther
+}
+
+// ParseReviewIssues 解析题目保存的审核问题，未审核或格式错误时返回 nil
+func ParseReviewIssues(question *model.Question) []ReviewIssue {
+	var issues []ReviewIssue
+	if question.ReviewIssues != "" {
+		json.Unmarshal([]byte(question.ReviewIssues), &issues)
+	}
+	return issues
+}
+
+// reviewPrompt 构造审核提示语，题目以JSON列出，index 从1开始
+func reviewPrompt(genReq GenerateRequest, batch []*model.Question) (string, error) {
+	type reviewItem struct {
+		Index       int             `json:"index"`
+		Title       string          `json:"title"`
+		Options     json.RawMessage `json:"options,omitempty"`
+		Answer      string          `json:"answer"`
+		Explanation string          `json:"explanation,omitempty"`
+		TestCases   json.RawMessage `json:"test_cases,omitempty"`
+	}
+	items := make([]reviewItem, 0, len(batch))
+	for i, question := range batch {
+		item := reviewItem{Index: i + 1, Title: question.Title, Answer: question.Answer, Explanation: question.Explanation}
+		if question.Options != "" && question.Options != "[]" {
+			item.Options = json.RawMessage(question.Options)
+		}
+		if question.TestCases != "" {
+			item.TestCases = json.RawMessage(question.TestCases)
+		}
+		items = append(items, item)
+	}
+	itemsJSON, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`    你是一名严谨的%s审核老师，请逐题检查以下%d道%s：
+    1. wrong_answer：标准答案是否正确（选择题的答案为正确选项的字母）；
+    2. ambiguous：题干是否有歧义，或存在多个合理的答案；
+    3. duplicate_options：是否有重复或含义相同的选项；
+    其他问题记为 other。
+    题目：
+%s
+    请严格按照以下JSON格式输出，每道题目一项，index 与题目的 index 对应；没有问题时 verdict 为 pass、issues 为空数组，有问题时 verdict 为 flagged，并在 suggestion 中给出修改建议：
+    {
+        "reviews": [
+            {
+                "index": 1,
+                "verdict": "flagged",
+                "issues": [{"type": "wrong_answer", "detail": "问题说明"}],
+                "suggestion": "修改建议"
+            }
+        ]
+    }
+`, genReq.Language, len(batch), questionTypeDesc(genReq.QuestionType), itemsJSON), nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"examsystem/config"
+	"examsystem/dao/model"
+	"strings"
+	"testing"
+)
+
+// staticTestProvider 总是返回固定内容的AI模型
+type staticTestProvider struct {
+	content string
+}
+
+func (p *staticTestProvider) Name() string                 { return "static" }
+func (p *staticTestProvider) Model() string                { return "static" }
+func (p *staticTestProvider) Capabilities() AICapabilities { return AICapabilities{MaxTokens: 2000} }
+
+func (p *staticTestProvider) Generate(ctx context.Context, req AIRequest) (string, error) {
+	return p.content, nil
+}
+
+func TestReviewBatch(t *testing.T) {
+	reviewer := &staticTestProvider{content: "审核结果如下：\n```json\n" + `{"reviews": [
+		{"index": 1, "verdict": "通过", "issues": []},
+		{"index": 2, "verdict": "pass", "issues": [{"type": "Wrong_Answer", "detail": " 答案应为B "}], "suggestion": "改为B"},
+		{"index": 3, "verdict": "unsure", "issues": [{"type": "typo", "detail": "错别字"}]},
+		{"index": 9, "verdict": "pass", "issues": []}
+	]}` + "\n```"}
+	s := &QuestionService{genConfig: config.AIGenerationConfig{ChunkSize: 5, Concurrency: 1}}
+
+	batch := make([]*model.Question, 4)
+	for i := range batch {
+		batch[i] = &model.Question{Title: "题目", QuestionType: model.QuestionTypeSingle, Options: `["a", "b", "c", "d"]`, Answer: "A"}
+	}
+	warning, err := s.reviewBatch(context.Background(), reviewer, GenerateRequest{Language: "Go", QuestionType: model.QuestionTypeSingle}, batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(warning, "1道题目没有审核结果") {
+		t.Fatalf("warning = %q", warning)
+	}
+
+	if batch[0].ReviewVerdict != model.ReviewVerdictPass || batch[0].ReviewModel != "static" {
+		t.Fatalf("第1道题目: %s %s", batch[0].ReviewVerdict, batch[0].ReviewModel)
+	}
+	// 结论为通过但列出了问题时仍需人工检查
+	if issues := ParseReviewIssues(batch[1]); batch[1].ReviewVerdict != model.ReviewVerdictFlagged || len(issues) != 1 ||
+		issues[0].Type != ReviewIssueWrongAnswer || issues[0].Detail != "答案应为B" || batch[1].ReviewSuggestion != "改为B" {
+		t.Fatalf("第2道题目: %s %+v %q", batch[1].ReviewVerdict, issues, batch[1].ReviewSuggestion)
+	}
+	if issues := ParseReviewIssues(batch[2]); batch[2].ReviewVerdict != model.ReviewVerdictFlagged || issues[0].Type != ReviewIssueOther {
+		t.Fatalf("第3道题目: %s %+v", batch[2].ReviewVerdict, issues)
+	}
+	if batch[3].ReviewVerdict != "" || ParseReviewIssues(batch[3]) != nil {
+		t.Fatalf("没有审核结果的题目应保持未审核，实际为 %s", batch[3].ReviewVerdict)
+	}
+}
+
+func TestGenerateQuestionsWithReview(t *testing.T) {
+	provider, err := NewMockProvider(config.AIProviderConfig{Name: "mock", Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestQuestionService(t, provider, config.AIGenerationConfig{ChunkSize: 5, Concurrency: 1, MaxQuestions: 10})
+
+	questions, report, err := s.GenerateQuestions(1, GenerateRequest{AIModel: "mock", Language: "Go", QuestionType: model.QuestionTypeSingle, NumQuestions: 3, Review: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ReviewModel != "mock" || report.Reviewed != len(questions) {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// 审核结论随待确认题目一起保存
+	saved, err := s.questionDAO.GetQuestionsByIDs([]int64{questions[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].ReviewVerdict != questions[0].ReviewVerdict || saved[0].ReviewModel != "mock" {
+		t.Fatalf("保存的题目为 %+v", saved)
+	}
+}
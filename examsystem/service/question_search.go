@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var ErrEmptySearchQuery = errors.New("检索内容不能为空")
+
+// 摘要中命中文字的标记，摘要的其他部分经过HTML转义
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+	snippetRadius  = 30 // 摘要在第一处命中前后保留的字数
+)
+
+// QuestionSearchResult 全文检索的一条结果
+type QuestionSearchResult struct {
+	Question   *model.Question
+	Score      float64           // 相关度，越大越相关
+	Highlights map[string]string // 命中的字段（title、options、explanation、keywords）及标出命中文字的摘要
+}
+
+// SearchQuestions 在用户的题目中全文检索，按相关度排序分页返回，以及符合条件的总数
+func (s *QuestionService) SearchQuestions(userID int64, text string, filter QuestionFilter, page, pageSize int) ([]*QuestionSearchResult, int64, error) {
+	terms := dao.SearchTerms(text)
+	if len(terms) == 0 {
+		return nil, 0, ErrEmptySearchQuery
+	}
+	if err := s.validateQuestionFilter(filter); err != nil {
+		return nil, 0, err
+	}
+
+	hits, total, err := s.questionDAO.SearchQuestions(userID, text, filter, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]*QuestionSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		question := hit.Question
+		highlights := make(map[string]string)
+		fields := map[string]string{
+			"title":       question.Title,
+			"options":     dao.QuestionOptionsText(question.Options),
+			"explanation": question.Explanation,
+			"keywords":    question.Keywords,
+		}
+		for name, value := range fields {
+			if snippet := highlightSnippet(value, terms); snippet != "" {
+				highlights[name] = snippet
+			}
+		}
+		results = append(results, &QuestionSearchResult{
+			Question:   &question,
+			Score:      hit.Score,
+			Highlights: highlights,
+		})
+	}
+	return results, total, nil
+}
+
+// highlightSnippet 在文本中标出检索词（忽略大小写），返回第一处命中附近的摘要，没有命中时返回空字符串
+func highlightSnippet(text string, terms []string) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// 找出所有命中的区间并合并重叠的部分
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) == term {
+				spans = append(spans, span{i, i + len(t)})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return ""
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	merged := spans[:1]
+	for _, sp := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if sp.start <= last.end {
+			if sp.end > last.end {
+				last.end = sp.end
+			}
+			continue
+		}
+		merged = append(merged, sp)
+	}
+
+	from := merged[0].start - snippetRadius
+	if from < 0 {
+		from = 0
+	}
+	to := merged[0].end + snippetRadius
+	if to > len(runes) {
+		to = len(runes)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, sp := range merged {
+		if sp.start >= to {
+			break
+		}
+		if sp.end <= from {
+			continue
+		}
+		start, end := sp.start, sp.end
+		if start < from {
+			start = from
+		}
+		if end > to {
+			end = to
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:start])))
+		b.WriteString(highlightStart)
+		b.WriteString(html.EscapeString(string(runes[start:end])))
+		b.WriteString(highlightEnd)
+		pos = end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -1,240 +1,705 @@
 package service
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"examsystem/config"
 	"examsystem/dao"
 	"examsystem/dao/model"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidQuestionType  = errors.New("无效的题目类型")
+	ErrInvalidQuestionCount = errors.New("无效的题目数量")
+	ErrInvalidDifficulty    = errors.New("无效的难度")
+	ErrInvalidCognitive     = errors.New("无效的认知层次")
+	ErrInvalidQuestion      = errors.New("题目校验失败")
+	ErrQuestionNotFound     = errors.New("题目不存在")
+	ErrInvalidQuestionSort  = errors.New("无效的排序字段")
 )
 
+// QuestionFilter 题目列表的筛选条件，零值表示不按该条件筛选
+type QuestionFilter = dao.QuestionFilter
+
+// QuestionSort 题目列表的排序方式
+type QuestionSort = dao.QuestionSort
+
+// GenerateRequest 生成题目的参数
+type GenerateRequest struct {
+	AIModel          string
+	Language         string
+	QuestionType     model.QuestionType
+	Keywords         string
+	NumQuestions     int
+	Difficulty       int                  // 目标难度 1-5，0表示不指定
+	CognitiveLevel   model.CognitiveLevel // 目标认知层次，为空表示不指定
+	TemplateID       int64                // 提示语模板，0表示使用该题型的默认模板
+	TemplateVersion  int                  // 模板版本，0表示当前版本
+	Review           bool                 // 是否对生成的题目进行AI审核
+	ReviewModel      string               // 审核使用的AI模型，指定时总是审核；为空时使用配置的审核模型或生成题目的模型
+	KnowledgePointID int64                // 考查的知识点，生成的题目自动关联该知识点，0表示不指定
+	BatchID          string               // 生成批次，为空时使用新的批次
+
+	knowledgePath string // 知识点从学科开始的完整路径，生成时根据 KnowledgePointID 填写
+}
+
 type QuestionService struct {
-	questionDAO *dao.QuestionDAO
-	aiConfig    config.AIConfig
+	questionDAO     *dao.QuestionDAO
+	aiProviders     *AIProviderRegistry
+	genConfig       config.AIGenerationConfig
+	promptTemplates *PromptTemplateService
+	knowledge       *KnowledgeService
 }
 
-func NewQuestionService(questionDAO *dao.QuestionDAO, aiConfig config.AIConfig) *QuestionService {
+func NewQuestionService(questionDAO *dao.QuestionDAO, aiProviders *AIProviderRegistry, genConfig config.AIGenerationConfig, promptTemplates *PromptTemplateService, knowledge *KnowledgeService) *QuestionService {
+	if genConfig.Concurrency <= 0 {
+		genConfig.Concurrency = 1
+	}
 	return &QuestionService{
-		questionDAO: questionDAO,
-		aiConfig:    aiConfig,
+		questionDAO:     questionDAO,
+		aiProviders:     aiProviders,
+		genConfig:       genConfig,
+		promptTemplates: promptTemplates,
+		knowledge:       knowledge,
 	}
 }
 
-// GenerateQuestions 生成题目
-func (s *QuestionService) GenerateQuestions(userID int64, aiModel, language string, questionType model.QuestionType, keywords string, numQuestions int) ([]*model.Question, error) {
+// GetAIProviders 获取可用的AI模型
+func (s *QuestionService) GetAIProviders() []AIProvider {
+	return s.aiProviders.Providers()
+}
+
+// ValidateGenerateRequest 校验生成题目的参数，用于在创建生成任务前尽早返回错误
+func (s *QuestionService) ValidateGenerateRequest(req GenerateRequest) error {
 	// 验证题目类型
-	if questionType != model.QuestionTypeSingle && questionType != model.QuestionTypeMultiple {
-		return nil, fmt.Errorf("无效的题目类型: %s", questionType)
+	if !IsValidQuestionType(req.QuestionType) {
+		return fmt.Errorf("%w: %s", ErrInvalidQuestionType, req.QuestionType)
+	}
+	if req.NumQuestions <= 0 {
+		return fmt.Errorf("%w: 至少生成1道题目", ErrInvalidQuestionCount)
+	}
+	if s.genConfig.MaxQuestions > 0 && req.NumQuestions > s.genConfig.MaxQuestions {
+		return fmt.Errorf("%w: 单次最多生成%d道题目", ErrInvalidQuestionCount, s.genConfig.MaxQuestions)
+	}
+	if req.Difficulty != 0 && !IsValidDifficulty(req.Difficulty) {
+		return fmt.Errorf("%w: 难度应为%d-%d", ErrInvalidDifficulty, MinDifficulty, MaxDifficulty)
+	}
+	if req.CognitiveLevel != "" && !IsValidCognitiveLevel(req.CognitiveLevel) {
+		return fmt.Errorf("%w: %s", ErrInvalidCognitive, req.CognitiveLevel)
 	}
 
-	// 构造提示语
-	prompt := s.constructPrompt(aiModel, language, string(questionType), keywords, numQuestions)
+	// 未配置的AI模型直接报错，不发起请求
+	if _, err := s.aiProviders.Get(req.AIModel); err != nil {
+		return err
+	}
+	if reviewModel := s.reviewModelName(req); reviewModel != "" {
+		if _, err := s.aiProviders.Get(reviewModel); err != nil {
+			return fmt.Errorf("审核模型: %w", err)
+		}
+	}
+	if req.TemplateID != 0 {
+		if _, _, err := s.promptTemplates.ResolveTemplate(req.TemplateID, req.TemplateVersion, req.QuestionType); err != nil {
+			return err
+		}
+	}
+	if req.KnowledgePointID != 0 {
+		if _, err := s.knowledge.ResolveKnowledgePoints([]int64{req.KnowledgePointID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// 获取API密钥和URL
-	apiKey := s.getAPIKey(aiModel)
-	url := s.getAPIURL(aiModel)
+// IsGenerateRequestError 判断是否为生成参数错误
+func IsGenerateRequestError(err error) bool {
+	return errors.Is(err, ErrUnknownAIModel) || errors.Is(err, ErrInvalidQuestionType) || errors.Is(err, ErrInvalidQuestionCount) ||
+		errors.Is(err, ErrInvalidDifficulty) || errors.Is(err, ErrInvalidCognitive) ||
+		errors.Is(err, ErrPromptTemplateNotFound) || errors.Is(err, ErrInvalidPromptTemplate) ||
+		errors.Is(err, ErrKnowledgeNodeNotFound) || errors.Is(err, ErrInvalidKnowledgeNode)
+}
 
-	log.Printf("AI模型: %s, API Key: %s, URL: %s", aiModel, apiKey, url)
+// GenerateReport 一次生成的结果统计，部分批次失败时仍保存成功的题目
+type GenerateReport struct {
+	Requested    int                 `json:"requested"`    // 请求生成的题目数量
+	Generated    int                 `json:"generated"`    // 通过校验并保存的题目数量
+	Duplicates   int                 `json:"duplicates"`   // 与本次生成的其他题目重复或相似而被移除的数量
+	Similar      int                 `json:"similar"`      // 与题库中已有题目相似的题目数量，这些题目仍会保存
+	Chunks       int                 `json:"chunks"`       // 拆分的请求批次数
+	FailedChunks int                 `json:"failedChunks"` // 失败的批次数
+	Errors       []string            `json:"errors"`       // 失败批次的错误信息
+	Warnings     []string            `json:"warnings"`     // 修复了格式问题或输出被截断等情况的说明
+	Rejected     []QuestionRejection `json:"rejected"`     // 未被采用的题目及原因，包括重复的题目
+	BatchID      string              `json:"batchId"`      // 生成批次，确认题目时使用
+	Template     *PromptTemplateRef  `json:"template"`     // 使用的提示语模板，为空表示使用内置模板
+	ReviewModel  string              `json:"reviewModel"`  // 审核使用的AI模型，为空表示未审核
+	Reviewed     int                 `json:"reviewed"`     // 得到审核结果的题目数量
+	Flagged      int                 `json:"flagged"`      // 审核发现问题的题目数量
+}
 
-	// 调用AI API
-	questions, err := s.callAIAPI(url, apiKey, prompt)
-	if err != nil {
-		return nil, err
-	}
+// PromptTemplateRef 生成时使用的提示语模板版本
+type PromptTemplateRef struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
 
-	// 设置元信息并准备逻辑删除状态
-	// now := time.Now()
-	for _, question := range questions {
-		question.UserID = userID
-		question.AIModel = aiModel
-		question.Language = language
-		question.Keywords = keywords
-		question.DeletedAt.Time = time.Now()
-		question.DeletedAt.Valid = true
-	}
+// GenerateCallbacks 流式生成的回调，同一次生成中的回调不会并发执行
+type GenerateCallbacks struct {
+	OnQuestion func(question *model.Question, similar *SimilarQuestion) // 收下一道通过校验的题目，similar 为题库中相似的题目
+	OnRejected func(rejection QuestionRejection)                        // 一道题目未被采用
+	OnReviewed func(question *model.Question)                           // 一道已推送的题目得到审核结果，在全部题目生成后回调
+}
 
-	// 保存题目到数据库（逻辑保存）
-	if err := s.questionDAO.BatchCreateQuestions(questions); err != nil {
-		return nil, fmt.Errorf("保存题目失败: %v", err)
-	}
+// GenerateQuestions 生成题目并保存为待确认的题目
+// 题目数量超过单次请求的上限时拆分为多个批次并发请求，合并去重后保存，只有全部批次都失败时才返回错误
+func (s *QuestionService) GenerateQuestions(userID int64, req GenerateRequest) ([]*model.Question, *GenerateReport, error) {
+	questions, report, err := s.generate(context.Background(), userID, req, nil)
+	return s.saveDrafts(req, questions, report, err)
+}
 
-	return questions, nil
+// GenerateQuestionsStream 以流式方式生成题目，每从模型的部分输出中解析出一道题目就回调一次，
+// 生成结束后将题目保存为待确认题目。不支持流式输出的模型在每个批次生成完成后依次回调该批次的题目。
+// 分批生成时按批次顺序回调，后面批次的题目在前面的批次结束后才回调
+func (s *QuestionService) GenerateQuestionsStream(ctx context.Context, userID int64, req GenerateRequest, callbacks GenerateCallbacks) ([]*model.Question, *GenerateReport, error) {
+	questions, report, err := s.generate(ctx, userID, req, &callbacks)
+	return s.saveDrafts(req, questions, report, err)
 }
 
-// constructPrompt 构造AI提示语
-func (s *QuestionService) constructPrompt(aiModel, language, questionType, keywords string, numQuestions int) string {
-	typeDesc := "单选题"
-	if questionType == string(model.QuestionTypeMultiple) {
-		typeDesc = "多选题"
+// generate 分批生成题目并设置元信息，不保存题目；callbacks 不为空时使用模型的流式输出并逐题回调
+func (s *QuestionService) generate(ctx context.Context, userID int64, genReq GenerateRequest, callbacks *GenerateCallbacks) ([]*model.Question, *GenerateReport, error) {
+	if err := s.ValidateGenerateRequest(genReq); err != nil {
+		return nil, nil, err
 	}
-
-	return fmt.Sprintf(`
-    请严格按照以下JSON格式生成%d道关于"%s"的%s编程%s，每题必须有4个选项，答案使用选项索引（如"A", "B", "C", "D"）：
-    {
-        "questions": [
-            {
-                "title": "题目内容",
-                "options": ["选项A", "选项B", "选项C", "选项D"],
-                "answer": "正确选项索引",
-                "explanation": "答案解析"
-            }
-        ]
-    }
-    `, numQuestions, keywords, language, typeDesc)
-}
-
-// getAPIKey 获取API密钥
-func (s *QuestionService) getAPIKey(aiModel string) string {
-	switch aiModel {
-	case "通义千问":
-		return s.aiConfig.TongyiAPIKey
-	case "deepseek":
-		return s.aiConfig.DeepSeekAPIKey
-	default:
-		return ""
-	}
-}
-
-// getAPIURL 获取API URL
-func (s *QuestionService) getAPIURL(aiModel string) string {
-	switch aiModel {
-	case "通义千问":
-		return s.aiConfig.TongyiAPIURL
-	case "deepseek":
-		return s.aiConfig.DeepSeekAPIURL
-	default:
-		log.Printf("错误：未知的AI模型: %s", aiModel)
-		return ""
-	}
-}
-
-// callAIAPI 调用AI API
-func (s *QuestionService) callAIAPI(url, apiKey, prompt string) ([]*model.Question, error) {
-	// 构建符合DeepSeek API格式的请求体
-	payload := map[string]interface{}{
-		"model": "deepseek-chat", // 指定模型，根据实际情况修改
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"temperature": 0.7,  // 控制生成的随机性，范围0-1
-		"max_tokens":  2000, // 最大生成token数
-	}
-
-	// 转换为JSON
-	payloadBytes, err := json.Marshal(payload)
+	provider, err := s.aiProviders.Get(genReq.AIModel)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-
-	// 创建HTTP请求
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payloadBytes))
+	template, templateVersion, err := s.promptTemplates.ResolveTemplate(genReq.TemplateID, genReq.TemplateVersion, genReq.QuestionType)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if genReq.KnowledgePointID != 0 {
+		path, err := s.knowledge.GetNodePath(genReq.KnowledgePointID)
+		if err != nil {
+			return nil, nil, err
+		}
+		genReq.knowledgePath = knowledgePathName(path)
+		// 未填写关键词时以知识点名称作为题目主题
+		if genReq.Keywords == "" {
+			genReq.Keywords = path[len(path)-1].Name
+		}
 	}
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	chunks := splitQuestionCount(genReq.NumQuestions, s.genConfig.ChunkSize)
+	report := &GenerateReport{Requested: genReq.NumQuestions, Chunks: len(chunks), BatchID: genReq.BatchID}
+	if report.BatchID == "" {
+		report.BatchID = newBatchID()
+	}
+	if template != nil {
+		report.Template = &PromptTemplateRef{ID: template.ID, Name: template.Name, Version: templateVersion.Version}
+	}
 
-	// 定义可重试的错误类型
-	retryableStatusCodes := map[int]bool{
-		http.StatusRequestTimeout:      true,
-		http.StatusTooManyRequests:     true,
-		http.StatusInternalServerError: true,
-		http.StatusBadGateway:          true,
-		http.StatusServiceUnavailable:  true,
-		http.StatusGatewayTimeout:      true,
+	log.Printf("AI模型: %s, 请求模型: %s, 题型: %s, 数量: %d, 批次: %d, 流式: %v",
+		provider.Name(), provider.Model(), genReq.QuestionType, genReq.NumQuestions, len(chunks), callbacks != nil)
+
+	// 用户题库中已有的题目，用于标记相似的新题目
+	bank, err := s.questionDAO.GetQuestionsByUserID(userID, QuestionFilter{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询题库失败: %v", err)
+	}
+	existing := newSimilarityIndex(s.genConfig.DuplicateThreshold, bank)
+	generated := newSimilarityIndex(s.genConfig.DuplicateThreshold, nil)
+
+	// 通过校验、等待去重的题目
+	type parsedQuestion struct {
+		index    int
+		question *model.Question
 	}
 
-	// 指数退避重试策略
 	var (
-		resp          *http.Response
-		body          []byte
-		maxRetries    = 3
-		retryDelay    = 1 * time.Second
-		maxRetryDelay = 4 * time.Second
+		mu         sync.Mutex
+		questions  []*model.Question
+		seen       = make(map[string]bool)
+		parsed     = make([][]parsedQuestion, len(chunks)) // 各批次等待去重的题目
+		finished   = make([]bool, len(chunks))             // 各批次是否已经结束
+		current    = 0                                     // 正在去重的批次，之前的批次都已结束
+		chunkErrs  = make([]error, len(chunks))            // 各批次的错误，按批次顺序记录
+		chunkWarns = make([]string, len(chunks))           // 各批次的警告，按批次顺序记录
 	)
 
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if attempt > 0 {
-			log.Printf("AI API 请求重试中 (%d/%d)...", attempt, maxRetries)
-			time.Sleep(retryDelay)
-			retryDelay = min(retryDelay*2, maxRetryDelay) // 指数退避
+	// reject 记录未被采用的题目，调用时需持有锁
+	reject := func(rejection QuestionRejection) {
+		log.Printf("警告: 第%d批第%d道题目 '%s' 未被采用: %s %s", rejection.Chunk, rejection.Index, rejection.Title, rejection.Field, rejection.Reason)
+		report.Rejected = append(report.Rejected, rejection)
+		if callbacks != nil && callbacks.OnRejected != nil {
+			callbacks.OnRejected(rejection)
 		}
+	}
+
+	// accept 对一道通过校验的题目去重，未重复时收下，调用时需持有锁
+	accept := func(chunk, index int, question *model.Question) {
+		key := questionDedupKey(question)
+		if seen[key] {
+			report.Duplicates++
+			reject(QuestionRejection{Chunk: chunk + 1, Index: index, Title: question.Title, Field: "title", Reason: "与已生成的题目重复"})
+			return
+		}
+		if similar := generated.find(question); similar != nil {
+			report.Duplicates++
+			reject(QuestionRejection{Chunk: chunk + 1, Index: index, Title: question.Title, Field: "title",
+				Reason: fmt.Sprintf("与已生成的题目 '%s' 相似（相似度%.0f%%）", similar.Title, similar.Similarity*100)})
+			return
+		}
+		seen[key] = true
+		generated.add(question)
+		questions = append(questions, question)
+
+		similar := existing.find(question)
+		if similar != nil {
+			report.Similar++
+		}
+
+		// 推送前补全元信息，与保存后的题目一致
+		if callbacks != nil && callbacks.OnQuestion != nil {
+			question.UserID = userID
+			question.AIModel = genReq.AIModel
+			question.Language = genReq.Language
+			question.Keywords = genReq.Keywords
+			callbacks.OnQuestion(question, similar)
+		}
+	}
+
+	// flush 按批次和序号顺序去重已解析的题目，保证并发生成时保留哪道重复题目与完成顺序无关；
+	// 后面批次的题目要等前面的批次结束后才去重，调用时需持有锁
+	flush := func() {
+		for current < len(chunks) {
+			for _, p := range parsed[current] {
+				accept(current, p.index, p.question)
+			}
+			parsed[current] = nil
+			if !finished[current] {
+				return
+			}
+			current++
+		}
+	}
+
+	// handle 校验AI返回的一道题目，通过校验的题目等待按顺序去重，返回题目是否通过校验
+	handle := func(chunk, index int, raw json.RawMessage) bool {
+		question, title, err := parseAIQuestionItem(raw, genReq)
+
+		mu.Lock()
+		defer mu.Unlock()
 
-		// 发送请求
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err = client.Do(req)
 		if err != nil {
-			log.Printf("AI API 请求失败 (尝试 %d/%d): %v", attempt+1, maxRetries, err)
-			if attempt == maxRetries {
-				return nil, fmt.Errorf("AI API 请求失败，已达到最大重试次数: %v", err)
+			rejection := QuestionRejection{Chunk: chunk + 1, Index: index, Title: title, Reason: err.Error()}
+			var fieldErr *aiFieldError
+			if errors.As(err, &fieldErr) {
+				rejection.Field, rejection.Reason = fieldErr.Field, fieldErr.Reason
 			}
-			continue
+			reject(rejection)
+			return false
 		}
 
-		// 读取响应
-		body, err = ioutil.ReadAll(resp.Body)
-		resp.Body.Close() // 立即关闭响应体
+		parsed[chunk] = append(parsed[chunk], parsedQuestion{index: index, question: question})
+		flush()
+		return true
+	}
 
+	// 先构造全部批次的请求，模板渲染失败时不发起任何请求
+	requests := make([]AIRequest, len(chunks))
+	offset := 0
+	for i, count := range chunks {
+		req, err := s.buildAIRequest(genReq, count, templateVersion)
 		if err != nil {
-			log.Printf("AI API 读取响应失败 (尝试 %d/%d): %v", attempt+1, maxRetries, err)
-			if attempt == maxRetries {
-				return nil, fmt.Errorf("AI API 读取响应失败，已达到最大重试次数: %v", err)
+			return nil, report, err
+		}
+		req.Offset = offset
+		if len(chunks) > 1 {
+			req.Prompt += fmt.Sprintf("\n    这是分批生成的第%d批（共%d批），请侧重不同的知识点，避免与其他批次的题目重复。\n", i+1, len(chunks))
+		}
+		offset += count
+		requests[i] = req
+	}
+
+	// 并发请求各批次，同时进行的请求数不超过 Concurrency
+	sem := make(chan struct{}, s.genConfig.Concurrency)
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		wg.Add(1)
+		go func(chunk int, req AIRequest) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			warning, err := s.generateChunk(ctx, provider, req, callbacks != nil, func(index int, raw json.RawMessage) bool {
+				return handle(chunk, index, raw)
+			})
+			chunkWarns[chunk] = warning
+			if err != nil {
+				log.Printf("第 %d/%d 批题目生成失败: %v", chunk+1, len(chunks), err)
+				chunkErrs[chunk] = err
 			}
+
+			mu.Lock()
+			finished[chunk] = true
+			flush()
+			mu.Unlock()
+		}(i, req)
+	}
+	wg.Wait()
+
+	var firstErr error
+	for i, err := range chunkErrs {
+		if chunkWarns[i] != "" {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("第%d批: %s", i+1, chunkWarns[i]))
+		}
+		if err == nil {
 			continue
 		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		report.FailedChunks++
+		report.Errors = append(report.Errors, fmt.Sprintf("第%d批: %v", i+1, err))
+	}
 
-		// 检查响应状态码
-		if resp.StatusCode != http.StatusOK {
-			log.Printf("AI API 返回非成功状态码 (尝试 %d/%d): %d, 响应: %s",
-				attempt+1, maxRetries, resp.StatusCode, string(body))
+	if len(questions) == 0 {
+		switch report.FailedChunks {
+		case 0:
+			return nil, report, fmt.Errorf("没有有效的题目被解析")
+		case 1:
+			return nil, report, firstErr
+		default:
+			return nil, report, fmt.Errorf("全部%d批题目生成失败: %s", len(chunks), strings.Join(report.Errors, "; "))
+		}
+	}
+
+	// 题目已按批次顺序收下，未采用的题目也按批次和序号排列
+	sort.SliceStable(report.Rejected, func(i, j int) bool {
+		if report.Rejected[i].Chunk != report.Rejected[j].Chunk {
+			return report.Rejected[i].Chunk < report.Rejected[j].Chunk
+		}
+		return report.Rejected[i].Index < report.Rejected[j].Index
+	})
+	report.Generated = len(questions)
 
-			// 判断是否为可重试的状态码
-			if retryableStatusCodes[resp.StatusCode] && attempt < maxRetries {
+	// 保存前审核题目，审核结论随待确认题目一起保存
+	if reviewModel := s.reviewModelName(genReq); reviewModel != "" {
+		reviewer, err := s.aiProviders.Get(reviewModel)
+		if err != nil {
+			return nil, report, err
+		}
+		report.ReviewModel = reviewer.Name()
+		report.Warnings = append(report.Warnings, s.reviewQuestions(ctx, reviewer, genReq, questions)...)
+		for _, question := range questions {
+			if question.ReviewVerdict == "" {
 				continue
 			}
+			report.Reviewed++
+			if question.ReviewVerdict == model.ReviewVerdictFlagged {
+				report.Flagged++
+			}
+			if callbacks != nil && callbacks.OnReviewed != nil {
+				callbacks.OnReviewed(question)
+			}
+		}
+	}
 
-			// 非重试错误，直接返回
-			return nil, fmt.Errorf("AI API 错误: %d", resp.StatusCode)
+	s.prepareDraftQuestions(userID, genReq, report.BatchID, questions)
+	return questions, report, nil
+}
+
+// saveDrafts 保存 generate 生成的待确认题目，并关联到生成时指定的知识点
+func (s *QuestionService) saveDrafts(genReq GenerateRequest, questions []*model.Question, report *GenerateReport, err error) ([]*model.Question, *GenerateReport, error) {
+	if err != nil {
+		return nil, report, err
+	}
+
+	// 保存待确认题目到数据库
+	if err := s.questionDAO.BatchCreateQuestions(questions, knowledgePointIDs(genReq.KnowledgePointID)); err != nil {
+		return nil, report, fmt.Errorf("保存题目失败: %v", err)
+	}
+	return questions, report, nil
+}
+
+// knowledgePointIDs 生成的题目需要关联的知识点，pointID 为0时不关联
+func knowledgePointIDs(pointID int64) []int64 {
+	if pointID == 0 {
+		return nil
+	}
+	return []int64{pointID}
+}
+
+// generateChunk 请求一个批次的题目，对输出中的每道题目调用一次 handle（序号从1开始），handle 返回题目是否通过校验
+// 已处理过题目后不再重试，避免重复；输出中断或被截断时保留已经完整的题目，并返回说明
+func (s *QuestionService) generateChunk(ctx context.Context, provider AIProvider, req AIRequest, stream bool, handle func(index int, raw json.RawMessage) bool) (string, error) {
+	streamer, ok := provider.(AIStreamProvider)
+	stream = stream && ok && provider.Capabilities().Streaming
+
+	found, valid := 0, 0 // 已处理的题目数量、其中通过校验的数量
+	var parser *questionStreamParser
+	content, err := s.callWithRetry(ctx, func(ctx context.Context) (string, error) {
+		if !stream {
+			return provider.Generate(ctx, req)
 		}
+		parser = &questionStreamParser{}
+		return streamer.GenerateStream(ctx, req, func(chunk string) {
+			for _, raw := range parser.Write(chunk) {
+				found++
+				if handle(found, raw) {
+					valid++
+				}
+			}
+		})
+	}, func() bool {
+		return found == 0
+	})
 
-		// 请求成功，跳出重试循环
-		break
+	if err != nil {
+		if valid == 0 {
+			return "", err
+		}
+		return fmt.Sprintf("输出中断，已保留%d道完整的题目: %v", found, err), nil
+	}
+	if found > 0 {
+		if valid == 0 {
+			return "", fmt.Errorf("没有有效的题目被解析，%d道题目未通过校验", found)
+		}
+		if !parser.done {
+			return fmt.Sprintf("输出不完整，已保留%d道完整的题目", found), nil
+		}
+		return "", nil
 	}
 
-	// 解析DeepSeek API的标准响应格式
-	var response struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
+	// 非流式请求，或无法从部分输出中识别出题目时，从完整内容中提取
+	items, warning, err := extractAIQuestions(content)
+	if err != nil {
+		log.Printf("AI返回内容解析失败: %v, 内容: %s", err, content)
+		return "", err
 	}
+	for _, raw := range items {
+		found++
+		if handle(found, raw) {
+			valid++
+		}
+	}
+	if valid == 0 {
+		return warning, fmt.Errorf("没有有效的题目被解析，%d道题目未通过校验", found)
+	}
+	return warning, nil
+}
 
-	if err := json.Unmarshal(body, &response); err != nil {
-		log.Printf("JSON解析失败: %v, 响应内容: %s", err, string(body))
-		return nil, err
+// splitQuestionCount 将题目数量拆分为每批不超过 chunkSize 道，各批数量尽量平均
+func splitQuestionCount(numQuestions, chunkSize int) []int {
+	if chunkSize <= 0 || numQuestions <= chunkSize {
+		return []int{numQuestions}
+	}
+	n := (numQuestions + chunkSize - 1) / chunkSize
+	chunks := make([]int, n)
+	for i := range chunks {
+		chunks[i] = numQuestions / n
+		if i < numQuestions%n {
+			chunks[i]++
+		}
+	}
+	return chunks
+}
+
+// questionDedupKey 题目去重使用的键：忽略空白和大小写后的题干
+func questionDedupKey(question *model.Question) string {
+	return strings.ToLower(strings.Join(strings.Fields(question.Title), ""))
+}
+
+// buildAIRequest 构造题目生成请求
+func (s *QuestionService) buildAIRequest(genReq GenerateRequest, numQuestions int, template *model.PromptTemplateVersion) (AIRequest, error) {
+	prompt, err := s.constructPrompt(genReq, numQuestions, template)
+	if err != nil {
+		return AIRequest{}, err
+	}
+	return AIRequest{
+		Prompt:         prompt,
+		QuestionType:   genReq.QuestionType,
+		NumQuestions:   numQuestions,
+		Language:       genReq.Language,
+		Keywords:       genReq.Keywords,
+		Difficulty:     genReq.Difficulty,
+		CognitiveLevel: genReq.CognitiveLevel,
+	}, nil
+}
+
+// prepareDraftQuestions 设置元信息并将题目置为 batchID 批次的待确认题目，等待用户确认
+func (s *QuestionService) prepareDraftQuestions(userID int64, genReq GenerateRequest, batchID string, questions []*model.Question) {
+	for _, question := range questions {
+		question.UserID = userID
+		question.AIModel = genReq.AIModel
+		question.Language = genReq.Language
+		question.Keywords = genReq.Keywords
+		question.Status = model.QuestionStatusDraft
+		question.BatchID = batchID
+	}
+}
+
+// constructPrompt 使用提示语模板构造AI提示语，并附加难度和认知层次的要求
+// template 为空时使用内置模板；模板中没有使用输出格式变量时，在模板内容后附加题型对应的输出格式
+func (s *QuestionService) constructPrompt(genReq GenerateRequest, numQuestions int, template *model.PromptTemplateVersion) (string, error) {
+	content := defaultPromptTemplate
+	if template != nil {
+		content = template.Content
 	}
 
-	// 提取AI返回的内容
-	if len(response.Choices) == 0 {
-		return nil, fmt.Errorf("AI API 返回空结果")
+	vars := newPromptTemplateVars(genReq, numQuestions)
+	prompt, err := renderPromptTemplate(content, vars)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(content, ".Format") {
+		prompt += fmt.Sprintf("\n    %s，请严格按照以下JSON格式输出：\n%s\n", vars.Instruction, vars.Format)
+	}
+	if !strings.HasSuffix(strings.TrimRight(prompt, " "), "\n") {
+		prompt += "\n"
+	}
+	if genReq.knowledgePath != "" && !strings.Contains(content, ".KnowledgePoint") {
+		prompt += fmt.Sprintf("    所有题目都应考查知识点：%s。\n", genReq.knowledgePath)
+	}
+	return prompt + levelPrompt(genReq.Difficulty, genReq.CognitiveLevel), nil
+}
+
+// levelPrompt 构造难度和认知层次的要求，未指定时要求模型自行标注
+func levelPrompt(difficulty int, level model.CognitiveLevel) string {
+	var b strings.Builder
+	b.WriteString("    每道题目还需包含 difficulty 字段（难度，1-5的整数，1最容易）和 cognitive_level 字段（布鲁姆认知层次，取值为 remember、understand、apply、analyze、evaluate、create 之一）。\n")
+	if difficulty != 0 {
+		fmt.Fprintf(&b, "    所有题目的难度均应为%d级（%s）。\n", difficulty, difficultyDescs[difficulty])
+	}
+	if info, ok := lookupCognitiveLevel(string(level)); ok {
+		fmt.Fprintf(&b, "    所有题目的认知层次均应为 %s（%s），%s。\n", info.Level, info.Name, info.Hint)
+	}
+	return b.String()
+}
+
+// questionFormat 题型对输出内容的要求和对应的JSON输出格式
+func questionFormat(questionType model.QuestionType) (instruction, format string) {
+	switch questionType {
+	case model.QuestionTypeMultiple:
+		return "每题必须有4个选项，其中2到3个选项正确，answer 为所有正确选项的索引组成的数组", `    {
+        "questions": [
+            {
+                "title": "题目内容",
+                "options": ["选项A", "选项B", "选项C", "选项D"],
+                "answer": ["A", "C"],
+                "explanation": "答案解析"
+            }
+        ]
+    }`
+	case model.QuestionTypeJudge:
+		return "题目为一个需要判断对错的陈述，答案只能是 true 或 false", `    {
+        "questions": [
+            {
+                "title": "题目内容",
+                "answer": true,
+                "explanation": "答案解析"
+            }
+        ]
+    }`
+	case model.QuestionTypeBlank:
+		return `题目中用"____"标出每个空，answer 为数组，按顺序列出每个空的所有可接受答案`, `    {
+        "questions": [
+            {
+                "title": "Go语言中声明常量使用____关键字",
+                "answer": [["const"]],
+                "explanation": "答案解析"
+            }
+        ]
+    }`
+	case model.QuestionTypeShortAnswer:
+		return "answer 为参考答案，explanation 列出评分要点", `    {
+        "questions": [
+            {
+                "title": "题目内容",
+                "answer": "参考答案",
+                "explanation": "评分要点"
+            }
+        ]
+    }`
+	case model.QuestionTypeCoding:
+		return "程序从标准输入读取数据并向标准输出打印结果，code_template 为提供给考生的代码模板，answer 为参考实现，test_cases 至少包含3个测试用例", `    {
+        "questions": [
+            {
+                "title": "题目描述，包括输入输出格式",
+                "code_template": "代码模板",
+                "answer": "参考实现代码",
+                "test_cases": [
+                    {"input": "标准输入", "expected_output": "期望输出"}
+                ],
+                "explanation": "解题思路"
+            }
+        ]
+    }`
 	}
 
-	aiResponse := response.Choices[0].Message.Content
+	return `每题必须有4个选项，答案使用选项索引（如"A", "B", "C", "D"）`, `    {
+        "questions": [
+            {
+                "title": "题目内容",
+                "options": ["选项A", "选项B", "选项C", "选项D"],
+                "answer": "正确选项索引",
+                "explanation": "答案解析"
+            }
+        ]
+    }`
+}
+
+// callWithRetry 执行AI调用，网络错误、限流和服务端错误按指数退避重试，canRetry 不为空且返回 false 时不再重试
+func (s *QuestionService) callWithRetry(ctx context.Context, call func(ctx context.Context) (string, error), canRetry func() bool) (string, error) {
+	var (
+		maxRetries    = 3
+		retryDelay    = 1 * time.Second
+		maxRetryDelay = 4 * time.Second
+	)
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			log.Printf("AI API 请求重试中 (%d/%d)...", attempt, maxRetries)
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+			retryDelay = min(retryDelay*2, maxRetryDelay) // 指数退避
+		}
+
+		content, err := call(ctx)
+		if err == nil {
+			return content, nil
+		}
+
+		var statusErr *AIStatusError
+		if errors.As(err, &statusErr) {
+			log.Printf("AI API 返回非成功状态码 (尝试 %d/%d): %d, 响应: %s",
+				attempt+1, maxRetries+1, statusErr.StatusCode, statusErr.Body)
+		} else {
+			log.Printf("AI API 请求失败 (尝试 %d/%d): %v", attempt+1, maxRetries+1, err)
+		}
 
-	// 解析AI返回的内容为题目列表
-	return s.parseAIResponse(aiResponse)
+		// 非重试错误，直接返回
+		if !isRetryableAIError(err) || (canRetry != nil && !canRetry()) {
+			return "", err
+		}
+		if attempt == maxRetries {
+			return "", fmt.Errorf("AI API 请求失败，已达到最大重试次数: %v", err)
+		}
+	}
 }
 
 // 辅助函数：返回较小值
@@ -245,169 +710,398 @@ func min(a, b time.Duration) time.Duration {
 	return b
 }
 
-// 解析AI返回的内容为题目列表
-func (s *QuestionService) parseAIResponse(content string) ([]*model.Question, error) {
-	// 预处理：去除Markdown标记
-	content = strings.TrimSpace(content)
-	content = strings.TrimPrefix(content, "```json")
-	content = strings.TrimSuffix(content, "```")
-	content = strings.TrimSpace(content)
+// aiQuestion AI返回的单道题目
+type aiQuestion struct {
+	Title          string          `json:"title"`
+	Options        []string        `json:"options"`
+	Answer         json.RawMessage `json:"answer"`
+	Explanation    string          `json:"explanation"`
+	CodeTemplate   string          `json:"code_template"`
+	TestCases      []CodeTestCase  `json:"test_cases"`
+	Difficulty     json.RawMessage `json:"difficulty"`
+	CognitiveLevel json.RawMessage `json:"cognitive_level"`
+}
 
-	// 尝试解析JSON
-	var questionsData struct {
-		Questions []struct {
-			Title       string   `json:"title"`
-			Options     []string `json:"options"`
-			Answer      string   `json:"answer"`
-			Explanation string   `json:"explanation"`
-		} `json:"questions"`
+// aiQuestionLevels 读取模型标注的难度和认知层次，格式不正确时忽略，难度按默认值处理
+func aiQuestionLevels(q aiQuestion) (int, model.CognitiveLevel) {
+	difficulty := DefaultDifficulty
+	if n, err := strconv.Atoi(strings.Trim(strings.TrimSpace(string(q.Difficulty)), `"`)); err == nil && IsValidDifficulty(n) {
+		difficulty = n
 	}
 
-	if err := json.Unmarshal([]byte(content), &questionsData); err != nil {
-		log.Printf("JSON解析失败: %v, 处理后的内容: %s", err, content)
-		return nil, err
+	var level model.CognitiveLevel
+	var text string
+	if json.Unmarshal(q.CognitiveLevel, &text) == nil {
+		if info, ok := lookupCognitiveLevel(text); ok {
+			level = info.Level
+		}
 	}
+	return difficulty, level
+}
 
-	// 验证并转换题目
-	var questions []*model.Question
-	for _, q := range questionsData.Questions {
-		// 验证选项数量
-		if len(q.Options) != 4 {
-			log.Printf("警告: 题目 '%s' 的选项数量不足4个，实际: %d", q.Title, len(q.Options))
-			continue
+// parseAIQuestionItem 修复格式问题后按题型的结构定义检查AI返回的单道题目，并转换为题目模型
+// 返回的 title 为能够识别出的题目内容，用于说明未通过校验的题目
+func parseAIQuestionItem(raw json.RawMessage, genReq GenerateRequest) (*model.Question, string, error) {
+	raw = json.RawMessage(repairAIJSON(string(raw)))
+
+	var probe struct {
+		Title string `json:"title"`
+	}
+	json.Unmarshal(raw, &probe)
+
+	if err := checkAIQuestionSchema(raw, genReq.QuestionType); err != nil {
+		return nil, probe.Title, err
+	}
+
+	var item aiQuestion
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, probe.Title, fmt.Errorf("题目格式错误: %v", err)
+	}
+	question, err := parseAIQuestion(item, genReq.Language, genReq.QuestionType)
+	if err != nil {
+		return nil, item.Title, err
+	}
+
+	// 指定了目标难度和认知层次时以请求为准，否则采用模型的标注
+	question.Difficulty, question.CognitiveLevel = aiQuestionLevels(item)
+	if genReq.Difficulty != 0 {
+		question.Difficulty = genReq.Difficulty
+	}
+	if genReq.CognitiveLevel != "" {
+		question.CognitiveLevel = genReq.CognitiveLevel
+	}
+	return question, item.Title, nil
+}
+
+// parseAIQuestion 校验AI返回的单道题目并转换为题目模型
+func parseAIQuestion(q aiQuestion, language string, questionType model.QuestionType) (*model.Question, error) {
+	// 非选择题按题型校验并规范答案格式
+	if !isChoiceQuestion(questionType) {
+		question := &model.Question{
+			Title:        q.Title,
+			QuestionType: questionType,
+			Answer:       rawAnswerText(q.Answer),
+			Explanation:  q.Explanation,
+			Language:     language,
+		}
+		if questionType == model.QuestionTypeCoding {
+			testsJSON, err := json.Marshal(q.TestCases)
+			if err != nil {
+				return nil, err
+			}
+			question.CodeTemplate = q.CodeTemplate
+			question.TestCases = string(testsJSON)
+		}
+		if err := validateQuestion(question); err != nil {
+			return nil, err
 		}
+		return question, nil
+	}
+
+	// 验证选项数量
+	if len(q.Options) != 4 {
+		return nil, &aiFieldError{Field: "options", Reason: fmt.Sprintf("选项数量应为4个，实际: %d", len(q.Options))}
+	}
+
+	answer, err := resolveChoiceAnswer(q.Answer, q.Options)
+	if err != nil {
+		return nil, &aiFieldError{Field: "answer", Reason: err.Error()}
+	}
+	switch {
+	case questionType == model.QuestionTypeSingle && len(answer) != 1:
+		return nil, &aiFieldError{Field: "answer", Reason: fmt.Sprintf("单选题只能有一个答案，实际: %s", answer)}
+	case questionType == model.QuestionTypeMultiple && len(answer) == len(q.Options):
+		return nil, &aiFieldError{Field: "answer", Reason: "多选题的答案不能包含全部选项"}
+	}
 
-		// 验证答案格式
-		validAnswers := map[string]bool{"A": true, "B": true, "C": true, "D": true}
-		if !validAnswers[q.Answer] {
-			log.Printf("警告: 题目 '%s' 的答案格式不正确，应为A-D，实际: %s", q.Title, q.Answer)
-			// 创建一个字符串数组来存储选项索引
-			answerIndex := []string{"A", "B", "C", "D"}
+	// 转换选项为JSON字符串
+	optionsJSON, err := json.Marshal(q.Options)
+	if err != nil {
+		return nil, err
+	}
 
-			// 尝试从选项中查找匹配的答案
-			for i, option := range q.Options {
-				if option == q.Answer {
-					q.Answer = answerIndex[i] // 使用字符串数组来获取选项索引
+	question := &model.Question{
+		Title:        q.Title,
+		QuestionType: questionType,
+		Options:      string(optionsJSON),
+		Answer:       answer,
+		Explanation:  q.Explanation,
+	}
+	if err := validateQuestion(question); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// resolveChoiceAnswer 将AI返回的选择题答案转换为规范的选项字母，如 "ACD"
+// 支持字母串 "ACD"、"A,C,D"，字母数组 ["A","C","D"]，以及使用选项内容作为答案
+func resolveChoiceAnswer(raw json.RawMessage, options []string) (string, error) {
+	var tokens []string
+	if err := json.Unmarshal(raw, &tokens); err != nil {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return "", fmt.Errorf("答案应为字符串或字符串数组")
+		}
+		tokens = []string{text}
+	}
+
+	var letters strings.Builder
+	for _, token := range tokens {
+		token = strings.TrimSpace(token)
+		letter, ok := choiceLetters(token, len(options))
+		if !ok {
+			// 使用选项内容作为答案
+			for i, option := range options {
+				if strings.TrimSpace(option) == token {
+					letter, ok = string(rune('A'+i)), true
 					break
 				}
 			}
-
-			// 如果仍未找到匹配，跳过该题目
-			if !validAnswers[q.Answer] {
-				log.Printf("错误: 无法转换题目 '%s' 的答案，跳过", q.Title)
-				continue
-			}
 		}
+		if !ok {
+			return "", fmt.Errorf("答案应为%s或选项内容，实际: %s", choiceLetterRange(len(options)), token)
+		}
+		letters.WriteString(letter)
+	}
 
-		// 转换选项为JSON字符串
-		optionsJSON, err := json.Marshal(q.Options)
-		if err != nil {
-			return nil, err
+	answer := normalizeChoiceAnswer(letters.String())
+	if answer == "" {
+		return "", fmt.Errorf("答案不能为空")
+	}
+	return answer, nil
+}
+
+// choiceLetters 识别由选项字母和分隔符组成的答案，如 "ACD"、"a, c"、"A、C"，字母超出选项范围时返回 false
+func choiceLetters(token string, numOptions int) (string, bool) {
+	var letters strings.Builder
+	for _, r := range strings.ToUpper(token) {
+		switch {
+		case r >= 'A' && int(r-'A') < numOptions:
+			letters.WriteRune(r)
+		case strings.ContainsRune(" ,，、;；/|.", r):
+		default:
+			return "", false
 		}
+	}
+	return letters.String(), letters.Len() > 0
+}
 
-		questions = append(questions, &model.Question{
-			Title:        q.Title,
-			QuestionType: model.QuestionTypeSingle,
-			Options:      string(optionsJSON),
-			Answer:       q.Answer,
-			Explanation:  q.Explanation,
-		})
+// choiceLetterRange 选项字母的范围说明，如 "A-D"
+func choiceLetterRange(numOptions int) string {
+	return fmt.Sprintf("A-%c", 'A'+numOptions-1)
+}
+
+// rawAnswerText 将AI返回的答案字段转换为文本：字符串取原值，布尔值转为 true/false，数组保留JSON
+func rawAnswerText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var flag bool
+	if err := json.Unmarshal(raw, &flag); err == nil {
+		return strconv.FormatBool(flag)
 	}
+	return strings.TrimSpace(string(raw))
+}
 
-	if len(questions) == 0 {
-		return nil, fmt.Errorf("没有有效的题目被解析")
+// FindSimilarQuestions 在用户题库中查找与各题目相似的已有题目，返回题目ID到相似题目的映射，没有相似题目的不包含在内
+func (s *QuestionService) FindSimilarQuestions(userID int64, questions []*model.Question) (map[int64]*SimilarQuestion, error) {
+	bank, err := s.questionDAO.GetQuestionsByUserID(userID, QuestionFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	index := newSimilarityIndex(s.genConfig.DuplicateThreshold, bank)
+	result := make(map[int64]*SimilarQuestion)
+	for _, question := range questions {
+		if similar := index.find(question); similar != nil {
+			result[question.ID] = similar
+		}
 	}
+	return result, nil
+}
+
+// DuplicateDraft 与已有题目相似的待确认题目
+type DuplicateDraft struct {
+	ID        int64            `json:"id"`
+	Title     string           `json:"title"`
+	SimilarTo *SimilarQuestion `json:"similarTo"`
+}
 
-	return questions, nil
+// FlaggedDraft AI审核发现问题的待确认题目
+type FlaggedDraft struct {
+	ID         int64         `json:"id"`
+	Title      string        `json:"title"`
+	Issues     []ReviewIssue `json:"issues"`
+	Suggestion string        `json:"suggestion"`
 }
 
-// SaveSelectedQuestions 保存选中的题目，入库选中题目并物理删除未选中的题目
-func (s *QuestionService) SaveSelectedQuestions(userID int64, selectedIDs []int64) error {
-	log.Printf("[DEBUG] SaveSelectedQuestions start: userID=%d selectedIDs=%v", userID, selectedIDs)
+// ConfirmResult 确认题目的结果
+type ConfirmResult struct {
+	BatchID    string            `json:"batchId"`    // 确认的生成批次
+	Confirmed  []int64           `json:"confirmed"`  // 已入库的题目ID
+	Duplicates []*DuplicateDraft `json:"duplicates"` // 与题库中已有题目或先确认的题目相似的题目
+	Flagged    []*FlaggedDraft   `json:"flagged"`    // 已入库的题目中AI审核发现问题的题目，需要人工检查
+}
 
-	allGeneratedQuestions, err := s.questionDAO.GetGeneratedQuestionsByUserID(userID)
+// SaveSelectedQuestions 确认一个生成批次的题目：选中的题目入库，物理删除该批次中未选中的题目，不影响其他批次
+// batchID 为空时由选中的题目确定批次。选中的题目与题库中已有题目或其他选中题目相似时在结果中标记，
+// skipDuplicates 为 true 时不入库这些题目
+func (s *QuestionService) SaveSelectedQuestions(userID int64, batchID string, selectedIDs []int64, skipDuplicates bool) (*ConfirmResult, error) {
+	batchID, err := s.resolveDraftBatch(userID, batchID, selectedIDs)
 	if err != nil {
-		log.Printf("[ERROR] 查询未确认题目失败: %v", err)
-		return err
+		return nil, err
 	}
 
-	log.Printf("[DEBUG] 查询到未确认题目数量: %d", len(allGeneratedQuestions))
-	for _, q := range allGeneratedQuestions {
-		log.Printf("[DEBUG] Question ID: %d, DeletedAt: %v, Valid: %v", q.ID, q.DeletedAt.Time, q.DeletedAt.Valid)
+	drafts, err := s.questionDAO.GetDraftQuestionsByBatch(userID, batchID)
+	if err != nil {
+		log.Printf("[ERROR] 查询未确认题目失败: %v", err)
+		return nil, err
+	}
+	if len(drafts) == 0 {
+		return nil, ErrDraftBatchNotFound
 	}
 
+	byID := make(map[int64]*model.Question, len(drafts))
+	for _, q := range drafts {
+		byID[q.ID] = q
+	}
 	selectedMap := make(map[int64]bool)
 	for _, id := range selectedIDs {
+		if _, ok := byID[id]; !ok {
+			return nil, fmt.Errorf("%w: 题目 %d 不是该批次的待确认题目", ErrInvalidDraftSelection, id)
+		}
 		selectedMap[id] = true
 	}
 
-	var toRestoreIDs []int64
+	// 与题库中已有题目比较，选中的题目之间按选择顺序比较，先选中的题目优先
+	bank, err := s.questionDAO.GetQuestionsByUserID(userID, QuestionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	index := newSimilarityIndex(s.genConfig.DuplicateThreshold, bank)
+
+	result := &ConfirmResult{BatchID: batchID, Confirmed: []int64{}, Duplicates: []*DuplicateDraft{}, Flagged: []*FlaggedDraft{}}
+	skipped := make(map[int64]bool)
+	for _, id := range selectedIDs {
+		q := byID[id]
+		if skipped[id] {
+			continue
+		}
+		if similar := index.find(q); similar != nil {
+			result.Duplicates = append(result.Duplicates, &DuplicateDraft{ID: q.ID, Title: q.Title, SimilarTo: similar})
+			if skipDuplicates {
+				skipped[id] = true
+				continue
+			}
+		}
+		index.add(q)
+	}
+
+	var toActivateIDs []int64
 	var toDeleteIDs []int64
 
-	for _, q := range allGeneratedQuestions {
-		if selectedMap[q.ID] {
-			toRestoreIDs = append(toRestoreIDs, q.ID)
+	for _, q := range drafts {
+		if selectedMap[q.ID] && !skipped[q.ID] {
+			toActivateIDs = append(toActivateIDs, q.ID)
 		} else {
 			toDeleteIDs = append(toDeleteIDs, q.ID)
 		}
 	}
+	result.Confirmed = append(result.Confirmed, toActivateIDs...)
+	for _, id := range toActivateIDs {
+		if q := byID[id]; q.ReviewVerdict == model.ReviewVerdictFlagged {
+			result.Flagged = append(result.Flagged, &FlaggedDraft{ID: q.ID, Title: q.Title, Issues: ParseReviewIssues(q), Suggestion: q.ReviewSuggestion})
+		}
+	}
 
-	log.Printf("[DEBUG] 需要恢复的题目ID: %v", toRestoreIDs)
-	log.Printf("[DEBUG] 需要物理删除的题目ID: %v", toDeleteIDs)
-
-	if len(toRestoreIDs) > 0 {
-		if err := s.questionDAO.RestoreQuestionsByID(toRestoreIDs); err != nil {
-			log.Printf("[ERROR] 恢复题目失败: %v", err)
-			return fmt.Errorf("确认题目失败: %v", err)
+	if len(toActivateIDs) > 0 {
+		if err := s.questionDAO.UpdateQuestionsStatus(toActivateIDs, model.QuestionStatusActive); err != nil {
+			log.Printf("[ERROR] 题目入库失败: %v", err)
+			return nil, fmt.Errorf("确认题目失败: %v", err)
 		}
 	}
 
 	if len(toDeleteIDs) > 0 {
 		if err := s.questionDAO.DeleteQuestionsPermanently(toDeleteIDs); err != nil {
 			log.Printf("[ERROR] 物理删除题目失败: %v", err)
-			return fmt.Errorf("清除未选题目失败: %v", err)
+			return nil, fmt.Errorf("清除未选题目失败: %v", err)
 		}
 	}
 
-	log.Printf("[DEBUG] SaveSelectedQuestions 完成")
-	return nil
+	return result, nil
 }
 
-// GetQuestionsByUserID 获取用户题目列表
-func (s *QuestionService) GetQuestionsByUserID(userID int64, language, questionType, keyword string) ([]*model.Question, error) {
-	questions, err := s.questionDAO.GetQuestionsByUserID(userID, language, questionType, keyword)
-	if err != nil {
-		return nil, err
+// GetQuestionsByUserID 分页获取用户题目列表，返回当前页的题目和符合条件的总数
+func (s *QuestionService) GetQuestionsByUserID(userID int64, filter QuestionFilter, sort QuestionSort, page, pageSize int) ([]*model.Question, int64, error) {
+	if sort.Field == "" {
+		sort = QuestionSort{Field: "created_at", Desc: true}
 	}
-
-	// 将Options从JSON字符串转换为slice（可选，根据需要）
-	for _, q := range questions {
-		fmt.Println(q.ID)
-		// 注意：这里没有转换，因为控制器可能需要原始JSON格式
-		// 如需转换，可在此处添加json.Unmarshal
+	if _, ok := dao.QuestionSortColumns[sort.Field]; !ok {
+		return nil, 0, fmt.Errorf("%w: %s", ErrInvalidQuestionSort, sort.Field)
 	}
+	if err := s.validateQuestionFilter(filter); err != nil {
+		return nil, 0, err
+	}
+
+	return s.questionDAO.GetQuestionPageByUserID(userID, filter, sort, page, pageSize)
+}
 
-	return questions, nil
+// validateQuestionFilter 检查筛选条件中的题型、难度、认知层次和知识体系节点
+func (s *QuestionService) validateQuestionFilter(filter QuestionFilter) error {
+	if filter.QuestionType != "" && !IsValidQuestionType(model.QuestionType(filter.QuestionType)) {
+		return ErrInvalidQuestionType
+	}
+	if (filter.MinDifficulty != 0 && !IsValidDifficulty(filter.MinDifficulty)) || (filter.MaxDifficulty != 0 && !IsValidDifficulty(filter.MaxDifficulty)) {
+		return ErrInvalidDifficulty
+	}
+	if filter.CognitiveLevel != "" && !IsValidCognitiveLevel(model.CognitiveLevel(filter.CognitiveLevel)) {
+		return ErrInvalidCognitive
+	}
+	if filter.KnowledgeNodeID != 0 {
+		if _, err := s.knowledge.GetNode(filter.KnowledgeNodeID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // UpdateQuestion 更新题目
-func (s *QuestionService) UpdateQuestion(question *model.Question) error {
+// cognitiveLevel 为 nil 时保留原有的认知层次，为空字符串时清除认知层次
+func (s *QuestionService) UpdateQuestion(question *model.Question, cognitiveLevel *model.CognitiveLevel) error {
 	// 验证题目存在且属于当前用户
 	existingQuestion, err := s.questionDAO.GetUndeletedQuestionByID(question.ID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrQuestionNotFound
+		}
 		return err
 	}
 
 	if existingQuestion.UserID != question.UserID {
-		return fmt.Errorf("无权更新该题目")
+		return ErrQuestionNotFound
 	}
 
-	// 验证题目类型
-	if question.QuestionType != model.QuestionTypeSingle &&
-		question.QuestionType != model.QuestionTypeMultiple {
-		return fmt.Errorf("无效的题目类型: %s", question.QuestionType)
+	// 未填写难度和认知层次时保留原值
+	if question.Difficulty == 0 {
+		question.Difficulty = existingQuestion.Difficulty
+	}
+	question.CognitiveLevel = existingQuestion.CognitiveLevel
+	if cognitiveLevel != nil {
+		question.CognitiveLevel = *cognitiveLevel
 	}
 
+	// 按题型校验题目内容并规范答案格式
+	if err := validateQuestion(question); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidQuestion, err)
+	}
+
+	// 修改后原有的AI审核结论不再适用，随更新清空
+	question.ReviewVerdict = ""
+	question.ReviewIssues = ""
+	question.ReviewSuggestion = ""
+	question.ReviewModel = ""
+
 	// 更新题目
 	return s.questionDAO.UpdateQuestion(question)
 }
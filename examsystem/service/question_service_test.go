@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"examsystem/config"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"testing"
+	"time"
+)
+
+// newTestQuestionService 创建只有给定AI模型的题目服务，provider 可以为空
+func newTestQuestionService(t *testing.T, provider AIProvider, genConfig config.AIGenerationConfig) *QuestionService {
+	t.Helper()
+	registry := NewAIProviderRegistry(config.AIConfig{})
+	if provider != nil {
+		registry.Register(provider)
+	}
+	db := newTestDB(t)
+	questionDAO := dao.NewQuestionDAO(db)
+	return NewQuestionService(questionDAO, registry, genConfig, NewPromptTemplateService(dao.NewPromptTemplateDAO(db)), NewKnowledgeService(dao.NewKnowledgeDAO(db), questionDAO))
+}
+
+// chunkedTestProvider 按批次返回固定题目的AI模型，delays 为各批次的响应延迟
+type chunkedTestProvider struct {
+	chunkSize int
+	titles    [][]string
+	delays    []time.Duration
+}
+
+func (p *chunkedTestProvider) Name() string                 { return "test" }
+func (p *chunkedTestProvider) Model() string                { return "test" }
+func (p *chunkedTestProvider) Capabilities() AICapabilities { return AICapabilities{MaxTokens: 2000} }
+
+func (p *chunkedTestProvider) Generate(ctx context.Context, req AIRequest) (string, error) {
+	chunk := req.Offset / p.chunkSize
+	time.Sleep(p.delays[chunk])
+	items := make([]map[string]interface{}, 0, len(p.titles[chunk]))
+	for _, title := range p.titles[chunk] {
+		items = append(items, map[string]interface{}{"title": title, "answer": true, "explanation": "解析"})
+	}
+	content, err := json.Marshal(map[string]interface{}{"questions": items})
+	return string(content), err
+}
+
+func TestGenerateDedupFollowsChunkOrder(t *testing.T) {
+	// 第二批先返回，重复的题目仍然保留第一批中的那道
+	provider := &chunkedTestProvider{
+		chunkSize: 2,
+		titles: [][]string{
+			{"Go 的 map 不是并发安全的", "切片底层引用数组"},
+			{"Go 的 map 不是并发安全的", "关闭的通道仍然可以读取"},
+		},
+		delays: []time.Duration{200 * time.Millisecond, 0},
+	}
+	s := newTestQuestionService(t, provider, config.AIGenerationConfig{ChunkSize: 2, Concurrency: 2, MaxQuestions: 10, DuplicateThreshold: 0.8})
+
+	var streamed []string
+	questions, report, err := s.GenerateQuestionsStream(context.Background(), 1, GenerateRequest{AIModel: "test", Language: "Go", QuestionType: model.QuestionTypeJudge, NumQuestions: 4}, GenerateCallbacks{
+		OnQuestion: func(question *model.Question, similar *SimilarQuestion) {
+			streamed = append(streamed, question.Title)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Go 的 map 不是并发安全的", "切片底层引用数组", "关闭的通道仍然可以读取"}
+	if len(questions) != len(want) || len(streamed) != len(want) {
+		t.Fatalf("保存了 %d 道题目、推送了 %d 道题目，应为 %d 道", len(questions), len(streamed), len(want))
+	}
+	for i, title := range want {
+		if questions[i].Title != title || streamed[i] != title || questions[i].ID == 0 {
+			t.Fatalf("第%d道题目为 %q（推送 %q，ID %d），应为 %q", i+1, questions[i].Title, streamed[i], questions[i].ID, title)
+		}
+	}
+	if report.Chunks != 2 || report.Duplicates != 1 || report.Generated != len(want) {
+		t.Fatalf("report = %+v", report)
+	}
+	if len(report.Rejected) != 1 || report.Rejected[0].Chunk != 2 || report.Rejected[0].Index != 1 {
+		t.Fatalf("未采用的题目为 %+v，应为第2批的第1道", report.Rejected)
+	}
+}
+
+func TestUpdateQuestion(t *testing.T) {
+	s := newTestQuestionService(t, nil, config.AIGenerationConfig{})
+	question := &model.Question{UserID: 1, Title: "1+1=2", QuestionType: model.QuestionTypeJudge, Answer: "true", Options: "[]", Difficulty: 3, CognitiveLevel: model.CognitiveLevelRemember}
+	if err := s.questionDAO.CreateQuestion(question); err != nil {
+		t.Fatal(err)
+	}
+
+	guess := model.CognitiveLevel("guess")
+	tests := []struct {
+		name           string
+		update         model.Question
+		cognitiveLevel *model.CognitiveLevel
+		want           []error
+	}{
+		{"题目不存在", model.Question{ID: question.ID + 1, UserID: 1, Title: "1+1=2", QuestionType: model.QuestionTypeJudge, Answer: "true"}, nil, []error{ErrQuestionNotFound}},
+		{"不是自己的题目", model.Question{ID: question.ID, UserID: 2, Title: "1+1=2", QuestionType: model.QuestionTypeJudge, Answer: "true"}, nil, []error{ErrQuestionNotFound}},
+		{"无效的题目类型", model.Question{ID: question.ID, UserID: 1, Title: "1+1=2", QuestionType: "essay", Answer: "true"}, nil, []error{ErrInvalidQuestion, ErrInvalidQuestionType}},
+		{"无效的难度", model.Question{ID: question.ID, UserID: 1, Title: "1+1=2", QuestionType: model.QuestionTypeJudge, Answer: "true", Difficulty: 9}, nil, []error{ErrInvalidQuestion, ErrInvalidDifficulty}},
+		{"无效的认知层次", model.Question{ID: question.ID, UserID: 1, Title: "1+1=2", QuestionType: model.QuestionTypeJudge, Answer: "true"}, &guess, []error{ErrInvalidQuestion, ErrInvalidCognitive}},
+		{"答案格式错误", model.Question{ID: question.ID, UserID: 1, Title: "1+1=2", QuestionType: model.QuestionTypeJudge, Answer: "不确定"}, nil, []error{ErrInvalidQuestion}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := tt.update
+			err := s.UpdateQuestion(&update, tt.cognitiveLevel)
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Fatalf("err = %v，应为 %v", err, want)
+				}
+			}
+		})
+	}
+
+	// 未填写认知层次时保留原值，为空字符串时清除
+	update := model.Question{ID: question.ID, UserID: 1, Title: "1+1=2", QuestionType: model.QuestionTypeJudge, Answer: "true"}
+	if err := s.UpdateQuestion(&update, nil); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := s.questionDAO.GetUndeletedQuestionByID(question.ID); saved.Difficulty != 3 || saved.CognitiveLevel != model.CognitiveLevelRemember {
+		t.Fatalf("未填写时难度为 %d，认知层次为 %q", saved.Difficulty, saved.CognitiveLevel)
+	}
+	cleared := model.CognitiveLevel("")
+	if err := s.UpdateQuestion(&update, &cleared); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := s.questionDAO.GetUndeletedQuestionByID(question.ID); saved.CognitiveLevel != "" {
+		t.Fatalf("认知层次未被清除: %q", saved.CognitiveLevel)
+	}
+}
+
+func TestParseChoiceAnswer(t *testing.T) {
+	options := []string{"make", "new", "var", "const"}
+	tests := []struct {
+		name         string
+		questionType model.QuestionType
+		answer       string
+		want         string
+		wantErr      bool
+	}{
+		{"单选题字母", model.QuestionTypeSingle, `"b"`, "B", false},
+		{"单选题使用选项内容", model.QuestionTypeSingle, `"var"`, "C", false},
+		{"单选题多个答案", model.QuestionTypeSingle, `"AB"`, "", true},
+		{"多选题字母串", model.QuestionTypeMultiple, `"DA C"`, "ACD", false},
+		{"多选题带分隔符", model.QuestionTypeMultiple, `"A、C"`, "AC", false},
+		{"多选题数组", model.QuestionTypeMultiple, `["C", "A", "A"]`, "AC", false},
+		{"多选题数组使用选项内容", model.QuestionTypeMultiple, `["make", "new"]`, "AB", false},
+		{"多选题包含全部选项", model.QuestionTypeMultiple, `"ABCD"`, "", true},
+		{"答案为空", model.QuestionTypeMultiple, `[]`, "", true},
+		{"超出选项范围", model.QuestionTypeMultiple, `"AE"`, "", true},
+		{"答案类型错误", model.QuestionTypeMultiple, `3`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := aiQuestion{Title: "Go 中用于分配内存的关键字", Options: options, Answer: json.RawMessage(tt.answer)}
+			question, err := parseAIQuestion(item, "Go", tt.questionType)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("应返回错误，实际答案为 %s", question.Answer)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if question.Answer != tt.want || question.QuestionType != tt.questionType {
+				t.Fatalf("答案为 %s（%s），应为 %s（%s）", question.Answer, question.QuestionType, tt.want, tt.questionType)
+			}
+		})
+	}
+}
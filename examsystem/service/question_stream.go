@@ -0,0 +1,84 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// questionStreamParser 从流式输出的部分JSON中逐个提取 questions 数组里已经完整的题目对象
+// 只跟踪字符串和括号的嵌套，不校验JSON语法，提取出的对象由调用方解析
+type questionStreamParser struct {
+	key      string // 数组所在的字段名，为空时为 questions
+	buf      []byte
+	pos      int  // 下一个待扫描的位置
+	inArray  bool // 是否已进入 questions 数组
+	done     bool // questions 数组是否已结束
+	depth    int  // 在 questions 数组内的嵌套深度，0 表示位于两道题目之间
+	start    int  // 当前题目对象的起始位置
+	inString bool
+	escaped  bool
+}
+
+// Write 追加一段输出，返回这段输出之后新出现的完整题目对象
+func (p *questionStreamParser) Write(chunk string) []json.RawMessage {
+	p.buf = append(p.buf, chunk...)
+	if p.done {
+		return nil
+	}
+
+	if !p.inArray {
+		name := p.key
+		if name == "" {
+			name = "questions"
+		}
+		key := bytes.Index(p.buf, []byte(`"`+name+`"`))
+		if key < 0 {
+			return nil
+		}
+		open := bytes.IndexByte(p.buf[key:], '[')
+		if open < 0 {
+			return nil
+		}
+		p.inArray = true
+		p.pos = key + open + 1
+	}
+
+	var items []json.RawMessage
+	for ; p.pos < len(p.buf); p.pos++ {
+		c := p.buf[p.pos]
+		if p.inString {
+			switch {
+			case p.escaped:
+				p.escaped = false
+			case c == '\\':
+				p.escaped = true
+			case c == '"':
+				p.inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			p.inString = true
+		case '{', '[':
+			if p.depth == 0 {
+				p.start = p.pos
+			}
+			p.depth++
+		case '}', ']':
+			if p.depth == 0 {
+				// questions 数组结束
+				p.done = true
+				return items
+			}
+			p.depth--
+			if p.depth == 0 && c == '}' {
+				item := make([]byte, p.pos+1-p.start)
+				copy(item, p.buf[p.start:p.pos+1])
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"encoding/json"
+	"examsystem/dao/model"
+	"fmt"
+	"strings"
+)
+
+// 判断题答案的规范取值
+const (
+	JudgeAnswerTrue  = "true"
+	JudgeAnswerFalse = "false"
+)
+
+// 填空题多个可接受答案在纯文本中的分隔符，如 "golang|go"
+const blankVariantSeparator = "|"
+
+// 题目难度的取值范围，未指定难度的题目按中等难度保存
+const (
+	MinDifficulty     = 1
+	MaxDifficulty     = 5
+	DefaultDifficulty = 3
+)
+
+// difficultyDescs 各难度的中文说明
+var difficultyDescs = map[int]string{
+	1: "很容易",
+	2: "较容易",
+	3: "中等",
+	4: "较难",
+	5: "很难",
+}
+
+// cognitiveLevelInfo 布鲁姆认知层次的中文名称和出题要求
+type cognitiveLevelInfo struct {
+	Level model.CognitiveLevel
+	Name  string
+	Hint  string
+}
+
+// cognitiveLevels 按由低到高的顺序列出的认知层次
+var cognitiveLevels = []cognitiveLevelInfo{
+	{model.CognitiveLevelRemember, "记忆", "考查对事实、术语和基本概念的回忆"},
+	{model.CognitiveLevelUnderstand, "理解", "考查对概念含义的解释和举例"},
+	{model.CognitiveLevelApply, "应用", "考查在具体情境中运用知识解决问题"},
+	{model.CognitiveLevelAnalyze, "分析", "考查拆解问题、比较各部分之间的关系"},
+	{model.CognitiveLevelEvaluate, "评价", "考查依据标准对方案作出判断和取舍"},
+	{model.CognitiveLevelCreate, "创造", "考查综合所学知识设计新的方案"},
+}
+
+// IsValidDifficulty 判断难度是否在 1-5 之间
+func IsValidDifficulty(difficulty int) bool {
+	return difficulty >= MinDifficulty && difficulty <= MaxDifficulty
+}
+
+// lookupCognitiveLevel 查找认知层次，支持英文取值和中文名称，如 "apply"、"应用"
+func lookupCognitiveLevel(level string) (cognitiveLevelInfo, bool) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	for _, info := range cognitiveLevels {
+		if string(info.Level) == level || info.Name == level {
+			return info, true
+		}
+	}
+	return cognitiveLevelInfo{}, false
+}
+
+// IsValidCognitiveLevel 判断是否为支持的认知层次
+func IsValidCognitiveLevel(level model.CognitiveLevel) bool {
+	info, ok := lookupCognitiveLevel(string(level))
+	return ok && info.Level == level
+}
+
+// IsValidQuestionType 判断是否为支持的题目类型
+func IsValidQuestionType(questionType model.QuestionType) bool {
+	switch questionType {
+	case model.QuestionTypeSingle, model.QuestionTypeMultiple,
+		model.QuestionTypeJudge, model.QuestionTypeBlank, model.QuestionTypeShortAnswer,
+		model.QuestionTypeCoding:
+		return true
+	}
+	return false
+}
+
+// questionTypeDesc 题目类型的中文名称
+func questionTypeDesc(questionType model.QuestionType) string {
+	switch questionType {
+	case model.QuestionTypeMultiple:
+		return "多选题"
+	case model.QuestionTypeJudge:
+		return "判断题"
+	case model.QuestionTypeBlank:
+		return "填空题"
+	case model.QuestionTypeShortAnswer:
+		return "简答题"
+	case model.QuestionTypeCoding:
+		return "编程题"
+	default:
+		return "单选题"
+	}
+}
+
+// normalizeJudgeAnswer 将判断题答案规范为 true/false，无法识别时返回 false
+func normalizeJudgeAnswer(answer string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "true", "t", "yes", "y", "正确", "对", "是", "√", "✓":
+		return JudgeAnswerTrue, true
+	case "false", "f", "no", "n", "错误", "错", "否", "×", "✗":
+		return JudgeAnswerFalse, true
+	}
+	return "", false
+}
+
+// parseBlankAnswer 解析填空题答案，返回每个空的可接受答案列表
+// 支持 [["go","golang"],["8"]]、["go","8"] 两种JSON格式，以及单个空的纯文本 "go|golang"
+func parseBlankAnswer(answer string) ([][]string, error) {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil, fmt.Errorf("填空题答案不能为空")
+	}
+
+	var blanks [][]string
+	if strings.HasPrefix(answer, "[") {
+		var raw []json.RawMessage
+		if err := json.Unmarshal([]byte(answer), &raw); err != nil {
+			return nil, fmt.Errorf("填空题答案格式错误: %v", err)
+		}
+		for _, item := range raw {
+			var variants []string
+			if err := json.Unmarshal(item, &variants); err != nil {
+				var single string
+				if err := json.Unmarshal(item, &single); err != nil {
+					return nil, fmt.Errorf("填空题答案格式错误: %s", string(item))
+				}
+				variants = strings.Split(single, blankVariantSeparator)
+			}
+			blanks = append(blanks, variants)
+		}
+	} else {
+		blanks = [][]string{strings.Split(answer, blankVariantSeparator)}
+	}
+
+	for i, variants := range blanks {
+		cleaned := make([]string, 0, len(variants))
+		for _, v := range variants {
+			if v = strings.TrimSpace(v); v != "" {
+				cleaned = append(cleaned, v)
+			}
+		}
+		if len(cleaned) == 0 {
+			return nil, fmt.Errorf("第%d个空缺少答案", i+1)
+		}
+		blanks[i] = cleaned
+	}
+
+	if len(blanks) == 0 {
+		return nil, fmt.Errorf("填空题答案不能为空")
+	}
+	return blanks, nil
+}
+
+// parseBlankResponse 解析考生的填空题作答，支持JSON字符串数组或单个空的纯文本
+func parseBlankResponse(answer string) []string {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil
+	}
+
+	var responses []string
+	if strings.HasPrefix(answer, "[") && json.Unmarshal([]byte(answer), &responses) == nil {
+		return responses
+	}
+	return []string{answer}
+}
+
+// normalizeBlankText 统一填空题文本以便比较：去除首尾空白、合并连续空白、忽略大小写
+func normalizeBlankText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// parseCodeTestCases 解析编程题的测试用例，至少需要一个用例
+func parseCodeTestCases(testCases string) ([]CodeTestCase, error) {
+	var tests []CodeTestCase
+	if strings.TrimSpace(testCases) != "" {
+		if err := json.Unmarshal([]byte(testCases), &tests); err != nil {
+			return nil, fmt.Errorf("测试用例格式错误: %v", err)
+		}
+	}
+	if len(tests) == 0 {
+		return nil, fmt.Errorf("编程题至少需要一个测试用例")
+	}
+	return tests, nil
+}
+
+// validateQuestion 按题型校验题目内容，并将答案规范为统一的存储格式
+func validateQuestion(question *model.Question) error {
+	if strings.TrimSpace(question.Title) == "" {
+		return fmt.Errorf("题目内容不能为空")
+	}
+
+	if question.Difficulty == 0 {
+		question.Difficulty = DefaultDifficulty
+	}
+	if !IsValidDifficulty(question.Difficulty) {
+		return fmt.Errorf("%w，应为%d-%d，实际: %d", ErrInvalidDifficulty, MinDifficulty, MaxDifficulty, question.Difficulty)
+	}
+	if question.CognitiveLevel != "" && !IsValidCognitiveLevel(question.CognitiveLevel) {
+		return fmt.Errorf("%w: %s", ErrInvalidCognitive, question.CognitiveLevel)
+	}
+
+	var options []string
+	if question.Options != "" {
+		if err := json.Unmarshal([]byte(question.Options), &options); err != nil {
+			return fmt.Errorf("选项格式错误: %v", err)
+		}
+	}
+
+	switch question.QuestionType {
+	case model.QuestionTypeSingle, model.QuestionTypeMultiple:
+		if len(options) < 2 {
+			return fmt.Errorf("选择题至少需要2个选项")
+		}
+		answer := normalizeChoiceAnswer(question.Answer)
+		if answer == "" {
+			return fmt.Errorf("选择题答案不能为空")
+		}
+		for _, r := range answer {
+			if int(r-'A') >= len(options) {
+				return fmt.Errorf("答案 %c 超出选项范围", r)
+			}
+		}
+		if question.QuestionType == model.QuestionTypeSingle && len(answer) != 1 {
+			return fmt.Errorf("单选题只能有一个答案")
+		}
+		question.Answer = answer
+
+	case model.QuestionTypeJudge:
+		answer, ok := normalizeJudgeAnswer(question.Answer)
+		if !ok {
+			return fmt.Errorf("判断题答案应为 true 或 false，实际: %s", question.Answer)
+		}
+		question.Answer = answer
+		question.Options = "[]"
+
+	case model.QuestionTypeBlank:
+		blanks, err := parseBlankAnswer(question.Answer)
+		if err != nil {
+			return err
+		}
+		answerJSON, err := json.Marshal(blanks)
+		if err != nil {
+			return err
+		}
+		question.Answer = string(answerJSON)
+		question.Options = "[]"
+
+	case model.QuestionTypeShortAnswer:
+		// 简答题的答案为参考答案，允许为空，由阅卷老师人工评分
+		question.Answer = strings.TrimSpace(question.Answer)
+		question.Options = "[]"
+
+	case model.QuestionTypeCoding:
+		// 编程题的答案为参考实现，评分只依据测试用例
+		if NormalizeCodeLanguage(question.Language) == "" {
+			return fmt.Errorf("编程题不支持的编程语言: %s", question.Language)
+		}
+		tests, err := parseCodeTestCases(question.TestCases)
+		if err != nil {
+			return err
+		}
+		testsJSON, err := json.Marshal(tests)
+		if err != nil {
+			return err
+		}
+		question.TestCases = string(testsJSON)
+		question.Options = "[]"
+
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidQuestionType, question.QuestionType)
+	}
+
+	return nil
+}
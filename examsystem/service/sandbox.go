@@ -0,0 +1,28 @@
+package service
+
+// sandboxSpec 沙箱初始化进程的配置，以 JSON 作为初始化进程的第一个参数传入
+// 沙箱的根目录是内存文件系统，只包含下面列出的挂载和 /dev、/proc、/tmp
+type sandboxSpec struct {
+	Root     string         `json:"root"`     // 宿主机上的空目录，作为沙箱根目录的挂载点
+	Binds    []sandboxMount `json:"binds"`    // 挂载到沙箱中的宿主机路径
+	Overlays []sandboxMount `json:"overlays"` // 以宿主机目录为只读下层的目录，写入的内容只保存在本次沙箱的内存中
+	Dir      string         `json:"dir"`      // 沙箱内的工作目录
+	Args     []string       `json:"args"`
+	Env      []string       `json:"env"`
+	Limits   sandboxLimits  `json:"limits"`
+}
+
+// sandboxMount 挂载到沙箱中的宿主机路径
+type sandboxMount struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Writable bool   `json:"writable"`
+}
+
+// sandboxLimits 沙箱中进程的资源限制，为0时不限制
+type sandboxLimits struct {
+	MemoryBytes   uint64 `json:"memory_bytes"`
+	CPUSeconds    uint64 `json:"cpu_seconds"`
+	Processes     uint64 `json:"processes"`
+	FileSizeBytes uint64 `json:"file_size_bytes"`
+}
@@ -0,0 +1,380 @@
+//go:build linux
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxInitArg 沙箱初始化进程的 argv[0]，服务程序以此识别自己是否作为初始化进程启动
+const sandboxInitArg = "examsystem-sandbox-init"
+
+// 沙箱初始化进程继承的文件描述符：设置失败时写入错误信息的管道，以及考生程序的标准输出和标准错误。
+// 初始化进程自身的标准输出和标准错误指向 /dev/null，避免服务程序初始化时的日志混入考生程序的输出
+const (
+	sandboxErrorFD  = 3
+	sandboxStdoutFD = 4
+	sandboxStderrFD = 5
+)
+
+// sandboxNamespaces 沙箱使用的命名空间：用户、挂载、网络（只有未启用的回环网卡）、进程、IPC 和主机名
+const sandboxNamespaces = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET |
+	syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+
+// sandboxSecurebits 锁定的安全位（linux/securebits.h）：root 用户执行程序时不获得能力，
+// 切换用户时不调整能力，不能保留或提升能力
+const sandboxSecurebits = 1<<0 | 1<<1 | // SECBIT_NOROOT
+	1<<2 | 1<<3 | // SECBIT_NO_SETUID_FIXUP
+	1<<5 | // SECBIT_KEEP_CAPS_LOCKED
+	1<<6 | 1<<7 // SECBIT_NO_CAP_AMBIENT_RAISE
+
+// sandboxDevices 绑定到沙箱中的设备
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// sandboxProcess 运行中的沙箱
+type sandboxProcess struct {
+	cmd    *exec.Cmd
+	copied sync.WaitGroup
+}
+
+// startSandbox 以 uid、gid 作为沙箱在宿主机上的用户启动沙箱初始化进程，
+// 沙箱设置完成、考生程序开始执行后返回；设置失败时返回 error
+func startSandbox(ctx context.Context, spec *sandboxSpec, uid, gid int, stdin io.Reader, stdout, stderr io.Writer) (*sandboxProcess, error) {
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var pipes [3][2]*os.File
+	for i := range pipes {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, fmt.Errorf("创建管道失败: %v", err)
+		}
+		defer w.Close()
+		pipes[i] = [2]*os.File{r, w}
+	}
+	defer pipes[0][0].Close()
+
+	cmd := exec.CommandContext(ctx, "/proc/self/exe")
+	cmd.Args = []string{sandboxInitArg, string(specJSON)}
+	cmd.Env = []string{}
+	cmd.Stdin = stdin
+	cmd.ExtraFiles = []*os.File{pipes[0][1], pipes[1][1], pipes[2][1]}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:     true,
+		Cloneflags:  sandboxNamespaces,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: uid, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: gid, Size: 1}},
+		// 切换到命名空间中的 root，否则以宿主机 root 启动时执行初始化进程会丢失命名空间中的权限
+		Credential: &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: true},
+	}
+	// 考生程序是沙箱中的1号进程，结束整个进程组即可结束沙箱中的全部进程
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	if err := cmd.Start(); err != nil {
+		pipes[1][0].Close()
+		pipes[2][0].Close()
+		return nil, fmt.Errorf("启动代码运行沙箱失败: %v", err)
+	}
+	for _, pipe := range pipes {
+		pipe[1].Close()
+	}
+
+	p := &sandboxProcess{cmd: cmd}
+	for i, w := range []io.Writer{stdout, stderr} {
+		r := pipes[i+1][0]
+		p.copied.Add(1)
+		go func() {
+			defer p.copied.Done()
+			defer r.Close()
+			io.Copy(w, r)
+		}()
+	}
+
+	// 错误管道在考生程序开始执行时随 exec 关闭；读到内容说明设置失败
+	setupErr, _ := io.ReadAll(pipes[0][0])
+	if len(setupErr) > 0 {
+		p.Wait()
+		return nil, fmt.Errorf("设置代码运行沙箱失败: %s", setupErr)
+	}
+	return p, nil
+}
+
+// Wait 等待考生程序结束并读取全部输出
+func (p *sandboxProcess) Wait() error {
+	err := p.cmd.Wait()
+	p.copied.Wait()
+	return err
+}
+
+// InitSandbox 当前进程作为沙箱初始化进程启动时，完成沙箱设置后执行考生程序，不会返回；否则直接返回。
+// 必须在 main 函数和测试的 TestMain 开头调用
+func InitSandbox() {
+	if len(os.Args) != 2 || os.Args[0] != sandboxInitArg {
+		return
+	}
+	// 能力和安全位是线程级的设置，需要在执行考生程序的同一线程中完成
+	runtime.LockOSThread()
+
+	var spec sandboxSpec
+	err := json.Unmarshal([]byte(os.Args[1]), &spec)
+	if err == nil {
+		err = enterSandbox(&spec)
+	}
+	errPipe := os.NewFile(sandboxErrorFD, "sandbox-error")
+	fmt.Fprint(errPipe, err)
+	os.Exit(127)
+}
+
+// enterSandbox 设置挂载、资源限制和权限后执行考生程序，只在出错时返回
+func enterSandbox(spec *sandboxSpec) error {
+	syscall.CloseOnExec(sandboxErrorFD)
+	syscall.CloseOnExec(sandboxStdoutFD)
+	syscall.CloseOnExec(sandboxStderrFD)
+
+	if err := buildSandboxRoot(spec); err != nil {
+		return err
+	}
+	if err := syscall.Chdir(spec.Dir); err != nil {
+		return fmt.Errorf("进入工作目录失败: %v", err)
+	}
+	if err := setSandboxLimits(spec.Limits); err != nil {
+		return err
+	}
+	if err := dropCapabilities(); err != nil {
+		return err
+	}
+
+	// exec 在沙箱的新根目录中查找程序，环境变量使用配置中的值，不继承服务进程的环境变量
+	path := spec.Args[0]
+	if filepath.Base(path) == path {
+		os.Clearenv()
+		for _, kv := range spec.Env {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				os.Setenv(k, v)
+			}
+		}
+		found, err := exec.LookPath(path)
+		if err != nil {
+			return fmt.Errorf("找不到程序 %s: %v", path, err)
+		}
+		path = found
+	}
+	if err := unix.Dup3(sandboxStdoutFD, 1, 0); err != nil {
+		return err
+	}
+	if err := unix.Dup3(sandboxStderrFD, 2, 0); err != nil {
+		return err
+	}
+	return fmt.Errorf("执行 %s 失败: %v", path, syscall.Exec(path, spec.Args, spec.Env))
+}
+
+// buildSandboxRoot 在内存文件系统中组装沙箱的根目录并切换过去，原来的根目录随后卸载
+func buildSandboxRoot(spec *sandboxSpec) error {
+	root := spec.Root
+	// 挂载只在沙箱的挂载命名空间中生效，不传播回宿主机
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("设置挂载传播失败: %v", err)
+	}
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size=1m,mode=0755"); err != nil {
+		return fmt.Errorf("挂载沙箱根目录失败: %v", err)
+	}
+
+	for _, bind := range spec.Binds {
+		if err := bindIntoSandbox(root, bind); err != nil {
+			return err
+		}
+	}
+	for _, device := range sandboxDevices {
+		if err := bindIntoSandbox(root, sandboxMount{Source: device, Target: device, Writable: true}); err != nil {
+			return err
+		}
+	}
+	for name, target := range map[string]string{"fd": "/proc/self/fd", "stdin": "/proc/self/fd/0", "stdout": "/proc/self/fd/1", "stderr": "/proc/self/fd/2"} {
+		if err := os.Symlink(target, filepath.Join(root, "dev", name)); err != nil {
+			return err
+		}
+	}
+
+	// 新的 proc 只显示沙箱进程命名空间中的进程
+	procDir := filepath.Join(root, "proc")
+	if err := os.Mkdir(procDir, 0555); err != nil {
+		return err
+	}
+	if err := syscall.Mount("proc", procDir, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("挂载 /proc 失败: %v", err)
+	}
+
+	// /tmp 和 overlay 的写入层都在内存中，大小受限，随沙箱一起销毁
+	tmpDir := filepath.Join(root, "tmp")
+	if err := os.Mkdir(tmpDir, 0755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("tmpfs", tmpDir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size=64m,mode=1777"); err != nil {
+		return fmt.Errorf("挂载 /tmp 失败: %v", err)
+	}
+	for i, overlay := range spec.Overlays {
+		layerDir := filepath.Join(root, fmt.Sprintf(".overlay-%d", i))
+		if err := os.Mkdir(layerDir, 0755); err != nil {
+			return err
+		}
+		if err := syscall.Mount("tmpfs", layerDir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size=256m,mode=0755"); err != nil {
+			return fmt.Errorf("挂载 %s 的写入层失败: %v", overlay.Target, err)
+		}
+		upper, work := filepath.Join(layerDir, "upper"), filepath.Join(layerDir, "work")
+		target := filepath.Join(root, overlay.Target)
+		for _, dir := range []string{upper, work, target} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+		options := "userxattr,lowerdir=" + overlay.Source + ",upperdir=" + upper + ",workdir=" + work
+		if err := syscall.Mount("overlay", target, "overlay", syscall.MS_NOSUID|syscall.MS_NODEV, options); err != nil {
+			return fmt.Errorf("挂载 %s 失败: %v", overlay.Target, err)
+		}
+	}
+
+	oldRoot := filepath.Join(root, ".old-root")
+	if err := os.Mkdir(oldRoot, 0700); err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("切换根目录失败: %v", err)
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return err
+	}
+	if err := syscall.Unmount("/.old-root", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("卸载原根目录失败: %v", err)
+	}
+	if err := os.Remove("/.old-root"); err != nil {
+		return err
+	}
+	// 根目录只用于放置挂载点，设置完成后改为只读
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("设置根目录只读失败: %v", err)
+	}
+	return nil
+}
+
+// bindIntoSandbox 将宿主机路径绑定到沙箱根目录下的同名位置，符号链接按原样重建
+func bindIntoSandbox(root string, bind sandboxMount) error {
+	target := filepath.Join(root, bind.Target)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(bind.Source)
+	if err != nil {
+		return fmt.Errorf("挂载 %s 失败: %v", bind.Source, err)
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(bind.Source)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	case info.IsDir():
+		err = os.MkdirAll(target, 0755)
+	default:
+		var file *os.File
+		if file, err = os.Create(target); err == nil {
+			file.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := syscall.Mount(bind.Source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("挂载 %s 失败: %v", bind.Source, err)
+	}
+	if bind.Writable {
+		return nil
+	}
+	// 重新挂载为只读时需要保留原挂载点被锁定的标志，否则在用户命名空间中没有权限
+	var stat unix.Statfs_t
+	if err := unix.Statfs(bind.Source, &stat); err != nil {
+		return err
+	}
+	flags := uintptr(syscall.MS_REMOUNT | syscall.MS_BIND | syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV)
+	for statFlag, mountFlag := range map[int64]uintptr{
+		unix.ST_NOEXEC:     syscall.MS_NOEXEC,
+		unix.ST_NOATIME:    syscall.MS_NOATIME,
+		unix.ST_NODIRATIME: syscall.MS_NODIRATIME,
+		unix.ST_RELATIME:   syscall.MS_RELATIME,
+	} {
+		if stat.Flags&statFlag != 0 {
+			flags |= mountFlag
+		}
+	}
+	if err := syscall.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("设置 %s 只读失败: %v", bind.Source, err)
+	}
+	return nil
+}
+
+// setSandboxLimits 设置内存、CPU时间、进程数和文件大小限制，并禁止生成 core 文件
+func setSandboxLimits(limits sandboxLimits) error {
+	for resource, value := range map[int]uint64{
+		unix.RLIMIT_DATA:  limits.MemoryBytes,
+		unix.RLIMIT_CPU:   limits.CPUSeconds,
+		unix.RLIMIT_NPROC: limits.Processes,
+		unix.RLIMIT_FSIZE: limits.FileSizeBytes,
+		unix.RLIMIT_CORE:  0,
+	} {
+		if value == 0 && resource != unix.RLIMIT_CORE {
+			continue
+		}
+		if err := unix.Setrlimit(resource, &unix.Rlimit{Cur: value, Max: value}); err != nil {
+			return fmt.Errorf("设置资源限制失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// dropCapabilities 放弃沙箱中 root 用户的全部能力，执行考生程序后也不会重新获得，
+// 使考生程序无法修改挂载、访问其他用户的文件
+func dropCapabilities() error {
+	for c := 0; c <= unix.CAP_LAST_CAP; c++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return fmt.Errorf("放弃能力失败: %v", err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_SET_SECUREBITS, sandboxSecurebits, 0, 0, 0); err != nil {
+		return fmt.Errorf("设置安全位失败: %v", err)
+	}
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("设置 no_new_privs 失败: %v", err)
+	}
+	return nil
+}
+
+// configureProcessGroup 不使用沙箱时让命令在独立进程组中运行，超时后整组结束
+func configureProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// killProcessGroup 结束考生程序退出后留在进程组中的子进程
+func killProcessGroup(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,32 @@
+//go:build !linux
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// sandboxProcess 非 Linux 平台不支持沙箱
+type sandboxProcess struct{}
+
+// startSandbox 非 Linux 平台没有所需的命名空间，拒绝运行代码
+func startSandbox(ctx context.Context, spec *sandboxSpec, uid, gid int, stdin io.Reader, stdout, stderr io.Writer) (*sandboxProcess, error) {
+	return nil, fmt.Errorf("当前平台不支持代码运行沙箱，本地开发时可以设置 CODE_RUNNER_SANDBOX=false")
+}
+
+// Wait 非 Linux 平台不会启动沙箱
+func (p *sandboxProcess) Wait() error {
+	return nil
+}
+
+// InitSandbox 非 Linux 平台不会以沙箱初始化进程启动
+func InitSandbox() {}
+
+// configureProcessGroup 非 Linux 平台不设置进程组
+func configureProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup 非 Linux 平台没有进程组
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"encoding/json"
+	"examsystem/dao/model"
+	"math/rand"
+	"strings"
+)
+
+// ExamQuestion 考生看到的题目：题目顺序和选项顺序可能已按考试的随机种子打乱
+type ExamQuestion struct {
+	*PaperQuestionDetail
+	DisplayOrder int      // 考生看到的题号，从1开始
+	Options      []string // 按考生看到的顺序排列的选项
+	OptionOrder  []int    // OptionOrder[i] 为第 i 个显示选项在原题中的下标，未打乱时为 nil
+}
+
+// ToCanonicalAnswer 将考生按显示顺序作答的选项字母转换为原题的选项字母
+func (q *ExamQuestion) ToCanonicalAnswer(answer string) string {
+	if q.OptionOrder == nil {
+		return answer
+	}
+	return mapChoiceLetters(answer, func(displayIndex int) int {
+		return q.OptionOrder[displayIndex]
+	}, len(q.OptionOrder))
+}
+
+// ToDisplayAnswer 将原题的选项字母转换为考生看到的选项字母
+func (q *ExamQuestion) ToDisplayAnswer(answer string) string {
+	if q.OptionOrder == nil {
+		return answer
+	}
+	displayIndex := make([]int, len(q.OptionOrder))
+	for display, canonical := range q.OptionOrder {
+		displayIndex[canonical] = display
+	}
+	return mapChoiceLetters(answer, func(canonicalIndex int) int {
+		return displayIndex[canonicalIndex]
+	}, len(q.OptionOrder))
+}
+
+// buildExamQuestions 按考试的乱序设置生成考生看到的题目列表
+// 同一考试会话使用相同的种子，刷新页面或评分时得到的顺序始终一致
+func buildExamQuestions(session *model.ExamSession, details []*PaperQuestionDetail) []*ExamQuestion {
+	ordered := make([]*PaperQuestionDetail, len(details))
+	copy(ordered, details)
+	if session.ShuffleQuestions {
+		rng := rand.New(rand.NewSource(session.ShuffleSeed))
+		rng.Shuffle(len(ordered), func(i, j int) {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		})
+	}
+
+	questions := make([]*ExamQuestion, 0, len(ordered))
+	for i, detail := range ordered {
+		var options []string
+		json.Unmarshal([]byte(detail.Question.Options), &options)
+
+		eq := &ExamQuestion{
+			PaperQuestionDetail: detail,
+			DisplayOrder:        i + 1,
+			Options:             options,
+		}
+		if session.ShuffleOptions && isChoiceQuestion(detail.Question.QuestionType) && len(options) > 1 {
+			// 每道题使用独立的随机序列，避免题目增删影响其他题的选项顺序
+			rng := rand.New(rand.NewSource(session.ShuffleSeed ^ detail.Question.ID*0x9E3779B9))
+			eq.OptionOrder = rng.Perm(len(options))
+			eq.Options = make([]string, len(options))
+			for display, canonical := range eq.OptionOrder {
+				eq.Options[display] = options[canonical]
+			}
+		}
+		questions = append(questions, eq)
+	}
+	return questions
+}
+
+// isChoiceQuestion 判断题型是否为以选项字母作答的选择题
+func isChoiceQuestion(questionType model.QuestionType) bool {
+	return questionType == model.QuestionTypeSingle || questionType == model.QuestionTypeMultiple
+}
+
+// mapChoiceLetters 按下标映射转换答案中的选项字母，超出选项范围的字母原样保留
+func mapChoiceLetters(answer string, mapIndex func(int) int, optionCount int) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(answer) {
+		index := int(r - 'A')
+		if index >= 0 && index < optionCount {
+			b.WriteRune(rune('A' + mapIndex(index)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return normalizeChoiceAnswer(b.String())
+}
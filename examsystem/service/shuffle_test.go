@@ -0,0 +1,75 @@
+package service
+
+import (
+	"encoding/json"
+	"examsystem/dao/model"
+	"strings"
+	"testing"
+)
+
+// testPaperDetails 试卷中的题目：一道单选题、一道多选题
+func testPaperDetails() []*PaperQuestionDetail {
+	questions := []*model.Question{
+		{ID: 1, QuestionType: model.QuestionTypeSingle, Options: `["北京", "上海", "广州", "深圳"]`, Answer: "A"},
+		{ID: 2, QuestionType: model.QuestionTypeMultiple, Options: `["int", "string", "func", "class", "bool"]`, Answer: "ABE"},
+	}
+	details := make([]*PaperQuestionDetail, len(questions))
+	for i, question := range questions {
+		details[i] = &PaperQuestionDetail{
+			PaperQuestion: &model.PaperQuestion{QuestionID: question.ID, Score: 5, QuestionOrder: i + 1},
+			Question:      question,
+		}
+	}
+	return details
+}
+
+func TestBuildExamQuestionsIsStable(t *testing.T) {
+	session := &model.ExamSession{ShuffleSeed: 7, ShuffleQuestions: true, ShuffleOptions: true}
+	first := buildExamQuestions(session, testPaperDetails())
+	second := buildExamQuestions(session, testPaperDetails())
+	for i := range first {
+		if first[i].Question.ID != second[i].Question.ID || strings.Join(first[i].Options, ",") != strings.Join(second[i].Options, ",") {
+			t.Fatalf("相同的种子应得到相同的顺序: %d %v 和 %d %v",
+				first[i].Question.ID, first[i].Options, second[i].Question.ID, second[i].Options)
+		}
+	}
+}
+
+func TestShuffledAnswerMapping(t *testing.T) {
+	optionsMoved := false
+	for seed := int64(1); seed <= 20; seed++ {
+		session := &model.ExamSession{ShuffleSeed: seed, ShuffleQuestions: true, ShuffleOptions: true}
+		for _, eq := range buildExamQuestions(session, testPaperDetails()) {
+			var canonical []string
+			if err := json.Unmarshal([]byte(eq.Question.Options), &canonical); err != nil {
+				t.Fatal(err)
+			}
+			optionsMoved = optionsMoved || strings.Join(canonical, ",") != strings.Join(eq.Options, ",")
+
+			// 考生选择的显示选项，映射回原题后应是同一个选项
+			for display := range eq.Options {
+				letter := string(rune('A' + display))
+				mapped := eq.ToCanonicalAnswer(letter)
+				if canonical[mapped[0]-'A'] != eq.Options[display] {
+					t.Fatalf("种子 %d: 题目 %d 显示选项 %s（%s）映射为 %s", seed, eq.Question.ID, letter, eq.Options[display], mapped)
+				}
+				if back := eq.ToDisplayAnswer(mapped); back != letter {
+					t.Fatalf("种子 %d: 题目 %d 的 %s 转换回显示字母为 %s", seed, eq.Question.ID, letter, back)
+				}
+			}
+
+			// 按显示顺序选出正确答案后，评分应为满分
+			var grader Grader = &SingleChoiceGrader{}
+			if eq.Question.QuestionType == model.QuestionTypeMultiple {
+				grader = &MultipleChoiceGrader{Rule: MultipleChoiceAllOrNothing}
+			}
+			picked := eq.ToDisplayAnswer(eq.Question.Answer)
+			if result := grader.Grade(eq.Question, eq.ToCanonicalAnswer(picked), 5); !result.IsCorrect {
+				t.Fatalf("种子 %d: 题目 %d 按显示顺序选出正确答案 %s 后评分为 %+v", seed, eq.Question.ID, picked, result)
+			}
+		}
+	}
+	if !optionsMoved {
+		t.Fatalf("开启乱序后选项顺序始终没有变化")
+	}
+}
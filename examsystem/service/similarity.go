@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"examsystem/dao/model"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// SimilarQuestion 与题目相似的已有题目
+type SimilarQuestion struct {
+	ID         int64   `json:"id"`
+	Title      string  `json:"title"`
+	Similarity float64 `json:"similarity"` // 相似度，0-1
+}
+
+// shingleSet 文本的字符二元组集合
+type shingleSet map[string]struct{}
+
+// questionFingerprint 用于比较相似度的题目特征
+type questionFingerprint struct {
+	shingles shingleSet // 题干和选项的字符二元组
+	numbers  shingleSet // 题干和选项中的数字，如 "3.14" 记为 "3" 和 "14"
+}
+
+// newQuestionFingerprint 将题干和选项规范化后切分为字符二元组，并单独提取其中的数字
+// 中文没有空格分词，按相邻两个字符切分对中英文都适用
+func newQuestionFingerprint(question *model.Question) questionFingerprint {
+	text := question.Title
+	var options []string
+	if json.Unmarshal([]byte(question.Options), &options) == nil {
+		text += " " + strings.Join(options, " ")
+	}
+
+	// 只保留字母和数字，忽略大小写、空白和标点
+	var runes []rune
+	numbers := make(shingleSet)
+	number := ""
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsDigit(r) {
+			number += string(r)
+		} else if number != "" {
+			numbers[number] = struct{}{}
+			number = ""
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	if number != "" {
+		numbers[number] = struct{}{}
+	}
+
+	set := make(shingleSet)
+	if len(runes) == 1 {
+		set[string(runes)] = struct{}{}
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		set[string(runes[i:i+2])] = struct{}{}
+	}
+	return questionFingerprint{shingles: set, numbers: numbers}
+}
+
+// questionSimilarity 计算两道题目的相似度：字符二元组的 Jaccard 相似度，
+// 题目中有数字时再乘以数字的 Jaccard 相似度，只有数字不同的题目（如 "3+5=?" 和 "7+9=?"）不算相似
+func questionSimilarity(a, b questionFingerprint) float64 {
+	similarity := jaccard(a.shingles, b.shingles)
+	if len(a.numbers) > 0 || len(b.numbers) > 0 {
+		similarity *= jaccard(a.numbers, b.numbers)
+	}
+	return similarity
+}
+
+// jaccard 计算两个集合的 Jaccard 相似度
+func jaccard(a, b shingleSet) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	common := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// similarityIndex 在一组题目中查找相似的题目
+type similarityIndex struct {
+	threshold    float64
+	questions    []*model.Question
+	fingerprints []questionFingerprint
+}
+
+// newSimilarityIndex 创建相似题目索引，threshold 不大于0时不查找相似题目
+func newSimilarityIndex(threshold float64, questions []*model.Question) *similarityIndex {
+	index := &similarityIndex{threshold: threshold}
+	for _, question := range questions {
+		index.add(question)
+	}
+	return index
+}
+
+// add 将题目加入索引
+func (index *similarityIndex) add(question *model.Question) {
+	if index.threshold <= 0 {
+		return
+	}
+	index.questions = append(index.questions, question)
+	index.fingerprints = append(index.fingerprints, newQuestionFingerprint(question))
+}
+
+// find 查找与题目最相似且相似度达到阈值的题目，不与自身比较，没有时返回 nil
+func (index *similarityIndex) find(question *model.Question) *SimilarQuestion {
+	if index.threshold <= 0 || len(index.questions) == 0 {
+		return nil
+	}
+
+	fingerprint := newQuestionFingerprint(question)
+	var best *SimilarQuestion
+	for i, other := range index.questions {
+		if other == question || (question.ID != 0 && other.ID == question.ID) {
+			continue
+		}
+		similarity := questionSimilarity(fingerprint, index.fingerprints[i])
+		if similarity >= index.threshold && (best == nil || similarity > best.Similarity) {
+			best = &SimilarQuestion{ID: other.ID, Title: other.Title, Similarity: similarity}
+		}
+	}
+	if best != nil {
+		best.Similarity = math.Round(best.Similarity*100) / 100
+	}
+	return best
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"examsystem/dao/model"
+	"testing"
+)
+
+func TestQuestionSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     *model.Question
+		min, max float64
+	}{
+		{
+			name: "完全相同",
+			a:    &model.Question{Title: "Go 语言中 defer 语句的执行顺序是什么？"},
+			b:    &model.Question{Title: "Go 语言中 defer 语句的执行顺序是什么？"},
+			min:  1, max: 1,
+		},
+		{
+			name: "只有大小写、空白和标点不同",
+			a:    &model.Question{Title: "What does the DEFER keyword do?"},
+			b:    &model.Question{Title: "what does the defer keyword do"},
+			min:  1, max: 1,
+		},
+		{
+			name: "措辞略有不同",
+			a:    &model.Question{Title: "Go 语言中 defer 语句的执行顺序是什么？"},
+			b:    &model.Question{Title: "Go 语言中 defer 语句的执行顺序是怎样的？"},
+			min:  0.7, max: 0.95,
+		},
+		{
+			name: "只有数字不同",
+			a:    &model.Question{Title: "计算 1234 + 5678 的结果，并写出计算过程"},
+			b:    &model.Question{Title: "计算 4321 + 8765 的结果，并写出计算过程"},
+			min:  0, max: 0,
+		},
+		{
+			name: "数字部分相同",
+			a:    &model.Question{Title: "数组 [1, 2, 3, 4] 的元素之和是多少？"},
+			b:    &model.Question{Title: "数组 [1, 2, 3, 5] 的元素之和是多少？"},
+			min:  0.4, max: 0.7,
+		},
+		{
+			name: "选项不同",
+			a:    &model.Question{Title: "以下哪个是 Go 的关键字？", Options: `["func", "def", "fn", "function"]`},
+			b:    &model.Question{Title: "以下哪个是 Go 的关键字？", Options: `["select", "switch", "case", "when"]`},
+			min:  0.2, max: 0.7,
+		},
+		{
+			name: "完全不同",
+			a:    &model.Question{Title: "简述 TCP 三次握手的过程"},
+			b:    &model.Question{Title: "什么是数据库索引？"},
+			min:  0, max: 0.1,
+		},
+		{
+			name: "空题目",
+			a:    &model.Question{Title: ""},
+			b:    &model.Question{Title: "什么是数据库索引？"},
+			min:  0, max: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			similarity := questionSimilarity(newQuestionFingerprint(tt.a), newQuestionFingerprint(tt.b))
+			if similarity < tt.min || similarity > tt.max {
+				t.Fatalf("相似度 = %.2f，应在 %.2f 到 %.2f 之间", similarity, tt.min, tt.max)
+			}
+			if reverse := questionSimilarity(newQuestionFingerprint(tt.b), newQuestionFingerprint(tt.a)); reverse != similarity {
+				t.Fatalf("相似度不对称: %.2f 和 %.2f", similarity, reverse)
+			}
+		})
+	}
+}
+
+func TestSimilarityIndexFind(t *testing.T) {
+	bank := []*model.Question{
+		{ID: 1, Title: "Go 语言中 defer 语句的执行顺序是什么？"},
+		{ID: 2, Title: "计算 1234 + 5678 的结果"},
+	}
+
+	tests := []struct {
+		name      string
+		threshold float64
+		question  *model.Question
+		wantID    int64 // 0 表示没有相似题目
+	}{
+		{"相似的题目", 0.7, &model.Question{Title: "Go 语言中 defer 语句的执行顺序是怎样的？"}, 1},
+		{"只有数字不同的题目", 0.7, &model.Question{Title: "计算 4321 + 8765 的结果"}, 0},
+		{"不与自身比较", 0.7, &model.Question{ID: 1, Title: "Go 语言中 defer 语句的执行顺序是什么？"}, 0},
+		{"阈值为0时不查找", 0, &model.Question{Title: "Go 语言中 defer 语句的执行顺序是什么？"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			similar := newSimilarityIndex(tt.threshold, bank).find(tt.question)
+			switch {
+			case tt.wantID == 0 && similar != nil:
+				t.Fatalf("不应找到相似题目，找到了 %d（相似度%.2f）", similar.ID, similar.Similarity)
+			case tt.wantID != 0 && (similar == nil || similar.ID != tt.wantID):
+				t.Fatalf("应找到题目 %d，实际为 %+v", tt.wantID, similar)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"errors"
+	"examsystem/config"
+	"examsystem/dao"
+	"examsystem/dao/model"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTrashItemNotFound = errors.New("回收站中没有该项目")
+	ErrTrashItemInUse    = errors.New("仍被引用，不能彻底删除")
+)
+
+// TrashService 回收站服务：查看、恢复和彻底删除已删除的题目和试卷
+type TrashService struct {
+	questionDAO *dao.QuestionDAO
+	paperDAO    *dao.PaperDAO
+	examDAO     *dao.ExamDAO
+	config      config.TrashConfig
+}
+
+// NewTrashService 创建回收站服务实例
+func NewTrashService(questionDAO *dao.QuestionDAO, paperDAO *dao.PaperDAO, examDAO *dao.ExamDAO, trashConfig config.TrashConfig) *TrashService {
+	return &TrashService{
+		questionDAO: questionDAO,
+		paperDAO:    paperDAO,
+		examDAO:     examDAO,
+		config:      trashConfig,
+	}
+}
+
+// TrashQuestion 回收站中的题目
+type TrashQuestion struct {
+	Question  *model.Question
+	ExpiresAt *time.Time     // 自动彻底删除的时间，不自动删除时为空
+	Papers    []*model.Paper // 包含该题目的试卷（包括回收站中的试卷）
+}
+
+// TrashPaper 回收站中的试卷
+type TrashPaper struct {
+	Paper         *model.Paper
+	QuestionCount int
+	ExpiresAt     *time.Time // 自动彻底删除的时间，不自动删除时为空
+}
+
+// GetTrash 获取用户回收站中的题目和试卷
+func (s *TrashService) GetTrash(userID int64) ([]*TrashQuestion, []*TrashPaper, error) {
+	questions, err := s.questionDAO.GetDeletedQuestionsByUserID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	trashQuestions := make([]*TrashQuestion, 0, len(questions))
+	for _, question := range questions {
+		papers, err := s.paperDAO.GetPapersByQuestionID(question.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		trashQuestions = append(trashQuestions, &TrashQuestion{
+			Question:  question,
+			ExpiresAt: s.expiresAt(question.DeletedAt.Time),
+			Papers:    papers,
+		})
+	}
+
+	papers, err := s.paperDAO.GetDeletedPapersByCreatorID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	paperIDs := make([]int64, 0, len(papers))
+	for _, paper := range papers {
+		paperIDs = append(paperIDs, paper.ID)
+	}
+	questionCounts, err := s.paperDAO.CountQuestionsByPaperIDs(paperIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	trashPapers := make([]*TrashPaper, 0, len(papers))
+	for _, paper := range papers {
+		trashPapers = append(trashPapers, &TrashPaper{
+			Paper:         paper,
+			QuestionCount: questionCounts[paper.ID],
+			ExpiresAt:     s.expiresAt(*paper.DeletedAt),
+		})
+	}
+
+	return trashQuestions, trashPapers, nil
+}
+
+// expiresAt 计算自动彻底删除的时间
+func (s *TrashService) expiresAt(deletedAt time.Time) *time.Time {
+	if s.config.Retention <= 0 {
+		return nil
+	}
+	t := deletedAt.Add(s.config.Retention)
+	return &t
+}
+
+// RestoreQuestion 从回收站恢复题目，返回包含该题目的试卷
+// 删除题目不会将其移出试卷，恢复后这些试卷中的题目重新可以在题库中管理
+func (s *TrashService) RestoreQuestion(userID, questionID int64) ([]*model.Paper, error) {
+	if _, err := s.getTrashQuestion(userID, questionID); err != nil {
+		return nil, err
+	}
+	if err := s.questionDAO.RestoreQuestions([]int64{questionID}); err != nil {
+		return nil, err
+	}
+	return s.paperDAO.GetPapersByQuestionID(questionID)
+}
+
+// RestorePaper 从回收站恢复试卷，试卷中已删除的题目一并恢复，返回一并恢复的题目ID
+func (s *TrashService) RestorePaper(userID, paperID int64) ([]int64, error) {
+	if _, err := s.getTrashPaper(userID, paperID); err != nil {
+		return nil, err
+	}
+
+	paperQuestions, err := s.paperDAO.GetPaperQuestions(paperID)
+	if err != nil {
+		return nil, err
+	}
+	questionIDs := make([]int64, 0, len(paperQuestions))
+	for _, pq := range paperQuestions {
+		questionIDs = append(questionIDs, pq.QuestionID)
+	}
+	questions, err := s.questionDAO.GetQuestionsByIDs(questionIDs)
+	if err != nil {
+		return nil, err
+	}
+	restoredIDs := make([]int64, 0)
+	for _, question := range questions {
+		if question.DeletedAt.Valid && question.UserID == userID {
+			restoredIDs = append(restoredIDs, question.ID)
+		}
+	}
+
+	if len(restoredIDs) > 0 {
+		if err := s.questionDAO.RestoreQuestions(restoredIDs); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.paperDAO.RestorePaper(paperID); err != nil {
+		return nil, err
+	}
+	return restoredIDs, nil
+}
+
+// PurgeQuestion 彻底删除回收站中的题目，被试卷包含或已有作答记录的题目不能彻底删除
+func (s *TrashService) PurgeQuestion(userID, questionID int64) error {
+	question, err := s.getTrashQuestion(userID, questionID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkQuestionUnused(question); err != nil {
+		return err
+	}
+	return s.questionDAO.PermanentDeleteQuestion(questionID)
+}
+
+// PurgePaper 彻底删除回收站中的试卷，已有考试记录的试卷不能彻底删除
+func (s *TrashService) PurgePaper(userID, paperID int64) error {
+	paper, err := s.getTrashPaper(userID, paperID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkPaperUnused(paper); err != nil {
+		return err
+	}
+	return s.paperDAO.PurgePaper(paperID)
+}
+
+// getTrashQuestion 获取用户回收站中的题目
+func (s *TrashService) getTrashQuestion(userID, questionID int64) (*model.Question, error) {
+	question, err := s.questionDAO.GetDeletedQuestionByID(questionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTrashItemNotFound
+		}
+		return nil, err
+	}
+	if question.UserID != userID {
+		return nil, ErrTrashItemNotFound
+	}
+	return question, nil
+}
+
+// getTrashPaper 获取用户回收站中的试卷
+func (s *TrashService) getTrashPaper(userID, paperID int64) (*model.Paper, error) {
+	paper, err := s.paperDAO.GetDeletedPaperByID(paperID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTrashItemNotFound
+		}
+		return nil, err
+	}
+	if paper.CreatorID != userID {
+		return nil, ErrTrashItemNotFound
+	}
+	return paper, nil
+}
+
+// checkQuestionUnused 检查题目是否可以彻底删除：不被任何试卷包含，也没有作答记录
+func (s *TrashService) checkQuestionUnused(question *model.Question) error {
+	papers, err := s.paperDAO.GetPapersByQuestionID(question.ID)
+	if err != nil {
+		return err
+	}
+	if len(papers) > 0 {
+		titles := make([]string, 0, len(papers))
+		for _, paper := range papers {
+			titles = append(titles, "《"+paper.Title+"》")
+		}
+		return fmt.Errorf("%w: 题目被试卷%s包含，请先将其移出试卷或彻底删除试卷", ErrTrashItemInUse, strings.Join(titles, "、"))
+	}
+
+	answers, err := s.examDAO.CountAnswersByQuestionID(question.ID)
+	if err != nil {
+		return err
+	}
+	if answers > 0 {
+		return fmt.Errorf("%w: 题目已有%d条作答记录", ErrTrashItemInUse, answers)
+	}
+	return nil
+}
+
+// checkPaperUnused 检查试卷是否可以彻底删除：没有考试记录
+func (s *TrashService) checkPaperUnused(paper *model.Paper) error {
+	sessions, err := s.examDAO.CountSessionsByPaperID(paper.ID)
+	if err != nil {
+		return err
+	}
+	if sessions > 0 {
+		return fmt.Errorf("%w: 试卷已有%d条考试记录", ErrTrashItemInUse, sessions)
+	}
+	return nil
+}
+
+// RunPurgeWorker 定期彻底删除超过保留时间的题目和试卷，直到 stop 关闭
+func (s *TrashService) RunPurgeWorker(stop <-chan struct{}) {
+	if s.config.Retention <= 0 || s.config.PurgeInterval <= 0 {
+		log.Printf("未配置回收站保留时间，不自动清理回收站")
+		return
+	}
+
+	ticker := time.NewTicker(s.config.PurgeInterval)
+	defer ticker.Stop()
+
+	s.purgeExpired()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.purgeExpired()
+		}
+	}
+}
+
+// purgeExpired 彻底删除超过保留时间的试卷和题目
+// 先删除试卷，使只被这些试卷包含的题目可以在同一轮删除；仍被引用的项目保留在回收站中
+func (s *TrashService) purgeExpired() {
+	before := time.Now().Add(-s.config.Retention)
+	var purged, kept int
+
+	papers, err := s.paperDAO.GetPapersDeletedBefore(before)
+	if err != nil {
+		log.Printf("查询过期的回收站试卷失败: %v", err)
+		return
+	}
+	for _, paper := range papers {
+		if err := s.checkPaperUnused(paper); err != nil {
+			if errors.Is(err, ErrTrashItemInUse) {
+				kept++
+			} else {
+				log.Printf("检查试卷 %d 的引用失败: %v", paper.ID, err)
+			}
+			continue
+		}
+		if err := s.paperDAO.PurgePaper(paper.ID); err != nil {
+			log.Printf("彻底删除试卷 %d 失败: %v", paper.ID, err)
+			continue
+		}
+		purged++
+	}
+
+	questions, err := s.questionDAO.GetQuestionsDeletedBefore(before)
+	if err != nil {
+		log.Printf("查询过期的回收站题目失败: %v", err)
+		return
+	}
+	for _, question := range questions {
+		if err := s.checkQuestionUnused(question); err != nil {
+			if errors.Is(err, ErrTrashItemInUse) {
+				kept++
+			} else {
+				log.Printf("检查题目 %d 的引用失败: %v", question.ID, err)
+			}
+			continue
+		}
+		if err := s.questionDAO.PermanentDeleteQuestion(question.ID); err != nil {
+			log.Printf("彻底删除题目 %d 失败: %v", question.ID, err)
+			continue
+		}
+		purged++
+	}
+
+	if purged > 0 || kept > 0 {
+		log.Printf("回收站清理完成: 彻底删除%d项，%d项仍被引用而保留", purged, kept)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"examsystem/config"
+	"examsystem/dao"
+	"testing"
+	"time"
+)
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	paperService, paperID, questionIDs := newTestPaper(t, 3)
+	for _, id := range questionIDs[:2] {
+		if err := paperService.AddQuestionToPaper(1, paperID, id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	questionDAO := paperService.questionDAO
+	s := NewTrashService(questionDAO, paperService.paperDAO, dao.NewExamDAO(paperService.paperDAO.DB), config.TrashConfig{Retention: 24 * time.Hour})
+
+	for _, id := range []int64{questionIDs[0], questionIDs[2]} {
+		if err := questionDAO.DeleteQuestion(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := paperService.DeletePaper(1, paperID); err != nil {
+		t.Fatal(err)
+	}
+
+	questions, papers, err := s.GetTrash(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 2 || len(papers) != 1 || papers[0].QuestionCount != 2 || papers[0].ExpiresAt == nil {
+		t.Fatalf("回收站中有 %d 道题目、%d 张试卷", len(questions), len(papers))
+	}
+
+	if err := s.PurgeQuestion(2, questionIDs[2]); !errors.Is(err, ErrTrashItemNotFound) {
+		t.Fatalf("彻底删除他人的题目: err = %v", err)
+	}
+	if err := s.PurgeQuestion(1, questionIDs[0]); !errors.Is(err, ErrTrashItemInUse) {
+		t.Fatalf("彻底删除试卷中的题目: err = %v", err)
+	}
+	if err := s.PurgeQuestion(1, questionIDs[2]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreQuestion(1, questionIDs[2]); !errors.Is(err, ErrTrashItemNotFound) {
+		t.Fatalf("恢复已彻底删除的题目: err = %v", err)
+	}
+
+	// 恢复试卷时一并恢复其中已删除的题目
+	restored, err := s.RestorePaper(1, paperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 1 || restored[0] != questionIDs[0] {
+		t.Fatalf("一并恢复的题目为 %v", restored)
+	}
+	if questions, papers, err = s.GetTrash(1); err != nil || len(questions) != 0 || len(papers) != 0 {
+		t.Fatalf("恢复后回收站中有 %d 道题目、%d 张试卷, err = %v", len(questions), len(papers), err)
+	}
+}
+
+func TestTrashPurgeExpired(t *testing.T) {
+	paperService, paperID, questionIDs := newTestPaper(t, 2)
+	if err := paperService.AddQuestionToPaper(1, paperID, questionIDs[0], nil); err != nil {
+		t.Fatal(err)
+	}
+	questionDAO := paperService.questionDAO
+	s := NewTrashService(questionDAO, paperService.paperDAO, dao.NewExamDAO(paperService.paperDAO.DB), config.TrashConfig{Retention: time.Millisecond})
+
+	if err := questionDAO.DeleteQuestion(questionIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := paperService.DeletePaper(1, paperID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// 试卷先被删除，只被该试卷包含的题目在同一轮中删除
+	s.purgeExpired()
+	questions, papers, err := s.GetTrash(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 0 || len(papers) != 0 {
+		t.Fatalf("清理后回收站中有 %d 道题目、%d 张试卷", len(questions), len(papers))
+	}
+	remaining, err := questionDAO.GetQuestionsByIDs(questionIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != questionIDs[1] {
+		t.Fatalf("剩余的题目为 %v，应只保留未删除的题目", remaining)
+	}
+}